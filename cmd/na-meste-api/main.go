@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -12,12 +11,15 @@ import (
 
 	"github.com/cyberbrain-dev/na-meste-api/internal/config"
 	"github.com/cyberbrain-dev/na-meste-api/internal/database"
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/memory"
 	"github.com/cyberbrain-dev/na-meste-api/internal/database/repositories"
-	"github.com/cyberbrain-dev/na-meste-api/internal/server/endpoints"
-	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/health"
+	"github.com/cyberbrain-dev/na-meste-api/internal/logging"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server"
+	"github.com/cyberbrain-dev/na-meste-api/internal/tracing"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
 )
 
 const (
@@ -32,90 +34,113 @@ func main() {
 	// launching the slogger
 	logger := setupLogger(cfg.Env)
 
-	// some info
-	logger.Info("connecting to Postgres database...")
-
-	// connecting to the db
-	db, err := database.ConnectPostgres(cfg.PostgresConnection)
+	// setting up the tracing, the spans are dropped unless an exporter is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
-		// logging the error
-		logger.Error(
-			"connection was not successful",
-			slog.Any("err", err),
-		)
+		logger.Error("invalid config", slog.Any("err", err))
 		os.Exit(1)
 	}
 
-	rc := repositories.NewColleges(db)
-	ru := repositories.NewUsers(db)
-	ra := repositories.NewAttendances(db)
+	// the repositories of the storage chosen
+	var repos server.Repos
 
-	logger.Info("successfuly connected to Postgres database")
+	// the db, the bus of the live feed, the checks and the metrics
+	// of the storage are left nil for the memory storage
+	var (
+		db         *gorm.DB
+		bus        server.Bus
+		checks     []health.Check
+		collectors []prometheus.Collector
+	)
 
-	// initializing a router
-	router := chi.NewRouter()
+	switch cfg.Storage {
+	case "postgres":
+		// some info
+		logger.Info("connecting to Postgres database...")
+
+		// connecting to the db
+		db, err = database.ConnectPostgres(cfg.PostgresConnection)
+		if err != nil {
+			// logging the error
+			logger.Error(
+				"connection was not successful",
+				slog.Any("err", err),
+			)
+			os.Exit(1)
+		}
 
-	router.Use(middleware.RequestID)
-	router.Use(middleware.Recoverer)
+		repos.Colleges = repositories.NewColleges(db)
+		repos.Users = repositories.NewUsers(db)
+		repos.Attendances = repositories.NewAttendances(db)
+		repos.Lessons = repositories.NewLessons(db)
+		repos.Audit = repositories.NewAudit(db)
+		repos.Webhooks = repositories.NewWebhooks(db)
+		repos.Jobs = repositories.NewJobs(db)
+		repos.Guardians = repositories.NewGuardians(db)
+		repos.Notifications = repositories.NewNotifications(db)
+		repos.Transactor = database.NewTransactor(db)
+
+		// the instance is ready once the db answers and is migrated
+		checks = []health.Check{
+			{Name: "postgres", Run: func(ctx context.Context) error { return database.PingPostgres(ctx, db) }},
+			{Name: "schema", Run: func(ctx context.Context) error { return database.CheckSchemaVersion(ctx, db) }},
+		}
 
-	// ! settin' up the routes
+		// exposing the stats of the connection pool
+		poolStats, err := database.PoolStats(db)
+		if err != nil {
+			logger.Error("cannot collect the pool stats", slog.Any("err", err))
+			os.Exit(1)
+		}
+		collectors = append(collectors, poolStats)
+
+		// the live feed is shared by the instances through Postgres
+		bus = database.NewPostgresBus(logger, db, cfg.PostgresConnection, "attendance_feed")
+
+		logger.Info("successfuly connected to Postgres database")
+	case "memory":
+		logger.Warn("the records are kept in memory and are lost on exit")
+
+		store := memory.NewStore()
+
+		repos.Colleges = memory.NewColleges(store)
+		repos.Users = memory.NewUsers(store)
+		repos.Attendances = memory.NewAttendances(store)
+		repos.Lessons = memory.NewLessons(store)
+		repos.Audit = memory.NewAudit(store)
+		repos.Webhooks = memory.NewWebhooks(store)
+		repos.Jobs = memory.NewJobs(store)
+		repos.Guardians = memory.NewGuardians(store)
+		repos.Notifications = memory.NewNotifications(store)
+		repos.Transactor = memory.NewTransactor(store)
+	default:
+		logger.Error("invalid config", slog.String("storage", cfg.Storage))
+		os.Exit(1)
+	}
 
-	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("Все на месте!"))
+	app, err := server.New(server.Options{
+		Logger:     logger,
+		Config:     cfg,
+		Repos:      repos,
+		Bus:        bus,
+		Checks:     checks,
+		Collectors: collectors,
 	})
-
-	router.Post("/colleges/", endpoints.CreateCollege(logger, rc))
-	router.Post("/auth/register", endpoints.Register(logger, ru))
-	router.Post("/auth/login/", endpoints.Login(logger, ru))
-
-	// registring the attendance creation endpoint and setting a middleware
-	router.Post("/attendances/", myMw.CheckRole(
-		logger,
-		"scanner",
-		endpoints.CreateAttendance(
-			logger, ra,
-		),
-	))
-
-	// registring the attendance getter endpoint and setting a middleware
-	router.Get("/attendances/", myMw.CheckRole(
-		logger,
-		"teacher",
-		endpoints.GetAttendances(
-			logger, ra,
-		),
-	))
-	// !
-
-	logger.Info(
-		"launching the server...",
-		slog.String("address", cfg.HTTPServer.Address),
-	)
+	if err != nil {
+		logger.Error("invalid config", slog.Any("err", err))
+		os.Exit(1)
+	}
 
 	// creating a channel that is going to read interrupt signals
 	done := make(chan os.Signal, 1)
 	// making the signals be written in the channel
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
-	// creating an HTTP server
-	srv := &http.Server{
-		Addr:         cfg.HTTPServer.Address,
-		Handler:      router,
-		ReadTimeout:  cfg.HTTPServer.Timeout,
-		WriteTimeout: cfg.HTTPServer.Timeout,
-		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+	if err := app.Start(); err != nil {
+		logger.Error("failed to start server", slog.Any("err", err))
+		os.Exit(1)
 	}
 
-	// running the server in separate gorutine
-	// so the code that is under this goroutine can be executed
-	go func() {
-		if err := srv.ListenAndServe(); err != nil {
-			logger.Warn("server not running", slog.Any("err", err))
-		}
-	}()
-
-	logger.Info("server started")
-
 	// waiting for signals
 	// (and blocking the execution in the goroutine of main fuction)
 	<-done
@@ -124,29 +149,31 @@ func main() {
 	logger.Info("stopping server...")
 
 	// creating a context for shutting down
-	// the server with 10s timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// the server with 10s timeout after the drain delay
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Health.DrainDelay+10*time.Second)
 	defer cancel()
 
-	// gracefully shutting down the server...
-	// if it hasn't closed all the connections
-	// during the timeout it is shutdowned by force
-	if err := srv.Shutdown(ctx); err != nil {
+	// gracefully shutting down the server and the background jobs
+	if err := app.Shutdown(ctx); err != nil {
 		logger.Error("failed to stop server", slog.Any("err", err))
-
-		return
 	}
 
 	// disconnecting the database
-	err = database.DisconnectPostgres(db)
-	if err != nil {
-		// logging the error
-		logger.Error(
-			"unable to close connection to Postgres database",
-			slog.Any("err", err),
-		)
-	} else {
-		logger.Info("successfuly disconnected Postgres database")
+	if db != nil {
+		if err := database.DisconnectPostgres(db); err != nil {
+			// logging the error
+			logger.Error(
+				"unable to close connection to Postgres database",
+				slog.Any("err", err),
+			)
+		} else {
+			logger.Info("successfuly disconnected Postgres database")
+		}
+	}
+
+	// flushing the spans left
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("failed to flush the spans", slog.Any("err", err))
 	}
 
 	// final log
@@ -155,14 +182,15 @@ func main() {
 
 // Sets up a slog logger
 func setupLogger(env string) *slog.Logger {
-	var log *slog.Logger
+	var handler slog.Handler
 
 	switch env {
 	case envLocal:
-		log = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
 	case envProd:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo})
 	}
 
-	return log
+	// the records are written with the trace and the caller of their context
+	return slog.New(logging.NewHandler(handler))
 }
@@ -23,6 +23,9 @@ func main() {
 	// some info
 	logger.Info("Launching the migration utility...")
 
+	// the migrations may take longer than the queries of the API
+	cfg.PostgresConnection.QueryTimeout = 0
+
 	// connecting to the db
 	logger.Info("Connecting to Postgres database...")
 	db, err := database.ConnectPostgres(cfg.PostgresConnection)
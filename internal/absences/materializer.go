@@ -0,0 +1,77 @@
+// Contains the background job writing explicit absences after the lessons
+package absences
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Writes absent records for the students who have missed the ended lessons
+type Materializer struct {
+	logger      *slog.Logger
+	lessons     abstractions.LessonsRepo
+	attendances abstractions.AttendancesRepo
+	tx          abstractions.Transactor
+	events      abstractions.EventPublisher
+}
+
+// Kind of the job materializing the absences
+const JobKind = "absences.materialize"
+
+// Creates a new materializer
+func NewMaterializer(
+	logger *slog.Logger,
+	lessons abstractions.LessonsRepo,
+	attendances abstractions.AttendancesRepo,
+	tx abstractions.Transactor,
+	events abstractions.EventPublisher,
+) *Materializer {
+	return &Materializer{
+		logger:      logger.With(slog.String("job", "absences.Materializer")),
+		lessons:     lessons,
+		attendances: attendances,
+		tx:          tx,
+		events:      events,
+	}
+}
+
+// Materializes the absences of every lesson that has ended before now
+func (m *Materializer) MaterializeDue(ctx context.Context, now time.Time) error {
+	lessons, err := m.lessons.GetEndedUnmaterialized(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range lessons {
+		// the subscribers are notified in the transaction of the absences
+		var written int
+		err := m.tx.InTx(ctx, func(ctx context.Context) error {
+			var err error
+			if written, err = m.attendances.MaterializeAbsences(ctx, l.ID); err != nil || written == 0 {
+				return err
+			}
+
+			return m.events.Publish(ctx, l.CollegeID, models.EventAbsenceMaterialized, models.AbsencesMaterialized{
+				LessonID: l.ID,
+				Absences: written,
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("lesson №%d: %w", l.ID, err)
+		}
+
+		m.logger.InfoContext(
+			ctx,
+			"absences have been materialized",
+			slog.Any("lesson_id", l.ID),
+			slog.Int("absences", written),
+		)
+	}
+
+	return nil
+}
@@ -15,6 +15,21 @@ type Configuration struct {
 	Env                string             `yaml:"env"`
 	HTTPServer         HTTPServer         `yaml:"http_server"`
 	PostgresConnection PostgresConnection `yaml:"postgres_connection"`
+	OIDC               OIDC               `yaml:"oidc"`
+	LDAP               LDAP               `yaml:"ldap"`
+	Absences           Absences           `yaml:"absences"`
+	Retention          Retention          `yaml:"retention"`
+	Webhooks           Webhooks           `yaml:"webhooks"`
+	Jobs               Jobs               `yaml:"jobs"`
+	Notifications      Notifications      `yaml:"notifications"`
+	OpenAPI            OpenAPI            `yaml:"openapi"`
+	I18n               I18n               `yaml:"i18n"`
+	Health             Health             `yaml:"health"`
+	Tracing            Tracing            `yaml:"tracing"`
+
+	// Where the records are kept: postgres or memory.
+	// The memory storage loses the records on exit and is meant for demos and tests
+	Storage string `yaml:"storage" env-default:"postgres"`
 }
 
 // Represents a config for the app's server
@@ -31,6 +46,176 @@ type PostgresConnection struct {
 	Username string `yaml:"username"`
 	Password string `yaml:"password"`
 	DBName   string `yaml:"db_name"`
+
+	// How long a single query may run, 0 disables the limit
+	QueryTimeout time.Duration `yaml:"query_timeout" env-default:"5s"`
+}
+
+// Represents a config of OpenID Connect single sign-on
+type OIDC struct {
+	// How long a started login flow stays valid
+	StateTTL time.Duration `yaml:"state_ttl" env-default:"10m"`
+	// Key the started login flows are signed with.
+	// Must be the same on all the instances; a random one is used if empty
+	StateSecret string         `yaml:"state_secret"`
+	Providers   []OIDCProvider `yaml:"providers"`
+}
+
+// Represents an identity provider configured for a single college
+type OIDCProvider struct {
+	CollegeID    uint     `yaml:"college_id"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"`
+	Scopes       []string `yaml:"scopes"`
+
+	// Whether unknown users are created on their first login
+	AutoProvision bool `yaml:"auto_provision"`
+	// Role given to the auto-provisioned users
+	DefaultRole string `yaml:"default_role" env-default:"student"`
+}
+
+// Represents a config of LDAP / Active Directory authentication
+type LDAP struct {
+	Backends []LDAPBackend `yaml:"backends"`
+}
+
+// Represents a directory used for authenticating the users of a single college
+type LDAPBackend struct {
+	CollegeID uint   `yaml:"college_id"`
+	URL       string `yaml:"url"`
+	StartTLS  bool   `yaml:"start_tls"`
+
+	// Service account used for looking up the users
+	BindDN       string `yaml:"bind_dn"`
+	BindPassword string `yaml:"bind_password"`
+
+	BaseDN string `yaml:"base_dn"`
+	// Filter for finding a user, %s is replaced with the email
+	UserFilter     string `yaml:"user_filter" env-default:"(mail=%s)"`
+	NameAttribute  string `yaml:"name_attribute" env-default:"displayName"`
+	GroupAttribute string `yaml:"group_attribute" env-default:"memberOf"`
+
+	// Maps group DNs to roles, the first match wins
+	GroupRoles  []LDAPGroupRole `yaml:"group_roles"`
+	DefaultRole string          `yaml:"default_role"`
+
+	Timeout time.Duration `yaml:"timeout" env-default:"5s"`
+}
+
+// Represents a mapping of a directory group to a role
+type LDAPGroupRole struct {
+	Group string `yaml:"group"`
+	Role  string `yaml:"role"`
+}
+
+// Represents a config of the absence materialization
+type Absences struct {
+	// Cron expression of checking the ended lessons
+	Schedule string `yaml:"schedule" env-default:"* * * * *"`
+}
+
+// Represents a config of purging the soft-deleted records
+type Retention struct {
+	// How long the deleted records can be restored
+	Period time.Duration `yaml:"period" env-default:"2160h"`
+	// Cron expression of purging the expired records
+	Schedule string `yaml:"schedule" env-default:"0 * * * *"`
+}
+
+// Represents a config of the webhook deliveries
+type Webhooks struct {
+	// How often the pending deliveries are sent
+	Interval time.Duration `yaml:"interval" env-default:"5s"`
+	// How many deliveries are sent at once
+	BatchSize int `yaml:"batch_size" env-default:"20"`
+	// After how many failed attempts a delivery is given up
+	MaxAttempts int `yaml:"max_attempts" env-default:"10"`
+	// Bounds of the exponential backoff between the attempts
+	BaseBackoff time.Duration `yaml:"base_backoff" env-default:"10s"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" env-default:"6h"`
+	// How long a receiver has to respond
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+	// Whether the receivers may be on the loopback or a private network
+	AllowPrivateTargets bool `yaml:"allow_private_targets" env-default:"false"`
+}
+
+// Represents a config of the background job runner
+type Jobs struct {
+	// How many jobs are handled at once
+	Workers int `yaml:"workers" env-default:"4"`
+	// How often the queue is polled
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"1s"`
+	// How long a job may run before another worker takes it over
+	Lease time.Duration `yaml:"lease" env-default:"5m"`
+	// After how many failed attempts a job goes to the dead letters
+	MaxAttempts int `yaml:"max_attempts" env-default:"5"`
+	// Bounds of the exponential backoff between the attempts
+	BaseBackoff time.Duration `yaml:"base_backoff" env-default:"30s"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" env-default:"1h"`
+	// How long the running jobs are waited for on shutdown
+	DrainTimeout time.Duration `yaml:"drain_timeout" env-default:"20s"`
+}
+
+// Represents a config of the notification channels
+type Notifications struct {
+	SMTP     SMTP     `yaml:"smtp"`
+	Telegram Telegram `yaml:"telegram"`
+}
+
+// Represents a config of the email channel.
+// The fake driver logs the messages instead of sending them
+type SMTP struct {
+	Driver   string `yaml:"driver" env-default:"fake"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port" env-default:"587"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// Represents a config of the Telegram channel.
+// The fake driver logs the messages instead of sending them
+type Telegram struct {
+	Driver  string        `yaml:"driver" env-default:"fake"`
+	APIURL  string        `yaml:"api_url"`
+	Token   string        `yaml:"token"`
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+// Represents a config of checking the traffic against the OpenAPI document
+type OpenAPI struct {
+	// What is validated: off, requests or all.
+	// The invalid requests are rejected, the invalid responses are only logged
+	Validation string `yaml:"validation" env-default:"requests"`
+}
+
+// Represents a config of the translations of the messages
+type I18n struct {
+	// Language of the clients that accept none of ours: ru or en
+	DefaultLanguage string `yaml:"default_language" env-default:"ru"`
+}
+
+// Represents a config of the probes of the instance
+type Health struct {
+	// How long the readiness checks of the dependencies may run
+	Timeout time.Duration `yaml:"timeout" env-default:"2s"`
+	// How long the instance keeps serving once its readiness fails on the shutdown,
+	// so the load balancer stops sending the traffic before the connections are refused
+	DrainDelay time.Duration `yaml:"drain_delay" env-default:"5s"`
+}
+
+// Represents a config of the OpenTelemetry tracing.
+// The none exporter drops the spans, so the trace ids are only logged
+type Tracing struct {
+	Exporter    string `yaml:"exporter" env-default:"none"`
+	ServiceName string `yaml:"service_name" env-default:"na-meste-api"`
+	// Address of the OTLP/HTTP collector
+	Endpoint string `yaml:"endpoint" env-default:"localhost:4318"`
+	Insecure bool   `yaml:"insecure"`
+	// Share of the new traces that are sampled, the traces started by the callers keep their decision
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
 }
 
 // Loads a configuration
@@ -1,11 +1,30 @@
 package entities
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Represents an attendance record in th db
 type Attendance struct {
 	ID        uint      `gorm:"primaryKey"`
-	UserID    uint      `gorm:"<-:create;not null;constraint:OnDelete:CASCADE;"`
-	CollegeID uint      `gorm:"<-:create;not null;constraint:OnDelete:CASCADE;"`
+	UserID    uint      `gorm:"<-:create;not null;constraint:OnDelete:RESTRICT;"`
+	CollegeID uint      `gorm:"<-:create;not null;constraint:OnDelete:RESTRICT;"`
 	Date      time.Time `gorm:"not null;index"`
+
+	// location sent by the scanner
+	Latitude  *float64
+	Longitude *float64
+	Accuracy  *float64
+
+	GeofenceVerdict string `gorm:"size:20;not null;default:'unknown'"`
+
+	// lesson the attendance has been matched with
+	LessonID    *uint   `gorm:"index"`
+	Lesson      *Lesson `gorm:"constraint:OnDelete:SET NULL;"`
+	Status      string  `gorm:"size:20;not null;default:'outside_lesson'"`
+	MinutesLate int     `gorm:"not null;default:0"`
+
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
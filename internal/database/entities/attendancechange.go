@@ -0,0 +1,18 @@
+package entities
+
+import "time"
+
+// Represents a manual change of an attendance made by a teacher.
+// The records are kept even after the attendance is deleted
+type AttendanceChange struct {
+	ID           uint      `gorm:"primaryKey"`
+	AttendanceID uint      `gorm:"not null;index"`
+	LessonID     uint      `gorm:"not null;index"`
+	StudentID    uint      `gorm:"not null"`
+	ChangedBy    uint      `gorm:"not null"`
+	ChangedAt    time.Time `gorm:"not null"`
+	Action       string    `gorm:"size:20;not null;check:action IN ('mark', 'unmark', 'change_status')"`
+	OldStatus    string    `gorm:"size:20"`
+	NewStatus    string    `gorm:"size:20"`
+	Reason       string    `gorm:"size:500;not null"`
+}
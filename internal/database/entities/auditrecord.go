@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Represents a record of the audit log.
+// The table is append-only, updates and deletes are rejected by a trigger
+type AuditRecord struct {
+	ID         uint      `gorm:"primaryKey"`
+	OccurredAt time.Time `gorm:"not null;index"`
+
+	ActorID   *uint  `gorm:"index"`
+	ActorRole string `gorm:"size:20"`
+
+	Action   string `gorm:"size:200;not null;index"`
+	Entity   string `gorm:"size:50;index"`
+	EntityID string `gorm:"size:50;index"`
+
+	Before json.RawMessage `gorm:"type:jsonb"`
+	After  json.RawMessage `gorm:"type:jsonb"`
+
+	RequestID  string `gorm:"size:100"`
+	IP         string `gorm:"size:45"`
+	StatusCode int    `gorm:"not null"`
+}
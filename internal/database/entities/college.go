@@ -1,10 +1,26 @@
 // Contains all the representations of database tables
 package entities
 
+import (
+	"github.com/cyberbrain-dev/na-meste-api/pkg/geo"
+	"gorm.io/gorm"
+)
+
 // Represents a college record in db
 type College struct {
-	ID          uint   `gorm:"primaryKey"`
-	Name        string `gorm:"size:200; not null; unique"`
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"size:200; not null; uniqueIndex:idx_colleges_name,where:deleted_at IS NULL"`
+
+	Geofence     *geo.Fence `gorm:"type:jsonb; serializer:json"`
+	GeofenceMode string     `gorm:"size:10; not null; default:'flag'; check:geofence_mode IN ('flag', 'reject')"`
+
+	// minutes after the start of a lesson an arrival is still on time
+	LateGraceMinutes uint `gorm:"not null; default:5"`
+	// minutes before the start of a lesson a check-in is accepted for it
+	EarlyCheckInMinutes uint `gorm:"not null; default:15"`
+
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
 	Users       []User
 	Attendances []Attendance
 }
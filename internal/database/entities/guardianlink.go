@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+// Represents a link between a guardian and a student they can watch
+type GuardianLink struct {
+	GuardianID uint      `gorm:"primaryKey"`
+	StudentID  uint      `gorm:"primaryKey;index"`
+	CreatedAt  time.Time `gorm:"not null"`
+
+	Guardian User `gorm:"foreignKey:GuardianID;constraint:OnDelete:CASCADE;"`
+	Student  User `gorm:"foreignKey:StudentID;constraint:OnDelete:CASCADE;"`
+}
@@ -0,0 +1,22 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Represents a job of the background queue
+type Job struct {
+	ID          uint            `gorm:"primaryKey"`
+	Kind        string          `gorm:"size:100;not null;index"`
+	Payload     json.RawMessage `gorm:"type:jsonb;not null"`
+	Status      string          `gorm:"size:20;not null;default:'pending';check:status IN ('pending', 'running', 'done', 'dead')"`
+	Attempts    int             `gorm:"not null;default:0"`
+	MaxAttempts int             `gorm:"not null"`
+	RunAt       time.Time       `gorm:"not null;index"`
+	LockedUntil *time.Time
+	LastError   string    `gorm:"size:1000"`
+	UniqueKey   *string   `gorm:"size:200;uniqueIndex"`
+	CreatedAt   time.Time `gorm:"not null"`
+	FinishedAt  *time.Time
+}
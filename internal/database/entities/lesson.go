@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+// Represents a scheduled lesson record in db
+type Lesson struct {
+	ID        uint      `gorm:"primaryKey"`
+	CollegeID uint      `gorm:"not null;index;constraint:OnDelete:CASCADE;"`
+	TeacherID uint      `gorm:"not null;index"`
+	Title     string    `gorm:"size:200; not null"`
+	StartsAt  time.Time `gorm:"not null;index"`
+	EndsAt    time.Time `gorm:"not null"`
+
+	// when the absent records have been written for the lesson
+	AbsencesMaterializedAt *time.Time `gorm:"index"`
+
+	Teacher  User   `gorm:"foreignKey:TeacherID;constraint:OnDelete:CASCADE;"`
+	Students []User `gorm:"many2many:lesson_students;constraint:OnDelete:CASCADE;"`
+}
@@ -0,0 +1,22 @@
+package entities
+
+// Represents the notification channels chosen by a user
+type NotificationPreference struct {
+	UserID         uint   `gorm:"primaryKey"`
+	Email          bool   `gorm:"not null;default:true"`
+	Telegram       bool   `gorm:"not null;default:false"`
+	TelegramChatID string `gorm:"size:100"`
+	Language       string `gorm:"size:5;not null;default:'ru';check:language IN ('ru', 'en')"`
+
+	User User `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// Represents a rule of a college when the notifications are sent
+type NotificationRule struct {
+	ID        uint   `gorm:"primaryKey"`
+	CollegeID uint   `gorm:"not null;index"`
+	Kind      string `gorm:"size:30;not null;check:kind IN ('absences_in_row', 'rate_below')"`
+	Threshold int    `gorm:"not null"`
+
+	College College `gorm:"constraint:OnDelete:CASCADE;"`
+}
@@ -0,0 +1,9 @@
+package entities
+
+import "time"
+
+// Represents a version of the schema applied by the migrations
+type SchemaMigration struct {
+	Version    uint      `gorm:"primaryKey;autoIncrement:false"`
+	MigratedAt time.Time `gorm:"not null"`
+}
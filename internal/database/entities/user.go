@@ -1,14 +1,22 @@
 package entities
 
+import "gorm.io/gorm"
+
 // Represents a user record in db
 type User struct {
 	ID           uint   `gorm:"primaryKey"`
 	Username     string `gorm:"size:100; not null"`
-	Email        string `gorm:"size:200; not null; unique"`
+	Email        string `gorm:"size:200; not null; uniqueIndex:idx_users_email,where:deleted_at IS NULL"`
 	PasswordHash string `gorm:"not null"`
-	Role         string `gorm:"check:role IN ('teacher', 'scanner', 'student')"`
+	Role         string `gorm:"check:role IN ('admin', 'teacher', 'scanner', 'student', 'guardian')"`
 
 	CollegeID uint `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 
+	// teacher looking after the student
+	CuratorID *uint `gorm:"index"`
+	Curator   *User `gorm:"foreignKey:CuratorID;constraint:OnDelete:SET NULL;"`
+
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
 	Attendances []Attendance
 }
@@ -0,0 +1,38 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Represents a webhook subscription of a college
+type WebhookSubscription struct {
+	ID        uint      `gorm:"primaryKey"`
+	CollegeID uint      `gorm:"not null;index"`
+	URL       string    `gorm:"size:2000;not null"`
+	Secret    string    `gorm:"size:100;not null"`
+	Events    []string  `gorm:"type:jsonb;serializer:json;not null"`
+	Active    bool      `gorm:"not null;default:true"`
+	CreatedAt time.Time `gorm:"not null"`
+
+	College College `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// Represents a delivery of an event to a subscription.
+// The pending deliveries are the outbox, the rest is the delivery log
+type WebhookDelivery struct {
+	ID             uint            `gorm:"primaryKey"`
+	SubscriptionID uint            `gorm:"not null;index"`
+	Event          string          `gorm:"size:100;not null"`
+	Payload        json.RawMessage `gorm:"type:jsonb;not null"`
+	Status         string          `gorm:"size:20;not null;default:'pending';check:status IN ('pending', 'delivered', 'failed')"`
+	Attempts       int             `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time       `gorm:"not null;index"`
+	LastAttemptAt  *time.Time
+	LastStatusCode int
+	LastError      string    `gorm:"size:1000"`
+	CreatedAt      time.Time `gorm:"not null"`
+	DeliveredAt    *time.Time
+
+	Subscription WebhookSubscription `gorm:"constraint:OnDelete:CASCADE;"`
+}
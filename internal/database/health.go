@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/entities"
+	"gorm.io/gorm"
+)
+
+// Checks that the db answers
+func PingPostgres(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("cannot get the sql.DB from gorm db: %w", err)
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("cannot ping the db: %w", err)
+	}
+
+	return nil
+}
+
+// Checks that the schema has been migrated to the version the code expects
+func CheckSchemaVersion(ctx context.Context, db *gorm.DB) error {
+	var version uint
+	err := db.WithContext(ctx).
+		Model(&entities.SchemaMigration{}).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	if err != nil {
+		return fmt.Errorf("cannot get the schema version: %w", err)
+	}
+
+	if version != SchemaVersion {
+		return fmt.Errorf("schema is at version %d, expected %d", version, SchemaVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,437 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/geo"
+)
+
+// Represents an in-memory repository of attendances
+type Attendances struct {
+	s *Store
+}
+
+// Creates new attendances repo of the store passed
+func NewAttendances(s *Store) *Attendances {
+	return &Attendances{s: s}
+}
+
+// Creates a new attendance record
+func (r *Attendances) Create(ctx context.Context, a *models.Attendance) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	attendance := cloneAttendance(a)
+	attendance.DeletedAt = nil
+
+	if attendance.Status == "" {
+		attendance.Status = models.AttendanceStatusOutsideLesson
+	}
+	if attendance.GeofenceVerdict == "" {
+		attendance.GeofenceVerdict = geo.VerdictUnknown
+	}
+
+	if err := r.checkReferences(attendance); err != nil {
+		return fmt.Errorf("cannot create the attendance: %w", err)
+	}
+	if attendance.ID != 0 {
+		if _, ok := r.s.attendances[attendance.ID]; ok {
+			return fmt.Errorf("cannot create the attendance: %w", abstractions.ErrDuplicate)
+		}
+	}
+
+	// a scan replaces the absence written after the lesson
+	if attendance.LessonID != nil {
+		if absence := r.absence(*attendance.LessonID, attendance.UserID); absence != nil {
+			absence.DeletedAt = ptr(time.Now())
+		}
+	}
+
+	if err := r.insert(attendance); err != nil {
+		return fmt.Errorf("cannot create the attendance: %w", err)
+	}
+
+	a.ID = attendance.ID
+
+	return nil
+}
+
+// Returns attendance by its ID
+func (r *Attendances) Get(ctx context.Context, id uint) (*models.Attendance, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	a, ok := r.s.attendances[id]
+	if !ok || a.DeletedAt != nil {
+		return nil, fmt.Errorf("cannot get the attendance: %w", abstractions.ErrNotFound)
+	}
+
+	return cloneAttendance(a), nil
+}
+
+// Returns the attendances of the user and date span
+func (r *Attendances) GetByStudentAndDatespan(ctx context.Context, id uint, start time.Time, end time.Time) ([]*models.Attendance, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	attendances := r.filter(func(a *models.Attendance) bool {
+		return a.UserID == id && !a.Date.Before(start) && !a.Date.After(end)
+	})
+
+	if len(attendances) == 0 {
+		return nil, nil
+	}
+
+	return attendances, nil
+}
+
+// Returns the attendances of the lesson
+func (r *Attendances) GetByLesson(ctx context.Context, lessonID uint) ([]*models.Attendance, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	return r.filter(func(a *models.Attendance) bool {
+		return a.LessonID != nil && *a.LessonID == lessonID
+	}), nil
+}
+
+// Returns the latest attendances of the student matched with lessons
+func (r *Attendances) GetLatestByStudent(ctx context.Context, id uint, limit int) ([]*models.Attendance, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	attendances := r.filter(func(a *models.Attendance) bool {
+		return a.UserID == id && a.LessonID != nil
+	})
+
+	sort.SliceStable(attendances, func(i, j int) bool {
+		if !attendances[i].Date.Equal(attendances[j].Date) {
+			return attendances[i].Date.After(attendances[j].Date)
+		}
+
+		return attendances[i].ID > attendances[j].ID
+	})
+
+	if limit >= 0 && len(attendances) > limit {
+		attendances = attendances[:limit]
+	}
+
+	return attendances, nil
+}
+
+// Writes absent records for the lesson's students without an attendance
+func (r *Attendances) MaterializeAbsences(ctx context.Context, lessonID uint) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	lesson, ok := r.s.lessons[lessonID]
+	if !ok {
+		return 0, nil
+	}
+
+	written := 0
+	for _, studentID := range lesson.StudentIDs {
+		student, ok := r.s.users[studentID]
+		if !ok || student.DeletedAt != nil {
+			continue
+		}
+
+		if r.attended(lessonID, studentID) {
+			continue
+		}
+
+		err := r.insert(&models.Attendance{
+			UserID:          studentID,
+			CollegeID:       lesson.CollegeID,
+			Date:            lesson.StartsAt,
+			LessonID:        ptr(lessonID),
+			Status:          models.AttendanceStatusAbsent,
+			GeofenceVerdict: geo.VerdictUnknown,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("cannot materialize the absences of the lesson №%d: %w", lessonID, err)
+		}
+
+		written++
+	}
+
+	lesson.AbsencesMaterializedAt = ptr(time.Now())
+
+	return written, nil
+}
+
+// Returns the attendance of the student at the lesson
+func (r *Attendances) GetByLessonAndStudent(ctx context.Context, lessonID uint, studentID uint) (*models.Attendance, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	attendances := r.filter(func(a *models.Attendance) bool {
+		return a.LessonID != nil && *a.LessonID == lessonID && a.UserID == studentID
+	})
+
+	if len(attendances) == 0 {
+		return nil, fmt.Errorf("cannot get the attendance: %w", abstractions.ErrNotFound)
+	}
+
+	return attendances[0], nil
+}
+
+// Creates the attendance marked by a teacher and records the change
+func (r *Attendances) Mark(ctx context.Context, a *models.Attendance, c *models.AttendanceChange) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	attendance := &models.Attendance{
+		UserID:          a.UserID,
+		CollegeID:       a.CollegeID,
+		Date:            a.Date,
+		GeofenceVerdict: geo.VerdictUnknown,
+		LessonID:        clonePtr(a.LessonID),
+		Status:          a.Status,
+		MinutesLate:     a.MinutesLate,
+	}
+
+	if err := r.checkReferences(attendance); err != nil {
+		return fmt.Errorf("cannot mark the attendance: %w", err)
+	}
+	if err := r.insert(attendance); err != nil {
+		return fmt.Errorf("cannot mark the attendance: %w", err)
+	}
+
+	c.AttendanceID = attendance.ID
+	r.createChange(c)
+
+	a.ID = attendance.ID
+
+	return nil
+}
+
+// Changes the status of the attendance and records the change
+func (r *Attendances) ChangeStatus(ctx context.Context, id uint, status string, minutesLate int, c *models.AttendanceChange) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	a, ok := r.s.attendances[id]
+	if !ok || a.DeletedAt != nil {
+		return fmt.Errorf("cannot change the status of the attendance №%d: %w", id, abstractions.ErrNotFound)
+	}
+
+	// the absence stays unique for the lesson and the student
+	if status == models.AttendanceStatusAbsent && a.Status != status && a.LessonID != nil &&
+		r.absence(*a.LessonID, a.UserID) != nil {
+		return fmt.Errorf("cannot change the status of the attendance №%d: %w", id, abstractions.ErrDuplicate)
+	}
+
+	a.Status = status
+	a.MinutesLate = minutesLate
+
+	c.AttendanceID = id
+	r.createChange(c)
+
+	return nil
+}
+
+// Deletes the attendance unmarked by a teacher and records the change
+func (r *Attendances) Unmark(ctx context.Context, id uint, c *models.AttendanceChange) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if a, ok := r.s.attendances[id]; ok && a.DeletedAt == nil {
+		a.DeletedAt = ptr(time.Now())
+	}
+
+	c.AttendanceID = id
+	r.createChange(c)
+
+	return nil
+}
+
+// Returns the history of the manual changes of the attendance
+func (r *Attendances) GetHistory(ctx context.Context, id uint) ([]*models.AttendanceChange, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var changes []*models.AttendanceChange
+	for _, c := range r.s.changes {
+		if c.AttendanceID == id {
+			change := *c
+			changes = append(changes, &change)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if !changes[i].ChangedAt.Equal(changes[j].ChangedAt) {
+			return changes[i].ChangedAt.Before(changes[j].ChangedAt)
+		}
+
+		return changes[i].ID < changes[j].ID
+	})
+
+	return changes, nil
+}
+
+// Soft-deletes an attendance by an ID
+func (r *Attendances) Delete(ctx context.Context, id uint) (uint, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	a, ok := r.s.attendances[id]
+	if !ok || a.DeletedAt != nil {
+		return 0, fmt.Errorf(`not able to delete the attendance №%d: %w`, id, abstractions.ErrNotFound)
+	}
+
+	a.DeletedAt = ptr(time.Now())
+
+	return id, nil
+}
+
+// Returns the soft-deleted attendances of the college
+func (r *Attendances) GetDeleted(ctx context.Context, collegeID uint) ([]*models.Attendance, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var attendances []*models.Attendance
+	for _, a := range r.s.attendances {
+		if a.DeletedAt != nil && a.CollegeID == collegeID {
+			attendances = append(attendances, cloneAttendance(a))
+		}
+	}
+
+	sort.Slice(attendances, func(i, j int) bool {
+		return attendances[i].DeletedAt.After(*attendances[j].DeletedAt)
+	})
+
+	return attendances, nil
+}
+
+// Restores the soft-deleted attendance
+func (r *Attendances) Restore(ctx context.Context, id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	a, ok := r.s.attendances[id]
+	if !ok || a.DeletedAt == nil {
+		return fmt.Errorf("cannot restore the attendance №%d: %w", id, abstractions.ErrNotDeleted)
+	}
+
+	if a.Status == models.AttendanceStatusAbsent && a.LessonID != nil && r.absence(*a.LessonID, a.UserID) != nil {
+		return fmt.Errorf("cannot restore the attendance №%d: %w", id, abstractions.ErrDuplicate)
+	}
+
+	a.DeletedAt = nil
+
+	return nil
+}
+
+// Permanently deletes the attendances soft-deleted before the moment passed
+func (r *Attendances) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	purged := 0
+	for id, a := range r.s.attendances {
+		if a.DeletedAt != nil && a.DeletedAt.Before(before) {
+			delete(r.s.attendances, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// Checks the records the attendance refers to, the store must be locked
+func (r *Attendances) checkReferences(a *models.Attendance) error {
+	if _, ok := r.s.users[a.UserID]; !ok {
+		return abstractions.ErrForeignKey
+	}
+	if _, ok := r.s.colleges[a.CollegeID]; !ok {
+		return abstractions.ErrForeignKey
+	}
+	if a.LessonID != nil {
+		if _, ok := r.s.lessons[*a.LessonID]; !ok {
+			return abstractions.ErrForeignKey
+		}
+	}
+
+	return nil
+}
+
+// Stores the attendance keeping the absences unique, the store must be locked
+func (r *Attendances) insert(a *models.Attendance) error {
+	if a.Status == models.AttendanceStatusAbsent && a.LessonID != nil && r.absence(*a.LessonID, a.UserID) != nil {
+		return abstractions.ErrDuplicate
+	}
+
+	if a.ID == 0 {
+		a.ID = r.s.nextID("attendances")
+	}
+
+	r.s.attendances[a.ID] = a
+
+	return nil
+}
+
+// Returns the live absence of the student at the lesson, the store must be locked
+func (r *Attendances) absence(lessonID uint, studentID uint) *models.Attendance {
+	for _, a := range r.s.attendances {
+		if a.DeletedAt == nil && a.Status == models.AttendanceStatusAbsent &&
+			a.LessonID != nil && *a.LessonID == lessonID && a.UserID == studentID {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// Checks whether the student has a live attendance of the lesson, the store must be locked
+func (r *Attendances) attended(lessonID uint, studentID uint) bool {
+	for _, a := range r.s.attendances {
+		if a.DeletedAt == nil && a.LessonID != nil && *a.LessonID == lessonID && a.UserID == studentID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns copies of the live attendances matching the predicate ordered by the ID,
+// the store must be locked
+func (r *Attendances) filter(match func(a *models.Attendance) bool) []*models.Attendance {
+	var attendances []*models.Attendance
+	for _, a := range r.s.attendances {
+		if a.DeletedAt == nil && match(a) {
+			attendances = append(attendances, cloneAttendance(a))
+		}
+	}
+
+	sort.Slice(attendances, func(i, j int) bool {
+		return attendances[i].ID < attendances[j].ID
+	})
+
+	return attendances
+}
+
+// Writes a record of the manual change, the store must be locked
+func (r *Attendances) createChange(c *models.AttendanceChange) {
+	c.ID = r.s.nextID("attendance_changes")
+
+	change := *c
+	r.s.changes[change.ID] = &change
+}
+
+// Returns a copy of the attendance
+func cloneAttendance(a *models.Attendance) *models.Attendance {
+	attendance := *a
+	attendance.Latitude = clonePtr(a.Latitude)
+	attendance.Longitude = clonePtr(a.Longitude)
+	attendance.Accuracy = clonePtr(a.Accuracy)
+	attendance.LessonID = clonePtr(a.LessonID)
+	attendance.DeletedAt = clonePtr(a.DeletedAt)
+
+	return &attendance
+}
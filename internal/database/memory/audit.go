@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+)
+
+// Represents an in-memory audit log
+type Audit struct {
+	s *Store
+}
+
+// Creates a new audit log on a store passed
+func NewAudit(s *Store) *Audit {
+	return &Audit{s: s}
+}
+
+// Appends a record to the log
+func (r *Audit) Append(ctx context.Context, a *models.AuditRecord) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	record := cloneAuditRecord(a)
+	record.ID = r.s.nextID("audit_records")
+
+	r.s.audit[record.ID] = record
+	a.ID = record.ID
+
+	return nil
+}
+
+// Returns the records matching the filter, the newest first
+func (r *Audit) List(ctx context.Context, f models.AuditFilter) ([]*models.AuditRecord, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var records []*models.AuditRecord
+	for _, a := range r.s.audit {
+		if f.ActorID != nil && (a.ActorID == nil || *a.ActorID != *f.ActorID) {
+			continue
+		}
+		if f.Action != "" && a.Action != f.Action {
+			continue
+		}
+		if f.Entity != "" && a.Entity != f.Entity {
+			continue
+		}
+		if f.EntityID != "" && a.EntityID != f.EntityID {
+			continue
+		}
+		if !f.From.IsZero() && a.OccurredAt.Before(f.From) {
+			continue
+		}
+		if !f.To.IsZero() && a.OccurredAt.After(f.To) {
+			continue
+		}
+
+		records = append(records, cloneAuditRecord(a))
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].OccurredAt.Equal(records[j].OccurredAt) {
+			return records[i].OccurredAt.After(records[j].OccurredAt)
+		}
+
+		return records[i].ID > records[j].ID
+	})
+
+	return page(records, f.Limit, f.Offset), nil
+}
+
+// Returns the part of the records like LIMIT and OFFSET do,
+// a non-positive limit returns all the rest
+func page[T any](records []T, limit int, offset int) []T {
+	if offset > 0 {
+		if offset >= len(records) {
+			return nil
+		}
+
+		records = records[offset:]
+	}
+
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+
+	return records
+}
+
+// Returns a copy of the audit record
+func cloneAuditRecord(a *models.AuditRecord) *models.AuditRecord {
+	record := *a
+	record.ActorID = clonePtr(a.ActorID)
+	record.Before = slices.Clone(a.Before)
+	record.After = slices.Clone(a.After)
+
+	return &record
+}
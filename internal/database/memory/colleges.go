@@ -0,0 +1,236 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/geo"
+)
+
+// Defaults of the grace periods, the same as the ones of the db
+const (
+	defaultLateGrace    = 5 * time.Minute
+	defaultEarlyCheckIn = 15 * time.Minute
+)
+
+// Represents an in-memory repository of colleges
+type Colleges struct {
+	s *Store
+}
+
+// Creates new college repo of the store passed
+func NewColleges(s *Store) *Colleges {
+	return &Colleges{s: s}
+}
+
+// Adds a college to the store
+func (r *Colleges) Create(ctx context.Context, c *models.College) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	college := cloneCollege(c)
+	college.DeletedAt = nil
+
+	if college.GeofenceMode == "" {
+		college.GeofenceMode = models.GeofenceModeFlag
+	}
+	if college.LateGrace == 0 {
+		college.LateGrace = defaultLateGrace
+	}
+	if college.EarlyCheckIn == 0 {
+		college.EarlyCheckIn = defaultEarlyCheckIn
+	}
+
+	if err := checkGeofenceMode(college.GeofenceMode); err != nil {
+		return fmt.Errorf("cannot create the college: %w", err)
+	}
+	if r.nameTaken(college.Name, 0) {
+		return fmt.Errorf("cannot create the college: %w", abstractions.ErrDuplicate)
+	}
+
+	if college.ID == 0 {
+		college.ID = r.s.nextID("colleges")
+	} else if _, ok := r.s.colleges[college.ID]; ok {
+		return fmt.Errorf("cannot create the college: %w", abstractions.ErrDuplicate)
+	}
+
+	r.s.colleges[college.ID] = college
+	c.ID = college.ID
+
+	return nil
+}
+
+// Returns college by its name
+func (r *Colleges) Get(ctx context.Context, name string) (*models.College, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, c := range r.s.colleges {
+		if c.DeletedAt == nil && c.Name == name {
+			return cloneCollege(c), nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot get the college: %w", abstractions.ErrNotFound)
+}
+
+// Returns college by its ID
+func (r *Colleges) GetByID(ctx context.Context, id uint) (*models.College, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	c, ok := r.s.colleges[id]
+	if !ok || c.DeletedAt != nil {
+		return nil, fmt.Errorf("cannot get the college: %w", abstractions.ErrNotFound)
+	}
+
+	return cloneCollege(c), nil
+}
+
+// Sets the geofence of the college, nil fence removes it
+func (r *Colleges) SetGeofence(ctx context.Context, id uint, fence *geo.Fence, mode string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := checkGeofenceMode(mode); err != nil {
+		return fmt.Errorf("cannot set the geofence of the college №%d: %w", id, err)
+	}
+
+	if c, ok := r.s.colleges[id]; ok && c.DeletedAt == nil {
+		c.Geofence = clonePtr(fence)
+		c.GeofenceMode = mode
+	}
+
+	return nil
+}
+
+// Sets the grace periods used for classifying the arrivals
+func (r *Colleges) SetGracePeriods(ctx context.Context, id uint, lateGrace time.Duration, earlyCheckIn time.Duration) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// the db keeps the whole minutes
+	if c, ok := r.s.colleges[id]; ok && c.DeletedAt == nil {
+		c.LateGrace = lateGrace.Truncate(time.Minute)
+		c.EarlyCheckIn = earlyCheckIn.Truncate(time.Minute)
+	}
+
+	return nil
+}
+
+// Soft-deletes the college with its attendances and returns its ID
+func (r *Colleges) Delete(ctx context.Context, id uint) (uint, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.colleges[id]
+	if !ok || c.DeletedAt != nil {
+		return 0, fmt.Errorf(`not able to delete the college №%d: %w`, id, abstractions.ErrNotFound)
+	}
+
+	// the attendances get the same time, so they are restored together
+	now := time.Now()
+
+	c.DeletedAt = ptr(now)
+	for _, a := range r.s.attendances {
+		if a.CollegeID == id && a.DeletedAt == nil {
+			a.DeletedAt = ptr(now)
+		}
+	}
+
+	return id, nil
+}
+
+// Returns the college if it is soft-deleted
+func (r *Colleges) GetDeleted(ctx context.Context, collegeID uint) ([]*models.College, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var colleges []*models.College
+	for _, c := range r.s.colleges {
+		if c.DeletedAt != nil && c.ID == collegeID {
+			colleges = append(colleges, cloneCollege(c))
+		}
+	}
+
+	sort.Slice(colleges, func(i, j int) bool {
+		return colleges[i].DeletedAt.After(*colleges[j].DeletedAt)
+	})
+
+	return colleges, nil
+}
+
+// Restores the soft-deleted college with the attendances deleted along with it
+func (r *Colleges) Restore(ctx context.Context, id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	c, ok := r.s.colleges[id]
+	if !ok || c.DeletedAt == nil {
+		return fmt.Errorf("cannot restore the college №%d: %w", id, abstractions.ErrNotDeleted)
+	}
+	if r.nameTaken(c.Name, id) {
+		return fmt.Errorf("cannot restore the college №%d: %w", id, abstractions.ErrDuplicate)
+	}
+
+	for _, a := range r.s.attendances {
+		if a.CollegeID == id && a.DeletedAt != nil && a.DeletedAt.Equal(*c.DeletedAt) {
+			a.DeletedAt = nil
+		}
+	}
+	c.DeletedAt = nil
+
+	return nil
+}
+
+// Permanently deletes the colleges soft-deleted before the moment passed
+// that have no attendances left
+func (r *Colleges) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	purged := 0
+	for id, c := range r.s.colleges {
+		if c.DeletedAt != nil && c.DeletedAt.Before(before) && !r.s.collegeHasAttendances(id) {
+			r.s.purgeCollege(id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// Checks whether a college other than the one with the ID has the name,
+// the store must be locked
+func (r *Colleges) nameTaken(name string, id uint) bool {
+	for _, c := range r.s.colleges {
+		if c.ID != id && c.DeletedAt == nil && c.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Checks the mode like the check constraint of the db
+func checkGeofenceMode(mode string) error {
+	switch mode {
+	case models.GeofenceModeFlag, models.GeofenceModeReject:
+		return nil
+	default:
+		return abstractions.ErrCheckViolation
+	}
+}
+
+// Returns a copy of the college
+func cloneCollege(c *models.College) *models.College {
+	college := *c
+	college.Geofence = clonePtr(c.Geofence)
+	college.DeletedAt = clonePtr(c.DeletedAt)
+
+	return &college
+}
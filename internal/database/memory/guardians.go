@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents an in-memory repository of the links between guardians and students
+type Guardians struct {
+	s *Store
+}
+
+// Creates new guardians repo of the store passed
+func NewGuardians(s *Store) *Guardians {
+	return &Guardians{s: s}
+}
+
+// Links the student to the guardian, linking them again does nothing
+func (r *Guardians) Link(ctx context.Context, l *models.GuardianLink) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	_, guardian := r.s.users[l.GuardianID]
+	_, student := r.s.users[l.StudentID]
+	if !guardian || !student {
+		return fmt.Errorf("cannot link the student: %w", abstractions.ErrForeignKey)
+	}
+
+	key := linkKey{guardianID: l.GuardianID, studentID: l.StudentID}
+	if _, ok := r.s.links[key]; ok {
+		return nil
+	}
+
+	link := models.GuardianLink{
+		GuardianID: l.GuardianID,
+		StudentID:  l.StudentID,
+		CreatedAt:  time.Now(),
+	}
+
+	r.s.links[key] = &link
+	l.CreatedAt = link.CreatedAt
+
+	return nil
+}
+
+// Removes the link and returns false if there has been none
+func (r *Guardians) Unlink(ctx context.Context, guardianID uint, studentID uint) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	key := linkKey{guardianID: guardianID, studentID: studentID}
+	if _, ok := r.s.links[key]; !ok {
+		return false, nil
+	}
+
+	delete(r.s.links, key)
+
+	return true, nil
+}
+
+// Checks whether the student is linked to the guardian
+func (r *Guardians) IsLinked(ctx context.Context, guardianID uint, studentID uint) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	_, ok := r.s.links[linkKey{guardianID: guardianID, studentID: studentID}]
+
+	return ok, nil
+}
+
+// Returns the students linked to the guardian
+func (r *Guardians) GetStudents(ctx context.Context, guardianID uint) ([]*models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var ids []uint
+	for key := range r.s.links {
+		if key.guardianID == guardianID {
+			ids = append(ids, key.studentID)
+		}
+	}
+
+	return r.liveUsers(ids), nil
+}
+
+// Returns the guardians linked to the student
+func (r *Guardians) GetGuardians(ctx context.Context, studentID uint) ([]*models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var ids []uint
+	for key := range r.s.links {
+		if key.studentID == studentID {
+			ids = append(ids, key.guardianID)
+		}
+	}
+
+	return r.liveUsers(ids), nil
+}
+
+// Returns copies of the users with the IDs that are not deleted ordered by the ID,
+// the store must be locked
+func (r *Guardians) liveUsers(ids []uint) []*models.User {
+	var users []*models.User
+	for _, id := range ids {
+		if u, ok := r.s.users[id]; ok && u.DeletedAt == nil {
+			users = append(users, cloneUser(u))
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return users
+}
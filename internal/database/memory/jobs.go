@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents an in-memory repository of the background jobs
+type Jobs struct {
+	s *Store
+}
+
+// Creates new jobs repo of the store passed
+func NewJobs(s *Store) *Jobs {
+	return &Jobs{s: s}
+}
+
+// Adds a job to the queue, a job with a unique key that is already queued is skipped
+func (r *Jobs) Enqueue(ctx context.Context, j *models.Job) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if j.UniqueKey != nil {
+		for _, job := range r.s.jobs {
+			if job.UniqueKey != nil && *job.UniqueKey == *j.UniqueKey {
+				return false, nil
+			}
+		}
+	}
+
+	job := &models.Job{
+		ID:          r.s.nextID("jobs"),
+		Kind:        j.Kind,
+		Payload:     slices.Clone(j.Payload),
+		Status:      models.JobStatusPending,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       j.RunAt,
+		UniqueKey:   clonePtr(j.UniqueKey),
+		CreatedAt:   time.Now(),
+	}
+
+	if job.RunAt.IsZero() {
+		job.RunAt = job.CreatedAt
+	}
+
+	r.s.jobs[job.ID] = job
+	*j = *cloneJob(job)
+
+	return true, nil
+}
+
+// Returns a job by its ID
+func (r *Jobs) Get(ctx context.Context, id uint) (*models.Job, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	job, ok := r.s.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("cannot get the job: %w", abstractions.ErrNotFound)
+	}
+
+	return cloneJob(job), nil
+}
+
+// Returns the latest jobs with the status
+func (r *Jobs) GetByStatus(ctx context.Context, status string, limit int) ([]*models.Job, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var jobs []*models.Job
+	for _, job := range r.s.jobs {
+		if job.Status == status {
+			jobs = append(jobs, cloneJob(job))
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID > jobs[j].ID
+	})
+
+	return page(jobs, limit, 0), nil
+}
+
+// Claims up to limit due jobs of the kinds and marks them running
+func (r *Jobs) Claim(ctx context.Context, kinds []string, now time.Time, limit int, lease time.Duration) ([]*models.Job, error) {
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// the running jobs with a passed lease belong to a dead worker
+	var due []*models.Job
+	for _, job := range r.s.jobs {
+		if !slices.Contains(kinds, job.Kind) {
+			continue
+		}
+
+		pending := job.Status == models.JobStatusPending && !job.RunAt.After(now)
+		abandoned := job.Status == models.JobStatusRunning && job.LockedUntil != nil && !job.LockedUntil.After(now)
+
+		if pending || abandoned {
+			due = append(due, job)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].RunAt.Before(due[j].RunAt)
+	})
+
+	var jobs []*models.Job
+	for _, job := range page(due, limit, 0) {
+		job.Status = models.JobStatusRunning
+		job.Attempts++
+		job.LockedUntil = ptr(now.Add(lease))
+
+		jobs = append(jobs, cloneJob(job))
+	}
+
+	return jobs, nil
+}
+
+// Marks the job as done
+func (r *Jobs) Complete(ctx context.Context, id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if job, ok := r.s.jobs[id]; ok {
+		job.Status = models.JobStatusDone
+		job.LockedUntil = nil
+		job.LastError = ""
+		job.FinishedAt = ptr(time.Now())
+	}
+
+	return nil
+}
+
+// Records a failed attempt, the job is run again at runAt
+func (r *Jobs) Retry(ctx context.Context, id uint, runAt time.Time, errMsg string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if job, ok := r.s.jobs[id]; ok {
+		job.Status = models.JobStatusPending
+		job.RunAt = runAt
+		job.LockedUntil = nil
+		job.LastError = truncate(errMsg, 1000)
+	}
+
+	return nil
+}
+
+// Moves the job to the dead letters
+func (r *Jobs) Kill(ctx context.Context, id uint, errMsg string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if job, ok := r.s.jobs[id]; ok {
+		job.Status = models.JobStatusDead
+		job.LockedUntil = nil
+		job.LastError = truncate(errMsg, 1000)
+		job.FinishedAt = ptr(time.Now())
+	}
+
+	return nil
+}
+
+// Puts a dead job back to the queue with fresh attempts
+func (r *Jobs) Requeue(ctx context.Context, id uint, runAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if job, ok := r.s.jobs[id]; ok && job.Status == models.JobStatusDead {
+		job.Status = models.JobStatusPending
+		job.Attempts = 0
+		job.RunAt = runAt
+		job.FinishedAt = nil
+	}
+
+	return nil
+}
+
+// Permanently deletes the jobs done before the moment
+func (r *Jobs) PurgeDone(ctx context.Context, before time.Time) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	purged := 0
+	for id, job := range r.s.jobs {
+		if job.Status == models.JobStatusDone && job.FinishedAt != nil && job.FinishedAt.Before(before) {
+			delete(r.s.jobs, id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// Returns a copy of the job
+func cloneJob(j *models.Job) *models.Job {
+	job := *j
+	job.Payload = slices.Clone(j.Payload)
+	job.LockedUntil = clonePtr(j.LockedUntil)
+	job.UniqueKey = clonePtr(j.UniqueKey)
+	job.FinishedAt = clonePtr(j.FinishedAt)
+
+	return &job
+}
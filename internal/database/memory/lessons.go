@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents an in-memory repository of lessons
+type Lessons struct {
+	s *Store
+}
+
+// Creates new lessons repo of the store passed
+func NewLessons(s *Store) *Lessons {
+	return &Lessons{s: s}
+}
+
+// Adds a lesson with its students to the store
+func (r *Lessons) Create(ctx context.Context, l *models.Lesson) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.colleges[l.CollegeID]; !ok {
+		return fmt.Errorf("cannot create the lesson: %w", abstractions.ErrForeignKey)
+	}
+	if _, ok := r.s.users[l.TeacherID]; !ok {
+		return fmt.Errorf("cannot create the lesson: %w", abstractions.ErrForeignKey)
+	}
+
+	var students []uint
+	for _, id := range l.StudentIDs {
+		if _, ok := r.s.users[id]; !ok {
+			return fmt.Errorf("cannot create the lesson: %w", abstractions.ErrForeignKey)
+		}
+
+		if !slices.Contains(students, id) {
+			students = append(students, id)
+		}
+	}
+
+	lesson := &models.Lesson{
+		ID:         r.s.nextID("lessons"),
+		CollegeID:  l.CollegeID,
+		TeacherID:  l.TeacherID,
+		Title:      l.Title,
+		StartsAt:   l.StartsAt,
+		EndsAt:     l.EndsAt,
+		StudentIDs: students,
+	}
+
+	r.s.lessons[lesson.ID] = lesson
+	l.ID = lesson.ID
+
+	return nil
+}
+
+// Returns a lesson by its ID
+func (r *Lessons) Get(ctx context.Context, id uint) (*models.Lesson, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	l, ok := r.s.lessons[id]
+	if !ok {
+		return nil, fmt.Errorf("cannot get the lesson: %w", abstractions.ErrNotFound)
+	}
+
+	lesson := cloneLesson(l)
+
+	// the soft-deleted students are not loaded
+	lesson.StudentIDs = slices.DeleteFunc(lesson.StudentIDs, func(id uint) bool {
+		u, ok := r.s.users[id]
+		return !ok || u.DeletedAt != nil
+	})
+
+	return lesson, nil
+}
+
+// Returns the student's lesson that runs at the moment passed
+func (r *Lessons) GetByStudentAt(ctx context.Context, studentID uint, at time.Time, early time.Duration) (*models.Lesson, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	lessons := r.filter(func(l *models.Lesson) bool {
+		return slices.Contains(l.StudentIDs, studentID) &&
+			!l.StartsAt.After(at.Add(early)) && !l.EndsAt.Before(at)
+	})
+
+	sort.SliceStable(lessons, func(i, j int) bool {
+		return lessons[i].StartsAt.Before(lessons[j].StartsAt)
+	})
+
+	if len(lessons) == 0 {
+		return nil, nil
+	}
+
+	return lessons[0], nil
+}
+
+// Returns the lessons that have ended before the moment passed
+// and have no absences materialized yet
+func (r *Lessons) GetEndedUnmaterialized(ctx context.Context, before time.Time) ([]*models.Lesson, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	lessons := r.filter(func(l *models.Lesson) bool {
+		return !l.EndsAt.After(before) && l.AbsencesMaterializedAt == nil
+	})
+
+	sort.SliceStable(lessons, func(i, j int) bool {
+		return lessons[i].EndsAt.Before(lessons[j].EndsAt)
+	})
+
+	return lessons, nil
+}
+
+// Deletes a lesson by its ID
+func (r *Lessons) Delete(ctx context.Context, id uint) (uint, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.lessons[id]; !ok {
+		return 0, fmt.Errorf(`not able to delete the lesson №%d: %w`, id, abstractions.ErrNotFound)
+	}
+
+	r.s.purgeLesson(id)
+
+	return id, nil
+}
+
+// Returns copies of the lessons matching the predicate ordered by the ID,
+// the store must be locked
+func (r *Lessons) filter(match func(l *models.Lesson) bool) []*models.Lesson {
+	var lessons []*models.Lesson
+	for _, l := range r.s.lessons {
+		if match(l) {
+			lessons = append(lessons, cloneLesson(l))
+		}
+	}
+
+	sort.Slice(lessons, func(i, j int) bool {
+		return lessons[i].ID < lessons[j].ID
+	})
+
+	return lessons
+}
+
+// Returns a copy of the lesson
+func cloneLesson(l *models.Lesson) *models.Lesson {
+	lesson := *l
+	lesson.StudentIDs = slices.Clone(l.StudentIDs)
+	lesson.AbsencesMaterializedAt = clonePtr(l.AbsencesMaterializedAt)
+
+	return &lesson
+}
@@ -0,0 +1,27 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/memory"
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		s := memory.NewStore()
+
+		return repotest.Repos{
+			Colleges:      memory.NewColleges(s),
+			Users:         memory.NewUsers(s),
+			Attendances:   memory.NewAttendances(s),
+			Lessons:       memory.NewLessons(s),
+			Audit:         memory.NewAudit(s),
+			Webhooks:      memory.NewWebhooks(s),
+			Jobs:          memory.NewJobs(s),
+			Guardians:     memory.NewGuardians(s),
+			Notifications: memory.NewNotifications(s),
+			Transactor:    memory.NewTransactor(s),
+		}
+	})
+}
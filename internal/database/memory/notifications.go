@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents an in-memory repository of the notification preferences and rules
+type Notifications struct {
+	s *Store
+}
+
+// Creates new notifications repo of the store passed
+func NewNotifications(s *Store) *Notifications {
+	return &Notifications{s: s}
+}
+
+// Returns the preference of the user or nil if they have not set one
+func (r *Notifications) GetPreference(ctx context.Context, userID uint) (*models.NotificationPreference, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	p, ok := r.s.preferences[userID]
+	if !ok {
+		return nil, nil
+	}
+
+	preference := *p
+
+	return &preference, nil
+}
+
+// Creates or replaces the preference of the user
+func (r *Notifications) SetPreference(ctx context.Context, p *models.NotificationPreference) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.users[p.UserID]; !ok {
+		return fmt.Errorf("cannot set the preference: %w", abstractions.ErrForeignKey)
+	}
+
+	preference := *p
+	if preference.Language == "" {
+		preference.Language = models.LanguageRussian
+	}
+
+	switch preference.Language {
+	case models.LanguageRussian, models.LanguageEnglish:
+	default:
+		return fmt.Errorf("cannot set the preference: %w", abstractions.ErrCheckViolation)
+	}
+
+	r.s.preferences[p.UserID] = &preference
+
+	return nil
+}
+
+// Adds a rule to the store
+func (r *Notifications) CreateRule(ctx context.Context, rule *models.NotificationRule) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.colleges[rule.CollegeID]; !ok {
+		return fmt.Errorf("cannot create the rule: %w", abstractions.ErrForeignKey)
+	}
+
+	switch rule.Kind {
+	case models.RuleAbsencesInRow, models.RuleRateBelow:
+	default:
+		return fmt.Errorf("cannot create the rule: %w", abstractions.ErrCheckViolation)
+	}
+
+	stored := *rule
+	stored.ID = r.s.nextID("notification_rules")
+
+	r.s.rules[stored.ID] = &stored
+	rule.ID = stored.ID
+
+	return nil
+}
+
+// Returns a rule by its ID
+func (r *Notifications) GetRule(ctx context.Context, id uint) (*models.NotificationRule, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	rule, ok := r.s.rules[id]
+	if !ok {
+		return nil, fmt.Errorf("cannot get the rule: %w", abstractions.ErrNotFound)
+	}
+
+	found := *rule
+
+	return &found, nil
+}
+
+// Returns the rules of the college
+func (r *Notifications) GetRules(ctx context.Context, collegeID uint) ([]*models.NotificationRule, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var rules []*models.NotificationRule
+	for _, rule := range r.s.rules {
+		if rule.CollegeID == collegeID {
+			found := *rule
+			rules = append(rules, &found)
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+
+	return rules, nil
+}
+
+// Deletes a rule by its ID
+func (r *Notifications) DeleteRule(ctx context.Context, id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.rules[id]; !ok {
+		return fmt.Errorf("not able to delete the rule №%d: %w", id, abstractions.ErrNotFound)
+	}
+
+	delete(r.s.rules, id)
+
+	return nil
+}
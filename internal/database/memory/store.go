@@ -0,0 +1,243 @@
+// Contains the repositories keeping the records in memory.
+// They follow the semantics of the Postgres ones (uniqueness, cascades, soft deletion)
+// and are used by the tests and the demo mode. The records are lost on exit
+package memory
+
+import (
+	"sync"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+)
+
+// Represents the records shared by the repositories,
+// so the cascades can reach the records of the other repositories
+type Store struct {
+	mu sync.RWMutex
+	// held by the running transaction
+	txMu sync.Mutex
+
+	colleges      map[uint]*models.College
+	users         map[uint]*models.User
+	lessons       map[uint]*models.Lesson
+	attendances   map[uint]*models.Attendance
+	changes       map[uint]*models.AttendanceChange
+	audit         map[uint]*models.AuditRecord
+	subscriptions map[uint]*models.WebhookSubscription
+	deliveries    map[uint]*models.WebhookDelivery
+	jobs          map[uint]*models.Job
+	links         map[linkKey]*models.GuardianLink
+	preferences   map[uint]*models.NotificationPreference
+	rules         map[uint]*models.NotificationRule
+
+	// the last IDs given to the records of every table
+	sequences map[string]uint
+}
+
+// Identifies a link between a guardian and a student
+type linkKey struct {
+	guardianID uint
+	studentID  uint
+}
+
+// Creates an empty store
+func NewStore() *Store {
+	return &Store{
+		colleges:      make(map[uint]*models.College),
+		users:         make(map[uint]*models.User),
+		lessons:       make(map[uint]*models.Lesson),
+		attendances:   make(map[uint]*models.Attendance),
+		changes:       make(map[uint]*models.AttendanceChange),
+		audit:         make(map[uint]*models.AuditRecord),
+		subscriptions: make(map[uint]*models.WebhookSubscription),
+		deliveries:    make(map[uint]*models.WebhookDelivery),
+		jobs:          make(map[uint]*models.Job),
+		links:         make(map[linkKey]*models.GuardianLink),
+		preferences:   make(map[uint]*models.NotificationPreference),
+		rules:         make(map[uint]*models.NotificationRule),
+		sequences:     make(map[string]uint),
+	}
+}
+
+// Returns a copy of the records, so a failed transaction can restore them.
+// The sequences are left out, so the IDs are not given twice as in Postgres
+func (s *Store) snapshot() *Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &Store{
+		colleges:      cloneMap(s.colleges, cloneCollege),
+		users:         cloneMap(s.users, cloneUser),
+		lessons:       cloneMap(s.lessons, cloneLesson),
+		attendances:   cloneMap(s.attendances, cloneAttendance),
+		changes:       cloneMap(s.changes, clonePtr[models.AttendanceChange]),
+		audit:         cloneMap(s.audit, cloneAuditRecord),
+		subscriptions: cloneMap(s.subscriptions, cloneSubscription),
+		deliveries:    cloneMap(s.deliveries, cloneDelivery),
+		jobs:          cloneMap(s.jobs, cloneJob),
+		links:         cloneMap(s.links, clonePtr[models.GuardianLink]),
+		preferences:   cloneMap(s.preferences, clonePtr[models.NotificationPreference]),
+		rules:         cloneMap(s.rules, clonePtr[models.NotificationRule]),
+	}
+}
+
+// Replaces the records with the ones of the snapshot
+func (s *Store) restore(snapshot *Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.colleges = snapshot.colleges
+	s.users = snapshot.users
+	s.lessons = snapshot.lessons
+	s.attendances = snapshot.attendances
+	s.changes = snapshot.changes
+	s.audit = snapshot.audit
+	s.subscriptions = snapshot.subscriptions
+	s.deliveries = snapshot.deliveries
+	s.jobs = snapshot.jobs
+	s.links = snapshot.links
+	s.preferences = snapshot.preferences
+	s.rules = snapshot.rules
+}
+
+// Returns the next ID of the table, the store must be locked
+func (s *Store) nextID(table string) uint {
+	s.sequences[table]++
+	return s.sequences[table]
+}
+
+// Hard-deletes the lesson with the records referring to it, the store must be locked
+func (s *Store) purgeLesson(id uint) {
+	delete(s.lessons, id)
+
+	for _, a := range s.attendances {
+		if a.LessonID != nil && *a.LessonID == id {
+			a.LessonID = nil
+		}
+	}
+}
+
+// Hard-deletes the user with the records referring to them except the attendances,
+// which keep the user until they are purged themselves, the store must be locked
+func (s *Store) purgeUser(id uint) {
+	delete(s.users, id)
+	delete(s.preferences, id)
+
+	for lid, l := range s.lessons {
+		if l.TeacherID == id {
+			s.purgeLesson(lid)
+			continue
+		}
+
+		l.StudentIDs = without(l.StudentIDs, id)
+	}
+	for key := range s.links {
+		if key.guardianID == id || key.studentID == id {
+			delete(s.links, key)
+		}
+	}
+	for _, u := range s.users {
+		if u.CuratorID != nil && *u.CuratorID == id {
+			u.CuratorID = nil
+		}
+	}
+}
+
+// Hard-deletes the college with the records referring to it except the attendances,
+// the store must be locked
+func (s *Store) purgeCollege(id uint) {
+	delete(s.colleges, id)
+
+	for lid, l := range s.lessons {
+		if l.CollegeID == id {
+			s.purgeLesson(lid)
+		}
+	}
+	for sid, sub := range s.subscriptions {
+		if sub.CollegeID == id {
+			s.purgeSubscription(sid)
+		}
+	}
+	for rid, r := range s.rules {
+		if r.CollegeID == id {
+			delete(s.rules, rid)
+		}
+	}
+
+	// the users stay without a college
+	for _, u := range s.users {
+		if u.CollegeID == id {
+			u.CollegeID = 0
+		}
+	}
+}
+
+// Reports whether any attendance, even a deleted one, refers to the user,
+// the store must be locked
+func (s *Store) userHasAttendances(id uint) bool {
+	for _, a := range s.attendances {
+		if a.UserID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reports whether any attendance, even a deleted one, refers to the college,
+// the store must be locked
+func (s *Store) collegeHasAttendances(id uint) bool {
+	for _, a := range s.attendances {
+		if a.CollegeID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Hard-deletes the subscription with its deliveries, the store must be locked
+func (s *Store) purgeSubscription(id uint) {
+	delete(s.subscriptions, id)
+
+	for did, d := range s.deliveries {
+		if d.SubscriptionID == id {
+			delete(s.deliveries, did)
+		}
+	}
+}
+
+// Returns the IDs without the one passed
+func without(ids []uint, id uint) []uint {
+	var rest []uint
+	for _, v := range ids {
+		if v != id {
+			rest = append(rest, v)
+		}
+	}
+
+	return rest
+}
+
+// Returns a copy of the map with a copy of every record
+func cloneMap[K comparable, V any](m map[K]*V, clone func(*V) *V) map[K]*V {
+	c := make(map[K]*V, len(m))
+	for k, v := range m {
+		c[k] = clone(v)
+	}
+
+	return c
+}
+
+// Returns a pointer to a copy of the value
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// Returns a pointer to a copy of the value the pointer passed points to
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+
+	return ptr(*p)
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Key of the running transaction in the context
+type txKey struct{}
+
+// Represents a runner of the transactions of the store.
+// The transactions run one at a time and a failed one restores the records
+// as they were when it started, so the writes made outside a transaction
+// while it runs are rolled back with it
+type Transactor struct {
+	s *Store
+}
+
+// Creates a new transactor of the store
+func NewTransactor(s *Store) *Transactor {
+	return &Transactor{s: s}
+}
+
+// Runs fn in a transaction, a call within a running transaction joins it
+func (t *Transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(bool); ok {
+		return fn(ctx)
+	}
+
+	t.s.txMu.Lock()
+	defer t.s.txMu.Unlock()
+
+	snapshot := t.s.snapshot()
+
+	ctx, hooks := abstractions.WithCommitHooks(context.WithValue(ctx, txKey{}, true))
+
+	// a panic rolls back as well
+	committed := false
+	defer func() {
+		if !committed {
+			t.s.restore(snapshot)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		return err
+	}
+
+	committed = true
+	hooks.Run()
+
+	return nil
+}
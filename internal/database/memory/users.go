@@ -0,0 +1,254 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents an in-memory users repository
+type Users struct {
+	s *Store
+}
+
+// Creates a new users repository on a store passed
+func NewUsers(s *Store) *Users {
+	return &Users{s: s}
+}
+
+// Adds a new user record to the store
+func (r *Users) Create(ctx context.Context, u *models.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	user := cloneUser(u)
+	user.DeletedAt = nil
+
+	if err := checkRole(user.Role); err != nil {
+		return fmt.Errorf("cannot create the user: %w", err)
+	}
+	if _, ok := r.s.colleges[user.CollegeID]; !ok {
+		return fmt.Errorf("cannot create the user: %w", abstractions.ErrForeignKey)
+	}
+	if user.CuratorID != nil {
+		if _, ok := r.s.users[*user.CuratorID]; !ok {
+			return fmt.Errorf("cannot create the user: %w", abstractions.ErrForeignKey)
+		}
+	}
+	if r.emailTaken(user.Email, 0) {
+		return fmt.Errorf("cannot create the user: %w", abstractions.ErrDuplicate)
+	}
+
+	if user.ID == 0 {
+		user.ID = r.s.nextID("users")
+	} else if _, ok := r.s.users[user.ID]; ok {
+		return fmt.Errorf("cannot create the user: %w", abstractions.ErrDuplicate)
+	}
+
+	r.s.users[user.ID] = user
+	u.ID = user.ID
+
+	return nil
+}
+
+// Returns a user by their email
+func (r *Users) Get(ctx context.Context, email string) (*models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, u := range r.s.users {
+		if u.DeletedAt == nil && u.Email == email {
+			return cloneUser(u), nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot get the user: %w", abstractions.ErrNotFound)
+}
+
+// Returns a user by their ID
+func (r *Users) GetByID(ctx context.Context, id uint) (*models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	u, ok := r.s.users[id]
+	if !ok || u.DeletedAt != nil {
+		return nil, fmt.Errorf("cannot get the user: %w", abstractions.ErrNotFound)
+	}
+
+	return cloneUser(u), nil
+}
+
+// Updates user with the ID passed
+func (r *Users) Update(ctx context.Context, id uint, username *string, email *string) (uint, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	u, ok := r.s.users[id]
+	if !ok || u.DeletedAt != nil {
+		return id, nil
+	}
+
+	if email != nil && r.emailTaken(*email, id) {
+		return 0, fmt.Errorf("cannot update user: %w", abstractions.ErrDuplicate)
+	}
+
+	if username != nil {
+		u.Username = *username
+	}
+	if email != nil {
+		u.Email = *email
+	}
+
+	return id, nil
+}
+
+// Sets the role of the user with the ID passed
+func (r *Users) SetRole(ctx context.Context, id uint, role string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if err := checkRole(role); err != nil {
+		return fmt.Errorf("cannot update the role: %w", err)
+	}
+
+	if u, ok := r.s.users[id]; ok && u.DeletedAt == nil {
+		u.Role = role
+	}
+
+	return nil
+}
+
+// Sets the curator of the student with the ID passed, nil removes them
+func (r *Users) SetCurator(ctx context.Context, id uint, curatorID *uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if curatorID != nil {
+		if _, ok := r.s.users[*curatorID]; !ok {
+			return fmt.Errorf("cannot update the curator: %w", abstractions.ErrForeignKey)
+		}
+	}
+
+	if u, ok := r.s.users[id]; ok && u.DeletedAt == nil {
+		u.CuratorID = clonePtr(curatorID)
+	}
+
+	return nil
+}
+
+// Soft-deletes the user with their attendances
+func (r *Users) Delete(ctx context.Context, id uint) (uint, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	u, ok := r.s.users[id]
+	if !ok || u.DeletedAt != nil {
+		return 0, fmt.Errorf(`not able to delete the user: %w`, abstractions.ErrNotFound)
+	}
+
+	// the attendances get the same time, so they are restored together
+	now := time.Now()
+
+	u.DeletedAt = ptr(now)
+	for _, a := range r.s.attendances {
+		if a.UserID == id && a.DeletedAt == nil {
+			a.DeletedAt = ptr(now)
+		}
+	}
+
+	return id, nil
+}
+
+// Returns the soft-deleted users of the college
+func (r *Users) GetDeleted(ctx context.Context, collegeID uint) ([]*models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var users []*models.User
+	for _, u := range r.s.users {
+		if u.DeletedAt != nil && u.CollegeID == collegeID {
+			users = append(users, cloneUser(u))
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].DeletedAt.After(*users[j].DeletedAt)
+	})
+
+	return users, nil
+}
+
+// Restores the soft-deleted user with the attendances deleted along with them
+func (r *Users) Restore(ctx context.Context, id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	u, ok := r.s.users[id]
+	if !ok || u.DeletedAt == nil {
+		return fmt.Errorf("cannot restore the user №%d: %w", id, abstractions.ErrNotDeleted)
+	}
+	if r.emailTaken(u.Email, id) {
+		return fmt.Errorf("cannot restore the user №%d: %w", id, abstractions.ErrDuplicate)
+	}
+
+	for _, a := range r.s.attendances {
+		if a.UserID == id && a.DeletedAt != nil && a.DeletedAt.Equal(*u.DeletedAt) {
+			a.DeletedAt = nil
+		}
+	}
+	u.DeletedAt = nil
+
+	return nil
+}
+
+// Permanently deletes the users soft-deleted before the moment passed
+// that have no attendances left
+func (r *Users) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	purged := 0
+	for id, u := range r.s.users {
+		if u.DeletedAt != nil && u.DeletedAt.Before(before) && !r.s.userHasAttendances(id) {
+			r.s.purgeUser(id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// Checks whether a user other than the one with the ID has the email,
+// the store must be locked
+func (r *Users) emailTaken(email string, id uint) bool {
+	for _, u := range r.s.users {
+		if u.ID != id && u.DeletedAt == nil && u.Email == email {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Checks the role like the check constraint of the db
+func checkRole(role string) error {
+	switch role {
+	case "admin", "teacher", "scanner", "student", "guardian":
+		return nil
+	default:
+		return abstractions.ErrCheckViolation
+	}
+}
+
+// Returns a copy of the user
+func cloneUser(u *models.User) *models.User {
+	user := *u
+	user.CuratorID = clonePtr(u.CuratorID)
+	user.DeletedAt = clonePtr(u.DeletedAt)
+
+	return &user
+}
@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents an in-memory repository of webhook subscriptions and their outbox
+type Webhooks struct {
+	s *Store
+}
+
+// Creates new webhooks repo of the store passed
+func NewWebhooks(s *Store) *Webhooks {
+	return &Webhooks{s: s}
+}
+
+// Adds a subscription to the store
+func (r *Webhooks) CreateSubscription(ctx context.Context, s *models.WebhookSubscription) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.colleges[s.CollegeID]; !ok {
+		return fmt.Errorf("cannot create the subscription: %w", abstractions.ErrForeignKey)
+	}
+
+	sub := &models.WebhookSubscription{
+		ID:        r.s.nextID("webhook_subscriptions"),
+		CollegeID: s.CollegeID,
+		URL:       s.URL,
+		Secret:    s.Secret,
+		Events:    slices.Clone(s.Events),
+		Active:    true,
+		CreatedAt: time.Now(),
+	}
+
+	r.s.subscriptions[sub.ID] = sub
+
+	s.ID = sub.ID
+	s.Active = sub.Active
+	s.CreatedAt = sub.CreatedAt
+
+	return nil
+}
+
+// Returns a subscription by its ID
+func (r *Webhooks) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	sub, ok := r.s.subscriptions[id]
+	if !ok {
+		return nil, fmt.Errorf("cannot get the subscription: %w", abstractions.ErrNotFound)
+	}
+
+	return cloneSubscription(sub), nil
+}
+
+// Returns the subscriptions of the college
+func (r *Webhooks) GetSubscriptions(ctx context.Context, collegeID uint) ([]*models.WebhookSubscription, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var subs []*models.WebhookSubscription
+	for _, sub := range r.s.subscriptions {
+		if sub.CollegeID == collegeID {
+			subs = append(subs, cloneSubscription(sub))
+		}
+	}
+
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].ID < subs[j].ID
+	})
+
+	return subs, nil
+}
+
+// Deletes a subscription with its deliveries
+func (r *Webhooks) DeleteSubscription(ctx context.Context, id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.subscriptions[id]; !ok {
+		return fmt.Errorf("not able to delete the subscription №%d: %w", id, abstractions.ErrNotFound)
+	}
+
+	r.s.purgeSubscription(id)
+
+	return nil
+}
+
+// Writes a pending delivery for every active subscription of the college to the event
+func (r *Webhooks) Enqueue(ctx context.Context, collegeID uint, event string, payload []byte) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	now := time.Now()
+
+	written := 0
+	for _, sub := range r.s.subscriptions {
+		if sub.CollegeID != collegeID || !sub.Active || !slices.Contains(sub.Events, event) {
+			continue
+		}
+
+		d := &models.WebhookDelivery{
+			ID:             r.s.nextID("webhook_deliveries"),
+			SubscriptionID: sub.ID,
+			Event:          event,
+			Payload:        slices.Clone(payload),
+			Status:         models.DeliveryStatusPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		}
+
+		r.s.deliveries[d.ID] = d
+		written++
+	}
+
+	return written, nil
+}
+
+// Claims up to limit pending deliveries that are due
+func (r *Webhooks) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var due []*models.WebhookDelivery
+	for _, d := range r.s.deliveries {
+		if d.Status == models.DeliveryStatusPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+
+	due = page(due, limit, 0)
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	var deliveries []*models.WebhookDelivery
+	for _, d := range due {
+		claimed := cloneDelivery(d)
+
+		// leasing the deliveries, so they are not sent twice
+		d.NextAttemptAt = now.Add(lease)
+
+		// getting the endpoints and the secrets
+		if sub, ok := r.s.subscriptions[d.SubscriptionID]; ok {
+			claimed.URL = sub.URL
+			claimed.Secret = sub.Secret
+		}
+
+		deliveries = append(deliveries, claimed)
+	}
+
+	return deliveries, nil
+}
+
+// Marks the delivery as delivered
+func (r *Webhooks) MarkDelivered(ctx context.Context, id uint, statusCode int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	d, ok := r.s.deliveries[id]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+
+	d.Status = models.DeliveryStatusDelivered
+	d.Attempts++
+	d.LastAttemptAt = ptr(now)
+	d.LastStatusCode = statusCode
+	d.LastError = ""
+	d.DeliveredAt = ptr(now)
+
+	return nil
+}
+
+// Records a failed attempt of the delivery
+func (r *Webhooks) MarkAttemptFailed(ctx context.Context, id uint, statusCode int, errMsg string, next *time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	d, ok := r.s.deliveries[id]
+	if !ok {
+		return nil
+	}
+
+	d.Attempts++
+	d.LastAttemptAt = ptr(time.Now())
+	d.LastStatusCode = statusCode
+	d.LastError = truncate(errMsg, 1000)
+
+	if next != nil {
+		d.NextAttemptAt = *next
+	} else {
+		d.Status = models.DeliveryStatusFailed
+	}
+
+	return nil
+}
+
+// Returns the latest deliveries of the subscription
+func (r *Webhooks) GetDeliveries(ctx context.Context, subscriptionID uint, limit int) ([]*models.WebhookDelivery, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var deliveries []*models.WebhookDelivery
+	for _, d := range r.s.deliveries {
+		if d.SubscriptionID == subscriptionID {
+			deliveries = append(deliveries, cloneDelivery(d))
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+
+		return deliveries[i].ID > deliveries[j].ID
+	})
+
+	return page(deliveries, limit, 0), nil
+}
+
+// Returns a copy of the subscription
+func cloneSubscription(s *models.WebhookSubscription) *models.WebhookSubscription {
+	sub := *s
+	sub.Events = slices.Clone(s.Events)
+
+	return &sub
+}
+
+// Returns a copy of the delivery
+func cloneDelivery(d *models.WebhookDelivery) *models.WebhookDelivery {
+	delivery := *d
+	delivery.Payload = slices.Clone(d.Payload)
+	delivery.LastAttemptAt = clonePtr(d.LastAttemptAt)
+	delivery.DeliveredAt = clonePtr(d.DeliveredAt)
+
+	return &delivery
+}
+
+// Cuts the string to n bytes at most
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n]
+}
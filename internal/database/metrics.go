@@ -0,0 +1,19 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+// Returns a collector of the connection pool stats of the db
+func PoolStats(db *gorm.DB) (prometheus.Collector, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the sql.DB from gorm db: %w", err)
+	}
+
+	return collectors.NewDBStatsCollector(sqlDB, "postgres"), nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/config"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// How long the listener waits before reconnecting
+const relistenDelay = 5 * time.Second
+
+// Represents a channel of Postgres LISTEN/NOTIFY shared by the instances
+type PostgresBus struct {
+	logger  *slog.Logger
+	db      *gorm.DB
+	cfg     config.PostgresConnection
+	channel string
+}
+
+// Creates a new bus of the channel
+func NewPostgresBus(
+	logger *slog.Logger,
+	db *gorm.DB,
+	cfg config.PostgresConnection,
+	channel string,
+) *PostgresBus {
+	return &PostgresBus{
+		logger:  logger.With(slog.String("component", "database.PostgresBus"), slog.String("channel", channel)),
+		db:      db,
+		cfg:     cfg,
+		channel: channel,
+	}
+}
+
+// Sends the payload to every listener of the channel.
+// Within a transaction the payload is sent on its commit.
+// Postgres limits a payload to 8000 bytes
+func (b *PostgresBus) Notify(ctx context.Context, payload []byte) error {
+	if err := Conn(ctx, b.db).Exec("SELECT pg_notify(?, ?)", b.channel, string(payload)).Error; err != nil {
+		return fmt.Errorf("cannot notify the channel: %w", err)
+	}
+
+	return nil
+}
+
+// Listens to the channel until the context is cancelled,
+// reconnecting if the connection is lost
+func (b *PostgresBus) Listen(ctx context.Context, handle func(payload []byte)) {
+	for {
+		err := b.listen(ctx, handle)
+		if ctx.Err() != nil {
+			return
+		}
+
+		b.logger.ErrorContext(ctx, "listening has stopped, reconnecting", slog.Any("err", err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(relistenDelay):
+		}
+	}
+}
+
+// Listens on a dedicated connection until an error occurs
+func (b *PostgresBus) listen(ctx context.Context, handle func(payload []byte)) error {
+	conn, err := pgx.Connect(ctx, connectionString(b.cfg))
+	if err != nil {
+		return fmt.Errorf("cannot connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+		return fmt.Errorf("cannot listen: %w", err)
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		handle([]byte(n.Payload))
+	}
+}
@@ -3,6 +3,7 @@ package database
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/cyberbrain-dev/na-meste-api/internal/config"
 	"github.com/cyberbrain-dev/na-meste-api/internal/database/entities"
@@ -13,8 +14,28 @@ import (
 // Tries to connect to the db and returns a gorm db
 func ConnectPostgres(cfg config.PostgresConnection) (*gorm.DB, error) {
 
-	// declaring a connection string
-	dsn := fmt.Sprintf(
+	// opening the db
+	db, err := gorm.Open(postgres.Open(connectionString(cfg)), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database (check the config)")
+	}
+
+	if err := db.Use(queryTracing{}); err != nil {
+		return nil, fmt.Errorf("cannot set up the tracing of the queries: %w", err)
+	}
+
+	if cfg.QueryTimeout > 0 {
+		if err := db.Use(queryTimeout{timeout: cfg.QueryTimeout}); err != nil {
+			return nil, fmt.Errorf("cannot set the query timeout: %w", err)
+		}
+	}
+
+	return db, nil
+}
+
+// Returns the connection string of the config
+func connectionString(cfg config.PostgresConnection) string {
+	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.Host,
 		cfg.Port,
@@ -22,14 +43,6 @@ func ConnectPostgres(cfg config.PostgresConnection) (*gorm.DB, error) {
 		cfg.Password,
 		cfg.DBName,
 	)
-
-	// opening the db
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to the database (check the config)")
-	}
-
-	return db, nil
 }
 
 // Closes the connection to the postgreSQL database
@@ -46,12 +59,26 @@ func DisconnectPostgres(db *gorm.DB) error {
 	return nil
 }
 
+// Version of the schema the code expects,
+// it is raised whenever MigrateEntities changes the schema
+const SchemaVersion = 2
+
 // Migrates the entities to Postgres database
 func MigrateEntities(db *gorm.DB) error {
 	err := db.AutoMigrate(
+		&entities.SchemaMigration{},
 		&entities.College{},
 		&entities.User{},
+		&entities.Lesson{},
 		&entities.Attendance{},
+		&entities.AttendanceChange{},
+		&entities.AuditRecord{},
+		&entities.WebhookSubscription{},
+		&entities.WebhookDelivery{},
+		&entities.Job{},
+		&entities.GuardianLink{},
+		&entities.NotificationPreference{},
+		&entities.NotificationRule{},
 	)
 
 	if err != nil {
@@ -68,12 +95,23 @@ func MigrateEntities(db *gorm.DB) error {
 		ON DELETE SET NULL;
 	`)
 
+	// AutoMigrate does not update existing check constraints
+	db.Exec(`
+		ALTER TABLE users DROP CONSTRAINT chk_users_role;
+
+		ALTER TABLE users
+		ADD CONSTRAINT chk_users_role
+		CHECK (role IN ('admin', 'teacher', 'scanner', 'student', 'guardian'));
+	`)
+
+	// the attendances outlive their colleges and users until they are purged themselves,
+	// so purging a college or a user must never take them along
 	db.Exec(`
 		ALTER TABLE attendances DROP CONSTRAINT fk_colleges_attendances;
 
 		ALTER TABLE attendances
     	ADD CONSTRAINT fk_colleges_attendances
-    	FOREIGN KEY (college_id) REFERENCES colleges(id) ON DELETE CASCADE;
+    	FOREIGN KEY (college_id) REFERENCES colleges(id) ON DELETE RESTRICT;
 	`)
 
 	db.Exec(`
@@ -81,8 +119,51 @@ func MigrateEntities(db *gorm.DB) error {
 
 		ALTER TABLE attendances
     	ADD CONSTRAINT fk_users_attendances
-    	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+    	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE RESTRICT;
+	`)
+
+	// an absence can be written only once for a student and a lesson
+	db.Exec(`
+		DROP INDEX IF EXISTS idx_attendances_absences;
+
+		CREATE UNIQUE INDEX idx_attendances_absences
+		ON attendances (lesson_id, user_id)
+		WHERE status = 'absent' AND deleted_at IS NULL;
+	`)
+
+	// the names and emails are unique among the records that are not deleted,
+	// which is handled by partial indexes instead of the old constraints
+	db.Exec(`
+		ALTER TABLE colleges DROP CONSTRAINT IF EXISTS uni_colleges_name;
+		ALTER TABLE users DROP CONSTRAINT IF EXISTS uni_users_email;
 	`)
 
+	// the audit log is append-only
+	db.Exec(`
+		CREATE OR REPLACE FUNCTION audit_records_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_records is append-only';
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS audit_records_append_only ON audit_records;
+
+		CREATE TRIGGER audit_records_append_only
+		BEFORE UPDATE OR DELETE ON audit_records
+		FOR EACH ROW EXECUTE FUNCTION audit_records_append_only();
+
+		DROP TRIGGER IF EXISTS audit_records_no_truncate ON audit_records;
+
+		CREATE TRIGGER audit_records_no_truncate
+		BEFORE TRUNCATE ON audit_records
+		FOR EACH STATEMENT EXECUTE FUNCTION audit_records_append_only();
+	`)
+
+	// recording the version, so the instances can tell the schema is up to date
+	err = db.Save(&entities.SchemaMigration{Version: SchemaVersion, MigratedAt: time.Now()}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record the schema version: %w", err)
+	}
+
 	return nil
 }
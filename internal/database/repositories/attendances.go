@@ -1,11 +1,15 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/cyberbrain-dev/na-meste-api/internal/database"
 	"github.com/cyberbrain-dev/na-meste-api/internal/database/entities"
 	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/geo"
 	"gorm.io/gorm"
 )
 
@@ -20,52 +24,79 @@ func NewAttendances(db *gorm.DB) *Attendances {
 }
 
 // Creates a new attendance record
-func (r *Attendances) Create(a *models.Attendance) error {
+func (r *Attendances) Create(ctx context.Context, a *models.Attendance) error {
 	entity := entities.Attendance{
-		ID:        a.ID,
-		UserID:    a.UserID,
-		CollegeID: a.CollegeID,
-		Date:      a.Date,
+		ID:              a.ID,
+		UserID:          a.UserID,
+		CollegeID:       a.CollegeID,
+		Date:            a.Date,
+		Latitude:        a.Latitude,
+		Longitude:       a.Longitude,
+		Accuracy:        a.Accuracy,
+		GeofenceVerdict: string(a.GeofenceVerdict),
+		LessonID:        a.LessonID,
+		Status:          a.Status,
+		MinutesLate:     a.MinutesLate,
 	}
 
-	result := r.db.Create(&entity)
+	if entity.Status == "" {
+		entity.Status = models.AttendanceStatusOutsideLesson
+	}
+
+	if entity.GeofenceVerdict == "" {
+		entity.GeofenceVerdict = string(geo.VerdictUnknown)
+	}
+
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// a scan replaces the absence written after the lesson
+		if entity.LessonID != nil {
+			result := tx.
+				Where("(lesson_id = ?) AND (user_id = ?) AND (status = ?)",
+					*entity.LessonID, entity.UserID, models.AttendanceStatusAbsent).
+				Delete(&entities.Attendance{})
+
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		return tx.Create(&entity).Error
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create the attendance: %w", translateError(err))
+	}
+
+	a.ID = entity.ID
 
-	return result.Error
+	return nil
 }
 
 // Returns attendance by its ID
-func (r *Attendances) Get(id uint) (*models.Attendance, error) {
+func (r *Attendances) Get(ctx context.Context, id uint) (*models.Attendance, error) {
 	var entities []entities.Attendance
 
-	result := r.db.Where("id = ?", id).Find(&entities)
+	result := database.Conn(ctx, r.db).Where("id = ?", id).Find(&entities)
 
 	if result.Error != nil {
-		return nil, fmt.Errorf("cannot get the college: %w", result.Error)
+		return nil, fmt.Errorf("cannot get the attendance: %w", translateError(result.Error))
 	}
 
 	// if nothing has been found
 	if len(entities) == 0 {
-		return nil, nil
-	}
-
-	attendance := models.Attendance{
-		ID:        entities[0].ID,
-		UserID:    entities[0].UserID,
-		CollegeID: entities[0].CollegeID,
-		Date:      entities[0].Date,
+		return nil, fmt.Errorf("cannot get the attendance: %w", abstractions.ErrNotFound)
 	}
 
-	return &attendance, nil
+	return toAttendanceModel(&entities[0]), nil
 }
 
 // Returns the attendances of the user and date span
-func (r *Attendances) GetByStudentAndDatespan(id uint, start time.Time, end time.Time) ([]*models.Attendance, error) {
+func (r *Attendances) GetByStudentAndDatespan(ctx context.Context, id uint, start time.Time, end time.Time) ([]*models.Attendance, error) {
 	var entities []entities.Attendance
 
-	result := r.db.Where("(user_id = ?) AND (date BETWEEN ? AND ?)", id, start, end).Find(&entities)
+	result := database.Conn(ctx, r.db).Where("(user_id = ?) AND (date BETWEEN ? AND ?)", id, start, end).Find(&entities)
 
 	if result.Error != nil {
-		return nil, fmt.Errorf("cannot get the attendances: %w", result.Error)
+		return nil, fmt.Errorf("cannot get the attendances: %w", translateError(result.Error))
 	}
 
 	if len(entities) == 0 {
@@ -75,26 +106,305 @@ func (r *Attendances) GetByStudentAndDatespan(id uint, start time.Time, end time
 	var attmodels []*models.Attendance
 
 	for _, entity := range entities {
-		attmodels = append(
-			attmodels,
-			&models.Attendance{
-				ID:        entity.ID,
-				UserID:    entity.UserID,
-				CollegeID: entity.CollegeID,
-				Date:      entity.Date,
-			},
-		)
+		attmodels = append(attmodels, toAttendanceModel(&entity))
+	}
+
+	return attmodels, nil
+}
+
+// Returns the attendances of the lesson
+func (r *Attendances) GetByLesson(ctx context.Context, lessonID uint) ([]*models.Attendance, error) {
+	var entities []entities.Attendance
+
+	result := database.Conn(ctx, r.db).Where("lesson_id = ?", lessonID).Order("id").Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the attendances: %w", translateError(result.Error))
+	}
+
+	var attmodels []*models.Attendance
+	for i := range entities {
+		attmodels = append(attmodels, toAttendanceModel(&entities[i]))
+	}
+
+	return attmodels, nil
+}
+
+// Returns the latest attendances of the student matched with lessons
+func (r *Attendances) GetLatestByStudent(ctx context.Context, id uint, limit int) ([]*models.Attendance, error) {
+	var entities []entities.Attendance
+
+	result := database.Conn(ctx, r.db).
+		Where("(user_id = ?) AND (lesson_id IS NOT NULL)", id).
+		Order("date DESC, id DESC").
+		Limit(limit).
+		Find(&entities)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the attendances: %w", translateError(result.Error))
+	}
+
+	var attmodels []*models.Attendance
+	for i := range entities {
+		attmodels = append(attmodels, toAttendanceModel(&entities[i]))
 	}
 
 	return attmodels, nil
 }
 
-// Deletes an attendance by an ID
-func (r *Attendances) Delete(id uint) (uint, error) {
-	result := r.db.Where("id = ?", id).Delete(&entities.Attendance{})
+// Writes absent records for the lesson's students without an attendance
+func (r *Attendances) MaterializeAbsences(ctx context.Context, lessonID uint) (int, error) {
+	var written int64
+
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`
+			INSERT INTO attendances (user_id, college_id, date, lesson_id, status, geofence_verdict, minutes_late)
+			SELECT ls.user_id, l.college_id, l.starts_at, l.id, ?, ?, 0
+			FROM lessons l
+			JOIN lesson_students ls ON ls.lesson_id = l.id
+			JOIN users u ON u.id = ls.user_id AND u.deleted_at IS NULL
+			WHERE l.id = ?
+			AND NOT EXISTS (
+				SELECT 1 FROM attendances a
+				WHERE a.lesson_id = l.id AND a.user_id = ls.user_id AND a.deleted_at IS NULL
+			)
+			ON CONFLICT DO NOTHING
+		`, models.AttendanceStatusAbsent, geo.VerdictUnknown, lessonID)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		written = result.RowsAffected
+
+		return tx.Model(&entities.Lesson{}).
+			Where("id = ?", lessonID).
+			Update("absences_materialized_at", time.Now()).
+			Error
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("cannot materialize the absences of the lesson №%d: %w", lessonID, translateError(err))
+	}
+
+	return int(written), nil
+}
+
+// Returns the attendance of the student at the lesson
+func (r *Attendances) GetByLessonAndStudent(ctx context.Context, lessonID uint, studentID uint) (*models.Attendance, error) {
+	var entities []entities.Attendance
+
+	result := database.Conn(ctx, r.db).Where("(lesson_id = ?) AND (user_id = ?)", lessonID, studentID).Find(&entities)
+
 	if result.Error != nil {
-		return 0, fmt.Errorf(`not able to delete the attendance №%d: %w`, id, result.Error)
+		return nil, fmt.Errorf("cannot get the attendance: %w", translateError(result.Error))
+	}
+
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("cannot get the attendance: %w", abstractions.ErrNotFound)
+	}
+
+	return toAttendanceModel(&entities[0]), nil
+}
+
+// Creates the attendance marked by a teacher and records the change
+func (r *Attendances) Mark(ctx context.Context, a *models.Attendance, c *models.AttendanceChange) error {
+	entity := entities.Attendance{
+		UserID:          a.UserID,
+		CollegeID:       a.CollegeID,
+		Date:            a.Date,
+		GeofenceVerdict: string(geo.VerdictUnknown),
+		LessonID:        a.LessonID,
+		Status:          a.Status,
+		MinutesLate:     a.MinutesLate,
+	}
+
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&entity).Error; err != nil {
+			return err
+		}
+
+		c.AttendanceID = entity.ID
+
+		return createChange(tx, c)
+	})
+	if err != nil {
+		return fmt.Errorf("cannot mark the attendance: %w", translateError(err))
+	}
+
+	a.ID = entity.ID
+
+	return nil
+}
+
+// Changes the status of the attendance and records the change
+func (r *Attendances) ChangeStatus(ctx context.Context, id uint, status string, minutesLate int, c *models.AttendanceChange) error {
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entities.Attendance{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"status":       status,
+				"minutes_late": minutesLate,
+			})
+
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return abstractions.ErrNotFound
+		}
+
+		c.AttendanceID = id
+
+		return createChange(tx, c)
+	})
+	if err != nil {
+		return fmt.Errorf("cannot change the status of the attendance №%d: %w", id, translateError(err))
+	}
+
+	return nil
+}
+
+// Deletes the attendance unmarked by a teacher and records the change
+func (r *Attendances) Unmark(ctx context.Context, id uint, c *models.AttendanceChange) error {
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).Delete(&entities.Attendance{}).Error; err != nil {
+			return err
+		}
+
+		c.AttendanceID = id
+
+		return createChange(tx, c)
+	})
+	if err != nil {
+		return fmt.Errorf("cannot unmark the attendance №%d: %w", id, translateError(err))
+	}
+
+	return nil
+}
+
+// Returns the history of the manual changes of the attendance
+func (r *Attendances) GetHistory(ctx context.Context, id uint) ([]*models.AttendanceChange, error) {
+	var entities []entities.AttendanceChange
+
+	result := database.Conn(ctx, r.db).Where("attendance_id = ?", id).Order("changed_at, id").Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the history of the attendance №%d: %w", id, translateError(result.Error))
+	}
+
+	var changes []*models.AttendanceChange
+	for _, e := range entities {
+		changes = append(changes, &models.AttendanceChange{
+			ID:           e.ID,
+			AttendanceID: e.AttendanceID,
+			LessonID:     e.LessonID,
+			StudentID:    e.StudentID,
+			ChangedBy:    e.ChangedBy,
+			ChangedAt:    e.ChangedAt,
+			Action:       e.Action,
+			OldStatus:    e.OldStatus,
+			NewStatus:    e.NewStatus,
+			Reason:       e.Reason,
+		})
+	}
+
+	return changes, nil
+}
+
+// Soft-deletes an attendance by an ID
+func (r *Attendances) Delete(ctx context.Context, id uint) (uint, error) {
+	result := database.Conn(ctx, r.db).Where("id = ?", id).Delete(&entities.Attendance{})
+	if result.Error != nil {
+		return 0, fmt.Errorf(`not able to delete the attendance №%d: %w`, id, translateError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return 0, fmt.Errorf(`not able to delete the attendance №%d: %w`, id, abstractions.ErrNotFound)
 	}
 
 	return id, nil
 }
+
+// Returns the soft-deleted attendances of the college
+func (r *Attendances) GetDeleted(ctx context.Context, collegeID uint) ([]*models.Attendance, error) {
+	var entities []entities.Attendance
+
+	result := database.Conn(ctx, r.db).Unscoped().
+		Where("(deleted_at IS NOT NULL) AND (college_id = ?)", collegeID).
+		Order("deleted_at DESC").Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the deleted attendances: %w", translateError(result.Error))
+	}
+
+	var attmodels []*models.Attendance
+	for i := range entities {
+		attmodels = append(attmodels, toAttendanceModel(&entities[i]))
+	}
+
+	return attmodels, nil
+}
+
+// Restores the soft-deleted attendance
+func (r *Attendances) Restore(ctx context.Context, id uint) error {
+	result := database.Conn(ctx, r.db).Unscoped().Model(&entities.Attendance{}).
+		Where("(id = ?) AND (deleted_at IS NOT NULL)", id).
+		Update("deleted_at", nil)
+
+	if result.Error != nil {
+		return fmt.Errorf("cannot restore the attendance №%d: %w", id, translateError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("cannot restore the attendance №%d: %w", id, abstractions.ErrNotDeleted)
+	}
+
+	return nil
+}
+
+// Permanently deletes the attendances soft-deleted before the moment passed
+func (r *Attendances) Purge(ctx context.Context, before time.Time) (int, error) {
+	result := database.Conn(ctx, r.db).Unscoped().Where("deleted_at < ?", before).Delete(&entities.Attendance{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("cannot purge the attendances: %w", translateError(result.Error))
+	}
+
+	return int(result.RowsAffected), nil
+}
+
+// Converts an attendance entity to the model
+func toAttendanceModel(e *entities.Attendance) *models.Attendance {
+	return &models.Attendance{
+		ID:              e.ID,
+		UserID:          e.UserID,
+		CollegeID:       e.CollegeID,
+		Date:            e.Date,
+		Latitude:        e.Latitude,
+		Longitude:       e.Longitude,
+		Accuracy:        e.Accuracy,
+		GeofenceVerdict: geo.Verdict(e.GeofenceVerdict),
+		LessonID:        e.LessonID,
+		Status:          e.Status,
+		MinutesLate:     e.MinutesLate,
+		DeletedAt:       deletedAt(e.DeletedAt),
+	}
+}
+
+// Writes a record of the manual change in the transaction passed
+func createChange(tx *gorm.DB, c *models.AttendanceChange) error {
+	entity := entities.AttendanceChange{
+		AttendanceID: c.AttendanceID,
+		LessonID:     c.LessonID,
+		StudentID:    c.StudentID,
+		ChangedBy:    c.ChangedBy,
+		ChangedAt:    c.ChangedAt,
+		Action:       c.Action,
+		OldStatus:    c.OldStatus,
+		NewStatus:    c.NewStatus,
+		Reason:       c.Reason,
+	}
+
+	if err := tx.Create(&entity).Error; err != nil {
+		return err
+	}
+
+	c.ID = entity.ID
+
+	return nil
+}
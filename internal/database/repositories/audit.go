@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/database"
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/entities"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"gorm.io/gorm"
+)
+
+// Represents a Postgres audit log
+type Audit struct {
+	db *gorm.DB
+}
+
+// Creates a new audit log on a database passed
+func NewAudit(db *gorm.DB) *Audit {
+	return &Audit{db: db}
+}
+
+// Appends a record to the log
+func (r *Audit) Append(ctx context.Context, a *models.AuditRecord) error {
+	entity := entities.AuditRecord{
+		OccurredAt: a.OccurredAt,
+		ActorID:    a.ActorID,
+		ActorRole:  a.ActorRole,
+		Action:     a.Action,
+		Entity:     a.Entity,
+		EntityID:   a.EntityID,
+		Before:     a.Before,
+		After:      a.After,
+		RequestID:  a.RequestID,
+		IP:         a.IP,
+		StatusCode: a.StatusCode,
+	}
+
+	if err := database.Conn(ctx, r.db).Create(&entity).Error; err != nil {
+		return fmt.Errorf("cannot append the audit record: %w", translateError(err))
+	}
+
+	a.ID = entity.ID
+
+	return nil
+}
+
+// Returns the records matching the filter, the newest first
+func (r *Audit) List(ctx context.Context, f models.AuditFilter) ([]*models.AuditRecord, error) {
+	query := database.Conn(ctx, r.db).Model(&entities.AuditRecord{})
+
+	if f.ActorID != nil {
+		query = query.Where("actor_id = ?", *f.ActorID)
+	}
+	if f.Action != "" {
+		query = query.Where("action = ?", f.Action)
+	}
+	if f.Entity != "" {
+		query = query.Where("entity = ?", f.Entity)
+	}
+	if f.EntityID != "" {
+		query = query.Where("entity_id = ?", f.EntityID)
+	}
+	if !f.From.IsZero() {
+		query = query.Where("occurred_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		query = query.Where("occurred_at <= ?", f.To)
+	}
+
+	// a non-positive limit returns all the rest
+	if f.Limit > 0 {
+		query = query.Limit(f.Limit)
+	}
+
+	var entities []entities.AuditRecord
+
+	result := query.Order("occurred_at DESC, id DESC").Offset(f.Offset).Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the audit records: %w", translateError(result.Error))
+	}
+
+	var records []*models.AuditRecord
+	for _, e := range entities {
+		records = append(records, &models.AuditRecord{
+			ID:         e.ID,
+			OccurredAt: e.OccurredAt,
+			ActorID:    e.ActorID,
+			ActorRole:  e.ActorRole,
+			Action:     e.Action,
+			Entity:     e.Entity,
+			EntityID:   e.EntityID,
+			Before:     e.Before,
+			After:      e.After,
+			RequestID:  e.RequestID,
+			IP:         e.IP,
+			StatusCode: e.StatusCode,
+		})
+	}
+
+	return records, nil
+}
@@ -1,10 +1,15 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/cyberbrain-dev/na-meste-api/internal/database"
 	"github.com/cyberbrain-dev/na-meste-api/internal/database/entities"
 	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/geo"
 	"gorm.io/gorm"
 )
 
@@ -19,46 +24,189 @@ func NewColleges(db *gorm.DB) *Colleges {
 }
 
 // Adds a college to the db
-func (r *Colleges) Create(c *models.College) error {
+func (r *Colleges) Create(ctx context.Context, c *models.College) error {
 	entity := entities.College{
-		ID:   c.ID,
-		Name: c.Name,
+		ID:           c.ID,
+		Name:         c.Name,
+		Geofence:     c.Geofence,
+		GeofenceMode: c.GeofenceMode,
 	}
 
-	result := r.db.Create(&entity)
+	if entity.GeofenceMode == "" {
+		entity.GeofenceMode = models.GeofenceModeFlag
+	}
+
+	result := database.Conn(ctx, r.db).Create(&entity)
+	if result.Error != nil {
+		return fmt.Errorf("cannot create the college: %w", translateError(result.Error))
+	}
 
-	return result.Error
+	c.ID = entity.ID
+
+	return nil
 }
 
 // Returns college by its name
-func (r *Colleges) Get(name string) (*models.College, error) {
+func (r *Colleges) Get(ctx context.Context, name string) (*models.College, error) {
+	var entities []entities.College
+
+	result := database.Conn(ctx, r.db).Where("name = ?", name).Find(&entities)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the college: %w", translateError(result.Error))
+	}
+
+	// if college has not been found
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("cannot get the college: %w", abstractions.ErrNotFound)
+	}
+
+	return toCollegeModel(&entities[0]), nil
+}
+
+// Returns college by its ID
+func (r *Colleges) GetByID(ctx context.Context, id uint) (*models.College, error) {
 	var entities []entities.College
 
-	result := r.db.Where("name = ?", name).Find(&entities)
+	result := database.Conn(ctx, r.db).Where("id = ?", id).Find(&entities)
 
 	if result.Error != nil {
-		return nil, fmt.Errorf("cannot get the college: %w", result.Error)
+		return nil, fmt.Errorf("cannot get the college: %w", translateError(result.Error))
 	}
 
 	// if college has not been found
 	if len(entities) == 0 {
-		return nil, nil
+		return nil, fmt.Errorf("cannot get the college: %w", abstractions.ErrNotFound)
 	}
 
-	college := models.College{
-		ID:   entities[0].ID,
-		Name: entities[0].Name,
+	return toCollegeModel(&entities[0]), nil
+}
+
+// Sets the geofence of the college, nil fence removes it
+func (r *Colleges) SetGeofence(ctx context.Context, id uint, fence *geo.Fence, mode string) error {
+	// selecting the columns explicitly, so a nil fence is written too
+	result := database.Conn(ctx, r.db).Model(&entities.College{}).
+		Where("id = ?", id).
+		Select("geofence", "geofence_mode").
+		Updates(entities.College{Geofence: fence, GeofenceMode: mode})
+
+	if result.Error != nil {
+		return fmt.Errorf("cannot set the geofence of the college №%d: %w", id, translateError(result.Error))
 	}
 
-	return &college, nil
+	return nil
 }
 
-// Deletes the college and returns its ID
-func (r *Colleges) Delete(id uint) (uint, error) {
-	result := r.db.Where("id = ?", id).Delete(&entities.College{})
+// Sets the grace periods used for classifying the arrivals
+func (r *Colleges) SetGracePeriods(ctx context.Context, id uint, lateGrace time.Duration, earlyCheckIn time.Duration) error {
+	result := database.Conn(ctx, r.db).Model(&entities.College{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"late_grace_minutes":     uint(lateGrace.Minutes()),
+			"early_check_in_minutes": uint(earlyCheckIn.Minutes()),
+		})
+
 	if result.Error != nil {
-		return 0, fmt.Errorf(`not able to delete the college №%d: %w`, id, result.Error)
+		return fmt.Errorf("cannot set the grace periods of the college №%d: %w", id, translateError(result.Error))
+	}
+
+	return nil
+}
+
+// Soft-deletes the college with its attendances and returns its ID
+func (r *Colleges) Delete(ctx context.Context, id uint) (uint, error) {
+	now := time.Now()
+
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entities.College{}).Where("id = ?", id).Update("deleted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return abstractions.ErrNotFound
+		}
+
+		// the attendances get the same time, so they are restored together
+		return tx.Model(&entities.Attendance{}).Where("college_id = ?", id).Update("deleted_at", now).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf(`not able to delete the college №%d: %w`, id, translateError(err))
 	}
 
 	return id, nil
 }
+
+// Returns the college if it is soft-deleted
+func (r *Colleges) GetDeleted(ctx context.Context, collegeID uint) ([]*models.College, error) {
+	var entities []entities.College
+
+	result := database.Conn(ctx, r.db).Unscoped().
+		Where("(deleted_at IS NOT NULL) AND (id = ?)", collegeID).
+		Order("deleted_at DESC").Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the deleted colleges: %w", translateError(result.Error))
+	}
+
+	var colleges []*models.College
+	for i := range entities {
+		colleges = append(colleges, toCollegeModel(&entities[i]))
+	}
+
+	return colleges, nil
+}
+
+// Restores the soft-deleted college with the attendances deleted along with it
+func (r *Colleges) Restore(ctx context.Context, id uint) error {
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var college entities.College
+
+		result := tx.Unscoped().Where("(id = ?) AND (deleted_at IS NOT NULL)", id).Limit(1).Find(&college)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return abstractions.ErrNotDeleted
+		}
+
+		err := tx.Unscoped().Model(&entities.Attendance{}).
+			Where("(college_id = ?) AND (deleted_at = ?)", id, college.DeletedAt).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Model(&entities.College{}).Where("id = ?", id).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return fmt.Errorf("cannot restore the college №%d: %w", id, translateError(err))
+	}
+
+	return nil
+}
+
+// Permanently deletes the colleges soft-deleted before the moment passed
+// that have no attendances left
+func (r *Colleges) Purge(ctx context.Context, before time.Time) (int, error) {
+	result := database.Conn(ctx, r.db).Unscoped().
+		Where("deleted_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM attendances WHERE attendances.college_id = colleges.id)").
+		Delete(&entities.College{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("cannot purge the colleges: %w", translateError(result.Error))
+	}
+
+	return int(result.RowsAffected), nil
+}
+
+// Converts a college entity to the model
+func toCollegeModel(e *entities.College) *models.College {
+	return &models.College{
+		ID:           e.ID,
+		Name:         e.Name,
+		Geofence:     e.Geofence,
+		GeofenceMode: e.GeofenceMode,
+		LateGrace:    time.Duration(e.LateGraceMinutes) * time.Minute,
+		EarlyCheckIn: time.Duration(e.EarlyCheckInMinutes) * time.Minute,
+		DeletedAt:    deletedAt(e.DeletedAt),
+	}
+}
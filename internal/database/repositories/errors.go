@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Codes of the Postgres errors translated by the repositories
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+)
+
+// Wraps the error of the storage into the error of the repositories,
+// the original error stays in the chain, so it is still logged
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return fmt.Errorf("%w: %w", abstractions.ErrDuplicate, err)
+		case pgForeignKeyViolation:
+			return fmt.Errorf("%w: %w", abstractions.ErrForeignKey, err)
+		case pgCheckViolation:
+			return fmt.Errorf("%w: %w", abstractions.ErrCheckViolation, err)
+		}
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %w", abstractions.ErrNotFound, err)
+	}
+
+	return err
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/database"
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/entities"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Represents a repository of the links between guardians and students
+type Guardians struct {
+	db *gorm.DB
+}
+
+// Creates new guardians repo of the db passed
+func NewGuardians(db *gorm.DB) *Guardians {
+	return &Guardians{db: db}
+}
+
+// Links the student to the guardian, linking them again does nothing
+func (r *Guardians) Link(ctx context.Context, l *models.GuardianLink) error {
+	entity := entities.GuardianLink{
+		GuardianID: l.GuardianID,
+		StudentID:  l.StudentID,
+		CreatedAt:  time.Now(),
+	}
+
+	result := database.Conn(ctx, r.db).
+		Omit("Guardian", "Student").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity)
+
+	if result.Error != nil {
+		return fmt.Errorf("cannot link the student: %w", translateError(result.Error))
+	}
+
+	l.CreatedAt = entity.CreatedAt
+
+	return nil
+}
+
+// Removes the link and returns false if there has been none
+func (r *Guardians) Unlink(ctx context.Context, guardianID uint, studentID uint) (bool, error) {
+	result := database.Conn(ctx, r.db).
+		Where("(guardian_id = ?) AND (student_id = ?)", guardianID, studentID).
+		Delete(&entities.GuardianLink{})
+
+	if result.Error != nil {
+		return false, fmt.Errorf("cannot unlink the student: %w", translateError(result.Error))
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// Checks whether the student is linked to the guardian
+func (r *Guardians) IsLinked(ctx context.Context, guardianID uint, studentID uint) (bool, error) {
+	var count int64
+
+	result := database.Conn(ctx, r.db).Model(&entities.GuardianLink{}).
+		Where("(guardian_id = ?) AND (student_id = ?)", guardianID, studentID).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("cannot check the link: %w", translateError(result.Error))
+	}
+
+	return count > 0, nil
+}
+
+// Returns the students linked to the guardian
+func (r *Guardians) GetStudents(ctx context.Context, guardianID uint) ([]*models.User, error) {
+	var students []entities.User
+
+	// the soft-deleted students are skipped by gorm
+	result := database.Conn(ctx, r.db).
+		Joins("JOIN guardian_links ON guardian_links.student_id = users.id").
+		Where("guardian_links.guardian_id = ?", guardianID).
+		Order("users.id").
+		Find(&students)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the students: %w", translateError(result.Error))
+	}
+
+	var users []*models.User
+	for i := range students {
+		users = append(users, toUserModel(&students[i]))
+	}
+
+	return users, nil
+}
+
+// Returns the guardians linked to the student
+func (r *Guardians) GetGuardians(ctx context.Context, studentID uint) ([]*models.User, error) {
+	var guardians []entities.User
+
+	result := database.Conn(ctx, r.db).
+		Joins("JOIN guardian_links ON guardian_links.guardian_id = users.id").
+		Where("guardian_links.student_id = ?", studentID).
+		Order("users.id").
+		Find(&guardians)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the guardians: %w", translateError(result.Error))
+	}
+
+	var users []*models.User
+	for i := range guardians {
+		users = append(users, toUserModel(&guardians[i]))
+	}
+
+	return users, nil
+}
@@ -0,0 +1,260 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/database"
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/entities"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Represents a repository of the background jobs
+type Jobs struct {
+	db *gorm.DB
+}
+
+// Creates new jobs repo of the db passed
+func NewJobs(db *gorm.DB) *Jobs {
+	return &Jobs{db: db}
+}
+
+// Adds a job to the queue, a job with a unique key that is already queued is skipped
+func (r *Jobs) Enqueue(ctx context.Context, j *models.Job) (bool, error) {
+	entity := entities.Job{
+		Kind:        j.Kind,
+		Payload:     j.Payload,
+		Status:      models.JobStatusPending,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       j.RunAt,
+		UniqueKey:   j.UniqueKey,
+		CreatedAt:   time.Now(),
+	}
+
+	if entity.RunAt.IsZero() {
+		entity.RunAt = entity.CreatedAt
+	}
+
+	result := database.Conn(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("cannot enqueue the job: %w", translateError(result.Error))
+	}
+
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	*j = *toJobModel(&entity)
+
+	return true, nil
+}
+
+// Returns a job by its ID
+func (r *Jobs) Get(ctx context.Context, id uint) (*models.Job, error) {
+	var entities []entities.Job
+
+	result := database.Conn(ctx, r.db).Where("id = ?", id).Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the job: %w", translateError(result.Error))
+	}
+
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("cannot get the job: %w", abstractions.ErrNotFound)
+	}
+
+	return toJobModel(&entities[0]), nil
+}
+
+// Returns the latest jobs with the status
+func (r *Jobs) GetByStatus(ctx context.Context, status string, limit int) ([]*models.Job, error) {
+	var entities []entities.Job
+
+	result := database.Conn(ctx, r.db).
+		Where("status = ?", status).
+		Order("id DESC").
+		Limit(limit).
+		Find(&entities)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the jobs: %w", translateError(result.Error))
+	}
+
+	var jobs []*models.Job
+	for i := range entities {
+		jobs = append(jobs, toJobModel(&entities[i]))
+	}
+
+	return jobs, nil
+}
+
+// Claims up to limit due jobs of the kinds and marks them running
+func (r *Jobs) Claim(ctx context.Context, kinds []string, now time.Time, limit int, lease time.Duration) ([]*models.Job, error) {
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+
+	var claimed []entities.Job
+
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// the rows locked by other workers are skipped,
+		// the running jobs with a passed lease belong to a dead worker
+		result := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("kind IN ?", kinds).
+			Where(
+				"((status = ?) AND (run_at <= ?)) OR ((status = ?) AND (locked_until <= ?))",
+				models.JobStatusPending, now,
+				models.JobStatusRunning, now,
+			).
+			Order("run_at").
+			Limit(limit).
+			Find(&claimed)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if len(claimed) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(claimed))
+		for i := range claimed {
+			ids[i] = claimed[i].ID
+
+			claimed[i].Status = models.JobStatusRunning
+			claimed[i].Attempts++
+			lockedUntil := now.Add(lease)
+			claimed[i].LockedUntil = &lockedUntil
+		}
+
+		return tx.Model(&entities.Job{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":       models.JobStatusRunning,
+				"attempts":     gorm.Expr("attempts + 1"),
+				"locked_until": now.Add(lease),
+			}).
+			Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot claim the jobs: %w", translateError(err))
+	}
+
+	var jobs []*models.Job
+	for i := range claimed {
+		jobs = append(jobs, toJobModel(&claimed[i]))
+	}
+
+	return jobs, nil
+}
+
+// Marks the job as done
+func (r *Jobs) Complete(ctx context.Context, id uint) error {
+	result := database.Conn(ctx, r.db).Model(&entities.Job{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusDone,
+			"locked_until": nil,
+			"last_error":   "",
+			"finished_at":  time.Now(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("cannot complete the job №%d: %w", id, translateError(result.Error))
+	}
+
+	return nil
+}
+
+// Records a failed attempt, the job is run again at runAt
+func (r *Jobs) Retry(ctx context.Context, id uint, runAt time.Time, errMsg string) error {
+	result := database.Conn(ctx, r.db).Model(&entities.Job{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusPending,
+			"run_at":       runAt,
+			"locked_until": nil,
+			"last_error":   truncate(errMsg, 1000),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("cannot retry the job №%d: %w", id, translateError(result.Error))
+	}
+
+	return nil
+}
+
+// Moves the job to the dead letters
+func (r *Jobs) Kill(ctx context.Context, id uint, errMsg string) error {
+	result := database.Conn(ctx, r.db).Model(&entities.Job{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       models.JobStatusDead,
+			"locked_until": nil,
+			"last_error":   truncate(errMsg, 1000),
+			"finished_at":  time.Now(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("cannot kill the job №%d: %w", id, translateError(result.Error))
+	}
+
+	return nil
+}
+
+// Puts a dead job back to the queue with fresh attempts
+func (r *Jobs) Requeue(ctx context.Context, id uint, runAt time.Time) error {
+	result := database.Conn(ctx, r.db).Model(&entities.Job{}).
+		Where("(id = ?) AND (status = ?)", id, models.JobStatusDead).
+		Updates(map[string]interface{}{
+			"status":      models.JobStatusPending,
+			"attempts":    0,
+			"run_at":      runAt,
+			"finished_at": nil,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("cannot requeue the job №%d: %w", id, translateError(result.Error))
+	}
+
+	return nil
+}
+
+// Permanently deletes the jobs done before the moment
+func (r *Jobs) PurgeDone(ctx context.Context, before time.Time) (int, error) {
+	result := database.Conn(ctx, r.db).
+		Where("(status = ?) AND (finished_at < ?)", models.JobStatusDone, before).
+		Delete(&entities.Job{})
+
+	if result.Error != nil {
+		return 0, fmt.Errorf("cannot purge the done jobs: %w", translateError(result.Error))
+	}
+
+	return int(result.RowsAffected), nil
+}
+
+// Converts a job entity to the model
+func toJobModel(e *entities.Job) *models.Job {
+	return &models.Job{
+		ID:          e.ID,
+		Kind:        e.Kind,
+		Payload:     e.Payload,
+		Status:      e.Status,
+		Attempts:    e.Attempts,
+		MaxAttempts: e.MaxAttempts,
+		RunAt:       e.RunAt,
+		LockedUntil: e.LockedUntil,
+		LastError:   e.LastError,
+		UniqueKey:   e.UniqueKey,
+		CreatedAt:   e.CreatedAt,
+		FinishedAt:  e.FinishedAt,
+	}
+}
@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/database"
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/entities"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"gorm.io/gorm"
+)
+
+// Represents a repository of lessons
+type Lessons struct {
+	db *gorm.DB
+}
+
+// Creates new lessons repo of the db passed
+func NewLessons(db *gorm.DB) *Lessons {
+	return &Lessons{db: db}
+}
+
+// Adds a lesson with its students to the db
+func (r *Lessons) Create(ctx context.Context, l *models.Lesson) error {
+	entity := entities.Lesson{
+		CollegeID: l.CollegeID,
+		TeacherID: l.TeacherID,
+		Title:     l.Title,
+		StartsAt:  l.StartsAt,
+		EndsAt:    l.EndsAt,
+	}
+
+	for _, id := range l.StudentIDs {
+		entity.Students = append(entity.Students, entities.User{ID: id})
+	}
+
+	// the students exist already, so only the links are created
+	result := database.Conn(ctx, r.db).Omit("Students.*").Create(&entity)
+	if result.Error != nil {
+		return fmt.Errorf("cannot create the lesson: %w", translateError(result.Error))
+	}
+
+	l.ID = entity.ID
+
+	return nil
+}
+
+// Returns a lesson by its ID
+func (r *Lessons) Get(ctx context.Context, id uint) (*models.Lesson, error) {
+	var entities []entities.Lesson
+
+	result := database.Conn(ctx, r.db).Preload("Students").Where("id = ?", id).Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the lesson: %w", translateError(result.Error))
+	}
+
+	// if nothing has been found
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("cannot get the lesson: %w", abstractions.ErrNotFound)
+	}
+
+	return toLessonModel(&entities[0]), nil
+}
+
+// Returns the student's lesson that runs at the moment passed
+func (r *Lessons) GetByStudentAt(ctx context.Context, studentID uint, at time.Time, early time.Duration) (*models.Lesson, error) {
+	var entities []entities.Lesson
+
+	result := database.Conn(ctx, r.db).
+		Joins("JOIN lesson_students ON lesson_students.lesson_id = lessons.id").
+		Where("lesson_students.user_id = ?", studentID).
+		Where("(lessons.starts_at <= ?) AND (lessons.ends_at >= ?)", at.Add(early), at).
+		Order("lessons.starts_at").
+		Limit(1).
+		Find(&entities)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the lesson: %w", translateError(result.Error))
+	}
+
+	if len(entities) == 0 {
+		return nil, nil
+	}
+
+	return toLessonModel(&entities[0]), nil
+}
+
+// Returns the lessons that have ended before the moment passed
+// and have no absences materialized yet
+func (r *Lessons) GetEndedUnmaterialized(ctx context.Context, before time.Time) ([]*models.Lesson, error) {
+	var entities []entities.Lesson
+
+	result := database.Conn(ctx, r.db).
+		Where("(ends_at <= ?) AND (absences_materialized_at IS NULL)", before).
+		Order("ends_at").
+		Find(&entities)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the ended lessons: %w", translateError(result.Error))
+	}
+
+	var lessons []*models.Lesson
+	for i := range entities {
+		lessons = append(lessons, toLessonModel(&entities[i]))
+	}
+
+	return lessons, nil
+}
+
+// Deletes a lesson by its ID
+func (r *Lessons) Delete(ctx context.Context, id uint) (uint, error) {
+	result := database.Conn(ctx, r.db).Where("id = ?", id).Delete(&entities.Lesson{})
+	if result.Error != nil {
+		return 0, fmt.Errorf(`not able to delete the lesson №%d: %w`, id, translateError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return 0, fmt.Errorf(`not able to delete the lesson №%d: %w`, id, abstractions.ErrNotFound)
+	}
+
+	return id, nil
+}
+
+// Converts a lesson entity to the model
+func toLessonModel(e *entities.Lesson) *models.Lesson {
+	lesson := models.Lesson{
+		ID:        e.ID,
+		CollegeID: e.CollegeID,
+		TeacherID: e.TeacherID,
+		Title:     e.Title,
+		StartsAt:  e.StartsAt,
+		EndsAt:    e.EndsAt,
+
+		AbsencesMaterializedAt: e.AbsencesMaterializedAt,
+	}
+
+	for _, s := range e.Students {
+		lesson.StudentIDs = append(lesson.StudentIDs, s.ID)
+	}
+
+	return &lesson
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/database"
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/entities"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Represents a repository of the notification preferences and rules
+type Notifications struct {
+	db *gorm.DB
+}
+
+// Creates new notifications repo of the db passed
+func NewNotifications(db *gorm.DB) *Notifications {
+	return &Notifications{db: db}
+}
+
+// Returns the preference of the user or nil if they have not set one
+func (r *Notifications) GetPreference(ctx context.Context, userID uint) (*models.NotificationPreference, error) {
+	var entities []entities.NotificationPreference
+
+	result := database.Conn(ctx, r.db).Where("user_id = ?", userID).Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the preference: %w", translateError(result.Error))
+	}
+
+	if len(entities) == 0 {
+		return nil, nil
+	}
+
+	e := entities[0]
+
+	return &models.NotificationPreference{
+		UserID:         e.UserID,
+		Email:          e.Email,
+		Telegram:       e.Telegram,
+		TelegramChatID: e.TelegramChatID,
+		Language:       e.Language,
+	}, nil
+}
+
+// Creates or replaces the preference of the user
+func (r *Notifications) SetPreference(ctx context.Context, p *models.NotificationPreference) error {
+	language := p.Language
+	if language == "" {
+		language = models.LanguageRussian
+	}
+
+	// a map is written, since gorm replaces the channels turned off
+	// with the defaults of the columns when a struct is
+	result := database.Conn(ctx, r.db).
+		Model(&entities.NotificationPreference{}).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"email", "telegram", "telegram_chat_id", "language"}),
+		}).
+		Create(map[string]interface{}{
+			"user_id":          p.UserID,
+			"email":            p.Email,
+			"telegram":         p.Telegram,
+			"telegram_chat_id": p.TelegramChatID,
+			"language":         language,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("cannot set the preference: %w", translateError(result.Error))
+	}
+
+	return nil
+}
+
+// Adds a rule to the db
+func (r *Notifications) CreateRule(ctx context.Context, rule *models.NotificationRule) error {
+	entity := entities.NotificationRule{
+		CollegeID: rule.CollegeID,
+		Kind:      rule.Kind,
+		Threshold: rule.Threshold,
+	}
+
+	if err := database.Conn(ctx, r.db).Omit("College").Create(&entity).Error; err != nil {
+		return fmt.Errorf("cannot create the rule: %w", translateError(err))
+	}
+
+	rule.ID = entity.ID
+
+	return nil
+}
+
+// Returns a rule by its ID
+func (r *Notifications) GetRule(ctx context.Context, id uint) (*models.NotificationRule, error) {
+	var entities []entities.NotificationRule
+
+	result := database.Conn(ctx, r.db).Where("id = ?", id).Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the rule: %w", translateError(result.Error))
+	}
+
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("cannot get the rule: %w", abstractions.ErrNotFound)
+	}
+
+	return toRuleModel(&entities[0]), nil
+}
+
+// Returns the rules of the college
+func (r *Notifications) GetRules(ctx context.Context, collegeID uint) ([]*models.NotificationRule, error) {
+	var entities []entities.NotificationRule
+
+	result := database.Conn(ctx, r.db).Where("college_id = ?", collegeID).Order("id").Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the rules: %w", translateError(result.Error))
+	}
+
+	var rules []*models.NotificationRule
+	for i := range entities {
+		rules = append(rules, toRuleModel(&entities[i]))
+	}
+
+	return rules, nil
+}
+
+// Deletes a rule by its ID
+func (r *Notifications) DeleteRule(ctx context.Context, id uint) error {
+	result := database.Conn(ctx, r.db).Where("id = ?", id).Delete(&entities.NotificationRule{})
+	if result.Error != nil {
+		return fmt.Errorf("not able to delete the rule №%d: %w", id, translateError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("not able to delete the rule №%d: %w", id, abstractions.ErrNotFound)
+	}
+
+	return nil
+}
+
+// Converts a rule entity to the model
+func toRuleModel(e *entities.NotificationRule) *models.NotificationRule {
+	return &models.NotificationRule{
+		ID:        e.ID,
+		CollegeID: e.CollegeID,
+		Kind:      e.Kind,
+		Threshold: e.Threshold,
+	}
+}
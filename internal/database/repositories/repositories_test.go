@@ -0,0 +1,60 @@
+package repositories_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/database"
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/repositories"
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/repotest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The suite needs a database it may wipe, e.g.
+// TEST_POSTGRES_DSN="host=localhost user=postgres password=postgres dbname=na_meste_test sslmode=disable"
+// or the one started in Docker by `make test_postgres`
+const dsnVariable = "TEST_POSTGRES_DSN"
+
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv(dsnVariable)
+	if dsn == "" {
+		t.Skipf("%s is not set", dsnVariable)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("cannot connect to the database: %v", err)
+	}
+
+	if err := database.MigrateEntities(db); err != nil {
+		t.Fatalf("cannot migrate the database: %v", err)
+	}
+
+	repotest.Run(t, func(t *testing.T) repotest.Repos {
+		// the audit log is append-only, so it is left as it is
+		err := db.Exec(`
+			TRUNCATE colleges, users, lessons, lesson_students, attendances, attendance_changes,
+				webhook_subscriptions, webhook_deliveries, jobs, guardian_links,
+				notification_preferences, notification_rules
+			RESTART IDENTITY CASCADE
+		`).Error
+		if err != nil {
+			t.Fatalf("cannot clean the database: %v", err)
+		}
+
+		return repotest.Repos{
+			Colleges:      repositories.NewColleges(db),
+			Users:         repositories.NewUsers(db),
+			Attendances:   repositories.NewAttendances(db),
+			Lessons:       repositories.NewLessons(db),
+			Audit:         repositories.NewAudit(db),
+			Webhooks:      repositories.NewWebhooks(db),
+			Jobs:          repositories.NewJobs(db),
+			Guardians:     repositories.NewGuardians(db),
+			Notifications: repositories.NewNotifications(db),
+			Transactor:    database.NewTransactor(db),
+		}
+	})
+}
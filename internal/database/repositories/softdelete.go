@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Converts a gorm deletion time to the one of the models
+func deletedAt(d gorm.DeletedAt) *time.Time {
+	if !d.Valid {
+		return nil
+	}
+
+	t := d.Time
+	return &t
+}
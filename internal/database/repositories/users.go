@@ -1,10 +1,14 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/cyberbrain-dev/na-meste-api/internal/database"
 	"github.com/cyberbrain-dev/na-meste-api/internal/database/entities"
 	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
 	"gorm.io/gorm"
 )
 
@@ -18,7 +22,7 @@ func NewUsers(db *gorm.DB) *Users {
 	return &Users{db: db}
 }
 
-func (r *Users) Create(u *models.User) error {
+func (r *Users) Create(ctx context.Context, u *models.User) error {
 	entity := entities.User{
 		Username:     u.Username,
 		Email:        u.Email,
@@ -27,55 +31,66 @@ func (r *Users) Create(u *models.User) error {
 		CollegeID:    u.CollegeID,
 	}
 
-	result := r.db.Create(&entity)
+	result := database.Conn(ctx, r.db).Create(&entity)
+	if result.Error != nil {
+		return fmt.Errorf("cannot create the user: %w", translateError(result.Error))
+	}
+
+	u.ID = entity.ID
 
-	return result.Error
+	return nil
 }
 
-func (r *Users) Get(email string) (*models.User, error) {
+func (r *Users) Get(ctx context.Context, email string) (*models.User, error) {
 	var entities []entities.User
 
-	result := r.db.Where("email = ?", email).Find(&entities)
+	result := database.Conn(ctx, r.db).Where("email = ?", email).Find(&entities)
 	if result.Error != nil {
-		return nil, fmt.Errorf("cannot get the user: %w", result.Error)
+		return nil, fmt.Errorf("cannot get the user: %w", translateError(result.Error))
 	}
 
 	// If user has not been found
 	if len(entities) == 0 {
-		return nil, nil
+		return nil, fmt.Errorf("cannot get the user: %w", abstractions.ErrNotFound)
 	}
 
-	e := entities[0]
+	return toUserModel(&entities[0]), nil
+}
 
-	user := models.User{
-		ID:           e.ID,
-		Username:     e.Username,
-		Email:        e.Email,
-		PasswordHash: e.PasswordHash,
-		Role:         e.Role,
-		CollegeID:    e.CollegeID,
+// Returns a user by their ID
+func (r *Users) GetByID(ctx context.Context, id uint) (*models.User, error) {
+	var entities []entities.User
+
+	result := database.Conn(ctx, r.db).Where("id = ?", id).Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the user: %w", translateError(result.Error))
+	}
+
+	// If user has not been found
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("cannot get the user: %w", abstractions.ErrNotFound)
 	}
 
-	return &user, nil
+	return toUserModel(&entities[0]), nil
 }
 
-func (r *Users) Update(id uint, username *string, email *string) (uint, error) {
+func (r *Users) Update(ctx context.Context, id uint, username *string, email *string) (uint, error) {
 	if username == nil && email == nil {
 		return id, nil
 	}
 
 	if username == nil && email != nil {
-		result := r.db.Model(&entities.User{}).Where("id = ?", id).Update("email", *email)
+		result := database.Conn(ctx, r.db).Model(&entities.User{}).Where("id = ?", id).Update("email", *email)
 		if result.Error != nil {
-			return 0, fmt.Errorf("cannot update the email: %w", result.Error)
+			return 0, fmt.Errorf("cannot update the email: %w", translateError(result.Error))
 		}
 	} else if username != nil && email == nil {
-		result := r.db.Model(&entities.User{}).Where("id = ?", id).Update("username", *username)
+		result := database.Conn(ctx, r.db).Model(&entities.User{}).Where("id = ?", id).Update("username", *username)
 		if result.Error != nil {
-			return 0, fmt.Errorf("cannot update the username: %w", result.Error)
+			return 0, fmt.Errorf("cannot update the username: %w", translateError(result.Error))
 		}
 	} else if username != nil && email != nil {
-		result := r.db.Model(&entities.User{}).
+		result := database.Conn(ctx, r.db).Model(&entities.User{}).
 			Where("id = ?", id).
 			Updates(map[string]interface{}{
 				"username": *username,
@@ -83,18 +98,127 @@ func (r *Users) Update(id uint, username *string, email *string) (uint, error) {
 			})
 
 		if result.Error != nil {
-			return 0, fmt.Errorf("cannot update user: %w", result.Error)
+			return 0, fmt.Errorf("cannot update user: %w", translateError(result.Error))
 		}
 	}
 
 	return id, nil
 }
 
-func (r *Users) Delete(id uint) (uint, error) {
-	result := r.db.Where("id = ?", id).Delete(&entities.User{})
+func (r *Users) SetRole(ctx context.Context, id uint, role string) error {
+	result := database.Conn(ctx, r.db).Model(&entities.User{}).Where("id = ?", id).Update("role", role)
 	if result.Error != nil {
-		return 0, fmt.Errorf(`not able to delete the user: %w`, result.Error)
+		return fmt.Errorf("cannot update the role: %w", translateError(result.Error))
+	}
+
+	return nil
+}
+
+// Sets the curator of the student with the ID passed, nil removes them
+func (r *Users) SetCurator(ctx context.Context, id uint, curatorID *uint) error {
+	result := database.Conn(ctx, r.db).Model(&entities.User{}).Where("id = ?", id).Update("curator_id", curatorID)
+	if result.Error != nil {
+		return fmt.Errorf("cannot update the curator: %w", translateError(result.Error))
+	}
+
+	return nil
+}
+
+// Soft-deletes the user with their attendances
+func (r *Users) Delete(ctx context.Context, id uint) (uint, error) {
+	now := time.Now()
+
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entities.User{}).Where("id = ?", id).Update("deleted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return abstractions.ErrNotFound
+		}
+
+		// the attendances get the same time, so they are restored together
+		return tx.Model(&entities.Attendance{}).Where("user_id = ?", id).Update("deleted_at", now).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf(`not able to delete the user: %w`, translateError(err))
 	}
 
 	return id, nil
 }
+
+// Returns the soft-deleted users of the college
+func (r *Users) GetDeleted(ctx context.Context, collegeID uint) ([]*models.User, error) {
+	var entities []entities.User
+
+	result := database.Conn(ctx, r.db).Unscoped().
+		Where("(deleted_at IS NOT NULL) AND (college_id = ?)", collegeID).
+		Order("deleted_at DESC").Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the deleted users: %w", translateError(result.Error))
+	}
+
+	var users []*models.User
+	for i := range entities {
+		users = append(users, toUserModel(&entities[i]))
+	}
+
+	return users, nil
+}
+
+// Restores the soft-deleted user with the attendances deleted along with them
+func (r *Users) Restore(ctx context.Context, id uint) error {
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var user entities.User
+
+		result := tx.Unscoped().Where("(id = ?) AND (deleted_at IS NOT NULL)", id).Limit(1).Find(&user)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return abstractions.ErrNotDeleted
+		}
+
+		err := tx.Unscoped().Model(&entities.Attendance{}).
+			Where("(user_id = ?) AND (deleted_at = ?)", id, user.DeletedAt).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Model(&entities.User{}).Where("id = ?", id).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return fmt.Errorf("cannot restore the user №%d: %w", id, translateError(err))
+	}
+
+	return nil
+}
+
+// Permanently deletes the users soft-deleted before the moment passed
+// that have no attendances left
+func (r *Users) Purge(ctx context.Context, before time.Time) (int, error) {
+	result := database.Conn(ctx, r.db).Unscoped().
+		Where("deleted_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM attendances WHERE attendances.user_id = users.id)").
+		Delete(&entities.User{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("cannot purge the users: %w", translateError(result.Error))
+	}
+
+	return int(result.RowsAffected), nil
+}
+
+// Converts a user entity to the model
+func toUserModel(e *entities.User) *models.User {
+	return &models.User{
+		ID:           e.ID,
+		Username:     e.Username,
+		Email:        e.Email,
+		PasswordHash: e.PasswordHash,
+		Role:         e.Role,
+		CollegeID:    e.CollegeID,
+		CuratorID:    e.CuratorID,
+		DeletedAt:    deletedAt(e.DeletedAt),
+	}
+}
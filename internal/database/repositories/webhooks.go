@@ -0,0 +1,309 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/database"
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/entities"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Represents a repository of webhook subscriptions and their outbox
+type Webhooks struct {
+	db *gorm.DB
+}
+
+// Creates new webhooks repo of the db passed
+func NewWebhooks(db *gorm.DB) *Webhooks {
+	return &Webhooks{db: db}
+}
+
+// Adds a subscription to the db
+func (r *Webhooks) CreateSubscription(ctx context.Context, s *models.WebhookSubscription) error {
+	entity := entities.WebhookSubscription{
+		CollegeID: s.CollegeID,
+		URL:       s.URL,
+		Secret:    s.Secret,
+		Events:    s.Events,
+		Active:    true,
+		CreatedAt: time.Now(),
+	}
+
+	if err := database.Conn(ctx, r.db).Omit("College").Create(&entity).Error; err != nil {
+		return fmt.Errorf("cannot create the subscription: %w", translateError(err))
+	}
+
+	s.ID = entity.ID
+	s.Active = entity.Active
+	s.CreatedAt = entity.CreatedAt
+
+	return nil
+}
+
+// Returns a subscription by its ID
+func (r *Webhooks) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	var entities []entities.WebhookSubscription
+
+	result := database.Conn(ctx, r.db).Where("id = ?", id).Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the subscription: %w", translateError(result.Error))
+	}
+
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("cannot get the subscription: %w", abstractions.ErrNotFound)
+	}
+
+	return toSubscriptionModel(&entities[0]), nil
+}
+
+// Returns the subscriptions of the college
+func (r *Webhooks) GetSubscriptions(ctx context.Context, collegeID uint) ([]*models.WebhookSubscription, error) {
+	var entities []entities.WebhookSubscription
+
+	result := database.Conn(ctx, r.db).Where("college_id = ?", collegeID).Order("id").Find(&entities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the subscriptions: %w", translateError(result.Error))
+	}
+
+	var subs []*models.WebhookSubscription
+	for i := range entities {
+		subs = append(subs, toSubscriptionModel(&entities[i]))
+	}
+
+	return subs, nil
+}
+
+// Deletes a subscription with its deliveries
+func (r *Webhooks) DeleteSubscription(ctx context.Context, id uint) error {
+	result := database.Conn(ctx, r.db).Where("id = ?", id).Delete(&entities.WebhookSubscription{})
+	if result.Error != nil {
+		return fmt.Errorf("not able to delete the subscription №%d: %w", id, translateError(result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("not able to delete the subscription №%d: %w", id, abstractions.ErrNotFound)
+	}
+
+	return nil
+}
+
+// Writes a pending delivery for every active subscription of the college to the event
+func (r *Webhooks) Enqueue(ctx context.Context, collegeID uint, event string, payload []byte) (int, error) {
+	var subs []entities.WebhookSubscription
+
+	result := database.Conn(ctx, r.db).Where("(college_id = ?) AND active", collegeID).Find(&subs)
+	if result.Error != nil {
+		return 0, fmt.Errorf("cannot get the subscriptions: %w", translateError(result.Error))
+	}
+
+	now := time.Now()
+
+	var deliveries []entities.WebhookDelivery
+	for _, s := range subs {
+		if !slices.Contains(s.Events, event) {
+			continue
+		}
+
+		deliveries = append(deliveries, entities.WebhookDelivery{
+			SubscriptionID: s.ID,
+			Event:          event,
+			Payload:        payload,
+			Status:         models.DeliveryStatusPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	if err := database.Conn(ctx, r.db).Omit("Subscription").Create(&deliveries).Error; err != nil {
+		return 0, fmt.Errorf("cannot enqueue the deliveries: %w", translateError(err))
+	}
+
+	return len(deliveries), nil
+}
+
+// Claims up to limit pending deliveries that are due
+func (r *Webhooks) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	var claimed []entities.WebhookDelivery
+
+	err := database.Conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// the rows locked by other instances are skipped
+		result := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ?) AND (next_attempt_at <= ?)", models.DeliveryStatusPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&claimed)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if len(claimed) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(claimed))
+		for i, d := range claimed {
+			ids[i] = d.ID
+		}
+
+		// leasing the deliveries, so they are not sent twice
+		return tx.Model(&entities.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).
+			Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot claim the deliveries: %w", translateError(err))
+	}
+
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+
+	// getting the endpoints and the secrets
+	subIDs := make([]uint, 0, len(claimed))
+	for _, d := range claimed {
+		subIDs = append(subIDs, d.SubscriptionID)
+	}
+
+	var subs []entities.WebhookSubscription
+	if err := database.Conn(ctx, r.db).Where("id IN ?", subIDs).Find(&subs).Error; err != nil {
+		return nil, fmt.Errorf("cannot get the subscriptions: %w", translateError(err))
+	}
+
+	byID := make(map[uint]*entities.WebhookSubscription, len(subs))
+	for i := range subs {
+		byID[subs[i].ID] = &subs[i]
+	}
+
+	var deliveries []*models.WebhookDelivery
+	for i := range claimed {
+		d := toDeliveryModel(&claimed[i])
+
+		if s, ok := byID[d.SubscriptionID]; ok {
+			d.URL = s.URL
+			d.Secret = s.Secret
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
+
+// Marks the delivery as delivered
+func (r *Webhooks) MarkDelivered(ctx context.Context, id uint, statusCode int) error {
+	now := time.Now()
+
+	result := database.Conn(ctx, r.db).Model(&entities.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":           models.DeliveryStatusDelivered,
+			"attempts":         gorm.Expr("attempts + 1"),
+			"last_attempt_at":  now,
+			"last_status_code": statusCode,
+			"last_error":       "",
+			"delivered_at":     now,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("cannot mark the delivery №%d as delivered: %w", id, translateError(result.Error))
+	}
+
+	return nil
+}
+
+// Records a failed attempt of the delivery
+func (r *Webhooks) MarkAttemptFailed(ctx context.Context, id uint, statusCode int, errMsg string, next *time.Time) error {
+	updates := map[string]interface{}{
+		"attempts":         gorm.Expr("attempts + 1"),
+		"last_attempt_at":  time.Now(),
+		"last_status_code": statusCode,
+		"last_error":       truncate(errMsg, 1000),
+	}
+
+	if next != nil {
+		updates["next_attempt_at"] = *next
+	} else {
+		updates["status"] = models.DeliveryStatusFailed
+	}
+
+	result := database.Conn(ctx, r.db).Model(&entities.WebhookDelivery{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("cannot record the attempt of the delivery №%d: %w", id, translateError(result.Error))
+	}
+
+	return nil
+}
+
+// Returns the latest deliveries of the subscription
+func (r *Webhooks) GetDeliveries(ctx context.Context, subscriptionID uint, limit int) ([]*models.WebhookDelivery, error) {
+	var entities []entities.WebhookDelivery
+
+	result := database.Conn(ctx, r.db).
+		Where("subscription_id = ?", subscriptionID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&entities)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("cannot get the deliveries: %w", translateError(result.Error))
+	}
+
+	var deliveries []*models.WebhookDelivery
+	for i := range entities {
+		deliveries = append(deliveries, toDeliveryModel(&entities[i]))
+	}
+
+	return deliveries, nil
+}
+
+// Converts a subscription entity to the model
+func toSubscriptionModel(e *entities.WebhookSubscription) *models.WebhookSubscription {
+	return &models.WebhookSubscription{
+		ID:        e.ID,
+		CollegeID: e.CollegeID,
+		URL:       e.URL,
+		Secret:    e.Secret,
+		Events:    e.Events,
+		Active:    e.Active,
+		CreatedAt: e.CreatedAt,
+	}
+}
+
+// Converts a delivery entity to the model
+func toDeliveryModel(e *entities.WebhookDelivery) *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		ID:             e.ID,
+		SubscriptionID: e.SubscriptionID,
+		Event:          e.Event,
+		Payload:        e.Payload,
+		Status:         e.Status,
+		Attempts:       e.Attempts,
+		NextAttemptAt:  e.NextAttemptAt,
+		LastAttemptAt:  e.LastAttemptAt,
+		LastStatusCode: e.LastStatusCode,
+		LastError:      e.LastError,
+		CreatedAt:      e.CreatedAt,
+		DeliveredAt:    e.DeliveredAt,
+	}
+}
+
+// Cuts the string to n bytes at most
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n]
+}
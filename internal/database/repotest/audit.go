@@ -0,0 +1,80 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+)
+
+func testAuditLog(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	// the log is append-only and is not cleaned between the tests,
+	// so the records of this run are told apart by their entity
+	entityID := fmt.Sprintf("repotest-%d", time.Now().UnixNano())
+	at := time.Now().UTC().Truncate(time.Second)
+	actor := uint(7)
+
+	for i, action := range []string{"POST /a", "PUT /a", "DELETE /a"} {
+		record := &models.AuditRecord{
+			OccurredAt: at.Add(time.Duration(i) * time.Minute),
+			Action:     action,
+			Entity:     "repotest",
+			EntityID:   entityID,
+			After:      []byte(`{"step":1}`),
+			StatusCode: 200,
+		}
+		if i > 0 {
+			record.ActorID = &actor
+		}
+
+		if err := r.Audit.Append(ctx, record); err != nil {
+			t.Fatalf("cannot append the record: %v", err)
+		}
+		if record.ID == 0 {
+			t.Fatal("the appended record has got no ID")
+		}
+	}
+
+	list := func(f models.AuditFilter) []*models.AuditRecord {
+		t.Helper()
+
+		f.EntityID = entityID
+
+		records, err := r.Audit.List(ctx, f)
+		if err != nil {
+			t.Fatalf("cannot list the records: %v", err)
+		}
+
+		return records
+	}
+
+	all := list(models.AuditFilter{})
+	if len(all) != 3 {
+		t.Fatalf("got %d records without a limit, want 3", len(all))
+	}
+	if all[0].Action != "DELETE /a" || all[2].Action != "POST /a" {
+		t.Errorf("got %q first and %q last, want the newest first", all[0].Action, all[2].Action)
+	}
+	if len(all[0].Before) != 0 {
+		t.Errorf("got the snapshot %s before, want none", all[0].Before)
+	}
+
+	if got := list(models.AuditFilter{Action: "PUT /a"}); len(got) != 1 {
+		t.Errorf("got %d records of the action, want 1", len(got))
+	}
+	if got := list(models.AuditFilter{ActorID: &actor}); len(got) != 2 {
+		t.Errorf("got %d records of the actor, want 2", len(got))
+	}
+	if got := list(models.AuditFilter{From: at.Add(time.Minute), To: at.Add(time.Minute)}); len(got) != 1 {
+		t.Errorf("got %d records of the period, want 1", len(got))
+	}
+
+	page := list(models.AuditFilter{Limit: 2, Offset: 1})
+	if len(page) != 2 || page[0].Action != "PUT /a" {
+		t.Errorf("got %d records of the page, want the 2 older ones", len(page))
+	}
+}
@@ -0,0 +1,81 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+func testGuardianLinks(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	guardian := createUser(t, r, c.ID, "guardian@example.com", "guardian")
+	first := createUser(t, r, c.ID, "first@example.com", "student")
+	second := createUser(t, r, c.ID, "second@example.com", "student")
+
+	link := func(guardianID uint, studentID uint) error {
+		return r.Guardians.Link(ctx, &models.GuardianLink{GuardianID: guardianID, StudentID: studentID})
+	}
+
+	if err := link(guardian.ID, second.ID); err != nil {
+		t.Fatalf("cannot link the student: %v", err)
+	}
+	if err := link(guardian.ID, first.ID); err != nil {
+		t.Fatalf("cannot link the student: %v", err)
+	}
+
+	// linking them again does nothing
+	if err := link(guardian.ID, first.ID); err != nil {
+		t.Errorf("got %v linking the student again, want nil", err)
+	}
+	if err := link(guardian.ID, second.ID+1000); !errors.Is(err, abstractions.ErrForeignKey) {
+		t.Errorf("got %v for an unknown student, want ErrForeignKey", err)
+	}
+
+	if linked, err := r.Guardians.IsLinked(ctx, guardian.ID, first.ID); err != nil || !linked {
+		t.Errorf("got the link %v (%v), want it linked", linked, err)
+	}
+	if linked, err := r.Guardians.IsLinked(ctx, first.ID, guardian.ID); err != nil || linked {
+		t.Errorf("got the reversed link %v (%v), want none", linked, err)
+	}
+
+	students, err := r.Guardians.GetStudents(ctx, guardian.ID)
+	if err != nil {
+		t.Fatalf("cannot get the students: %v", err)
+	}
+	if len(students) != 2 || students[0].ID != first.ID || students[1].ID != second.ID {
+		t.Errorf("got %d students of the guardian, want the 2 linked in order", len(students))
+	}
+
+	guardians, err := r.Guardians.GetGuardians(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("cannot get the guardians: %v", err)
+	}
+	if len(guardians) != 1 || guardians[0].ID != guardian.ID {
+		t.Errorf("got %d guardians of the student, want the linked one", len(guardians))
+	}
+
+	// the deleted students are not listed
+	if _, err := r.Users.Delete(ctx, second.ID); err != nil {
+		t.Fatalf("cannot delete the student: %v", err)
+	}
+
+	students, err = r.Guardians.GetStudents(ctx, guardian.ID)
+	if err != nil {
+		t.Fatalf("cannot get the students: %v", err)
+	}
+	if len(students) != 1 || students[0].ID != first.ID {
+		t.Errorf("got %d students of the guardian, want the one not deleted", len(students))
+	}
+
+	if unlinked, err := r.Guardians.Unlink(ctx, guardian.ID, first.ID); err != nil || !unlinked {
+		t.Errorf("got the unlinking %v (%v), want it unlinked", unlinked, err)
+	}
+	if unlinked, err := r.Guardians.Unlink(ctx, guardian.ID, first.ID); err != nil || unlinked {
+		t.Errorf("got the unlinking %v (%v) of no link, want false", unlinked, err)
+	}
+}
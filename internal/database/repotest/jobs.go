@@ -0,0 +1,175 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+func testJobQueue(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Second)
+
+	first := enqueueJob(t, r, "a", nil, now.Add(-time.Minute))
+	unique := enqueueJob(t, r, "a", ptr("key"), now.Add(-30*time.Second))
+	enqueueJob(t, r, "b", nil, now.Add(-time.Minute))
+	enqueueJob(t, r, "a", nil, now.Add(time.Hour))
+
+	if first.Status != models.JobStatusPending || first.Attempts != 0 || first.CreatedAt.IsZero() {
+		t.Errorf("got the %q job after %d attempts, want a new pending one", first.Status, first.Attempts)
+	}
+
+	// a key is enqueued only once
+	added, err := r.Jobs.Enqueue(ctx, &models.Job{Kind: "a", Payload: []byte(`{}`), MaxAttempts: 3, UniqueKey: ptr("key")})
+	if err != nil {
+		t.Fatalf("cannot enqueue the duplicate: %v", err)
+	}
+	if added {
+		t.Error("the job with a queued key has been enqueued again")
+	}
+
+	claimed, err := r.Jobs.Claim(ctx, []string{"a"}, now, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("cannot claim the jobs: %v", err)
+	}
+	if len(claimed) != 2 || claimed[0].ID != first.ID || claimed[1].ID != unique.ID {
+		t.Fatalf("claimed %d jobs, want the 2 due ones of the kind by the time", len(claimed))
+	}
+	for _, job := range claimed {
+		if job.Status != models.JobStatusRunning || job.Attempts != 1 || job.LockedUntil == nil {
+			t.Errorf("got the claimed job %+v, want it running at the first attempt", job)
+		}
+	}
+
+	// the leased jobs are claimed again only once the lease passes
+	again, err := r.Jobs.Claim(ctx, []string{"a"}, now, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("cannot claim the jobs again: %v", err)
+	}
+	if len(again) != 0 {
+		t.Errorf("claimed %d leased jobs, want 0", len(again))
+	}
+
+	abandoned, err := r.Jobs.Claim(ctx, []string{"a"}, now.Add(2*time.Minute), 1, time.Minute)
+	if err != nil {
+		t.Fatalf("cannot claim the abandoned jobs: %v", err)
+	}
+	if len(abandoned) != 1 || abandoned[0].Attempts != 2 {
+		t.Errorf("claimed %d abandoned jobs, want 1 at the second attempt", len(abandoned))
+	}
+
+	if claimed, err := r.Jobs.Claim(ctx, nil, now, 10, time.Minute); err != nil || len(claimed) != 0 {
+		t.Errorf("claimed %d jobs of no kinds (%v), want 0", len(claimed), err)
+	}
+}
+
+func testJobLifecycle(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Second)
+
+	done := enqueueJob(t, r, "a", nil, now.Add(-time.Minute))
+	retried := enqueueJob(t, r, "a", nil, now.Add(-time.Minute))
+	dead := enqueueJob(t, r, "a", nil, now.Add(-time.Minute))
+
+	if _, err := r.Jobs.Claim(ctx, []string{"a"}, now, 10, time.Minute); err != nil {
+		t.Fatalf("cannot claim the jobs: %v", err)
+	}
+
+	if err := r.Jobs.Complete(ctx, done.ID); err != nil {
+		t.Fatalf("cannot complete the job: %v", err)
+	}
+	if err := r.Jobs.Retry(ctx, retried.ID, now.Add(time.Hour), "failed"); err != nil {
+		t.Fatalf("cannot retry the job: %v", err)
+	}
+	if err := r.Jobs.Kill(ctx, dead.ID, "failed for good"); err != nil {
+		t.Fatalf("cannot kill the job: %v", err)
+	}
+
+	get := func(id uint) *models.Job {
+		t.Helper()
+
+		job, err := r.Jobs.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("cannot get the job №%d: %v", id, err)
+		}
+
+		return job
+	}
+
+	if job := get(done.ID); job.Status != models.JobStatusDone || job.FinishedAt == nil || job.LockedUntil != nil {
+		t.Errorf("got the completed job %+v, want it done", job)
+	}
+	if job := get(retried.ID); job.Status != models.JobStatusPending || job.LastError != "failed" || job.Attempts != 1 {
+		t.Errorf("got the retried job %+v, want it pending with the error", job)
+	}
+	if job := get(dead.ID); job.Status != models.JobStatusDead || job.FinishedAt == nil {
+		t.Errorf("got the killed job %+v, want it dead", job)
+	}
+
+	deadJobs, err := r.Jobs.GetByStatus(ctx, models.JobStatusDead, 10)
+	if err != nil {
+		t.Fatalf("cannot get the dead jobs: %v", err)
+	}
+	if len(deadJobs) != 1 || deadJobs[0].ID != dead.ID {
+		t.Errorf("got %d dead jobs, want the killed one", len(deadJobs))
+	}
+
+	// only the dead jobs are requeued
+	if err := r.Jobs.Requeue(ctx, dead.ID, now); err != nil {
+		t.Fatalf("cannot requeue the job: %v", err)
+	}
+	if err := r.Jobs.Requeue(ctx, done.ID, now); err != nil {
+		t.Fatalf("cannot requeue the done job: %v", err)
+	}
+	if job := get(dead.ID); job.Status != models.JobStatusPending || job.Attempts != 0 || job.FinishedAt != nil {
+		t.Errorf("got the requeued job %+v, want it pending with fresh attempts", job)
+	}
+	if job := get(done.ID); job.Status != models.JobStatusDone {
+		t.Errorf("got the %q done job after requeueing it, want it done", job.Status)
+	}
+
+	purged, err := r.Jobs.PurgeDone(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("cannot purge the jobs: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("purged %d jobs, want 1", purged)
+	}
+	if _, err := r.Jobs.Get(ctx, done.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for a purged job, want ErrNotFound", err)
+	}
+}
+
+// Enqueues a job of the kind to run at the moment
+func enqueueJob(t *testing.T, r Repos, kind string, key *string, runAt time.Time) *models.Job {
+	t.Helper()
+
+	j := &models.Job{
+		Kind:        kind,
+		Payload:     []byte(`{}`),
+		MaxAttempts: 3,
+		RunAt:       runAt,
+		UniqueKey:   key,
+	}
+
+	added, err := r.Jobs.Enqueue(context.Background(), j)
+	if err != nil {
+		t.Fatalf("cannot enqueue the %s job: %v", kind, err)
+	}
+	if !added {
+		t.Fatalf("the %s job has not been enqueued", kind)
+	}
+
+	return j
+}
+
+// Returns a pointer to the value
+func ptr[T any](v T) *T {
+	return &v
+}
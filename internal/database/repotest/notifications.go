@@ -0,0 +1,128 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+func testNotificationPreferences(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	u := createUser(t, r, c.ID, "curator@example.com", "teacher")
+
+	pref, err := r.Notifications.GetPreference(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("cannot get the preference: %v", err)
+	}
+	if pref != nil {
+		t.Errorf("got the preference %+v of a user who has set none, want nil", pref)
+	}
+
+	// the channels turned off are kept off, the language defaults to Russian
+	err = r.Notifications.SetPreference(ctx, &models.NotificationPreference{
+		UserID:         u.ID,
+		Telegram:       true,
+		TelegramChatID: "42",
+	})
+	if err != nil {
+		t.Fatalf("cannot set the preference: %v", err)
+	}
+
+	pref, err = r.Notifications.GetPreference(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("cannot get the preference: %v", err)
+	}
+	if pref == nil || pref.Email || !pref.Telegram || pref.TelegramChatID != "42" || pref.Language != models.LanguageRussian {
+		t.Errorf("got the preference %+v, want Telegram only in Russian", pref)
+	}
+
+	// setting it again replaces it
+	err = r.Notifications.SetPreference(ctx, &models.NotificationPreference{
+		UserID:   u.ID,
+		Email:    true,
+		Language: models.LanguageEnglish,
+	})
+	if err != nil {
+		t.Fatalf("cannot replace the preference: %v", err)
+	}
+
+	pref, err = r.Notifications.GetPreference(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("cannot get the preference: %v", err)
+	}
+	if pref == nil || !pref.Email || pref.Telegram || pref.Language != models.LanguageEnglish {
+		t.Errorf("got the preference %+v, want email only in English", pref)
+	}
+
+	err = r.Notifications.SetPreference(ctx, &models.NotificationPreference{UserID: u.ID, Language: "de"})
+	if !errors.Is(err, abstractions.ErrCheckViolation) {
+		t.Errorf("got %v for an unknown language, want ErrCheckViolation", err)
+	}
+
+	err = r.Notifications.SetPreference(ctx, &models.NotificationPreference{UserID: u.ID + 1000})
+	if !errors.Is(err, abstractions.ErrForeignKey) {
+		t.Errorf("got %v for an unknown user, want ErrForeignKey", err)
+	}
+}
+
+func testNotificationRules(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	other := createCollege(t, r, "Other")
+
+	create := func(collegeID uint, kind string) (*models.NotificationRule, error) {
+		rule := &models.NotificationRule{CollegeID: collegeID, Kind: kind, Threshold: 3}
+		return rule, r.Notifications.CreateRule(ctx, rule)
+	}
+
+	first, err := create(c.ID, models.RuleAbsencesInRow)
+	if err != nil {
+		t.Fatalf("cannot create the rule: %v", err)
+	}
+	second, err := create(c.ID, models.RuleRateBelow)
+	if err != nil {
+		t.Fatalf("cannot create the rule: %v", err)
+	}
+	if _, err := create(other.ID, models.RuleRateBelow); err != nil {
+		t.Fatalf("cannot create the rule: %v", err)
+	}
+
+	if _, err := create(c.ID, "unknown"); !errors.Is(err, abstractions.ErrCheckViolation) {
+		t.Errorf("got %v for an unknown kind, want ErrCheckViolation", err)
+	}
+	if _, err := create(c.ID+1000, models.RuleRateBelow); !errors.Is(err, abstractions.ErrForeignKey) {
+		t.Errorf("got %v for an unknown college, want ErrForeignKey", err)
+	}
+
+	got, err := r.Notifications.GetRule(ctx, second.ID)
+	if err != nil {
+		t.Fatalf("cannot get the rule: %v", err)
+	}
+	if got.CollegeID != c.ID || got.Kind != models.RuleRateBelow || got.Threshold != 3 {
+		t.Errorf("got the rule %+v, want the one created", got)
+	}
+
+	rules, err := r.Notifications.GetRules(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("cannot get the rules: %v", err)
+	}
+	if len(rules) != 2 || rules[0].ID != first.ID || rules[1].ID != second.ID {
+		t.Errorf("got %d rules of the college, want the 2 created in order", len(rules))
+	}
+
+	if err := r.Notifications.DeleteRule(ctx, first.ID); err != nil {
+		t.Fatalf("cannot delete the rule: %v", err)
+	}
+	if _, err := r.Notifications.GetRule(ctx, first.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for a deleted rule, want ErrNotFound", err)
+	}
+	if err := r.Notifications.DeleteRule(ctx, first.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v deleting a deleted rule, want ErrNotFound", err)
+	}
+}
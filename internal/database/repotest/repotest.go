@@ -0,0 +1,690 @@
+// Contains the conformance suite of the repositories.
+// Every implementation of the repositories runs it, so they behave the same way
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/geo"
+)
+
+// Represents the repositories checked by the suite, they must share the storage
+type Repos struct {
+	Colleges      abstractions.CollegesRepo
+	Users         abstractions.UsersRepo
+	Attendances   abstractions.AttendancesRepo
+	Lessons       abstractions.LessonsRepo
+	Audit         abstractions.AuditRepo
+	Webhooks      abstractions.WebhooksRepo
+	Jobs          abstractions.JobsRepo
+	Guardians     abstractions.GuardiansRepo
+	Notifications abstractions.NotificationsRepo
+	Transactor    abstractions.Transactor
+}
+
+// Runs the suite, newRepos must return the repositories of an empty storage
+func Run(t *testing.T, newRepos func(t *testing.T) Repos) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, r Repos)
+	}{
+		{"CollegeLookups", testCollegeLookups},
+		{"CollegeUniqueName", testCollegeUniqueName},
+		{"CollegeGeofenceMode", testCollegeGeofenceMode},
+		{"CollegeDeletion", testCollegeDeletion},
+		{"CollegePurge", testCollegePurge},
+		{"UserLookups", testUserLookups},
+		{"UserUniqueEmail", testUserUniqueEmail},
+		{"UserConstraints", testUserConstraints},
+		{"UserDeletion", testUserDeletion},
+		{"PurgeKeepsAttendances", testPurgeKeepsAttendances},
+		{"AttendanceLookups", testAttendanceLookups},
+		{"AttendanceDeletion", testAttendanceDeletion},
+		{"AttendanceReferences", testAttendanceReferences},
+		{"AbsenceMaterialization", testAbsenceMaterialization},
+		{"AuditLog", testAuditLog},
+		{"WebhookSubscriptions", testWebhookSubscriptions},
+		{"WebhookOutbox", testWebhookOutbox},
+		{"JobQueue", testJobQueue},
+		{"JobLifecycle", testJobLifecycle},
+		{"GuardianLinks", testGuardianLinks},
+		{"NotificationPreferences", testNotificationPreferences},
+		{"NotificationRules", testNotificationRules},
+		{"TransactionRollback", testTransactionRollback},
+		{"TransactionCommit", testTransactionCommit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepos(t))
+		})
+	}
+}
+
+func testCollegeLookups(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+
+	byName, err := r.Colleges.Get(ctx, "College")
+	if err != nil {
+		t.Fatalf("cannot get the college by its name: %v", err)
+	}
+	if byName.ID != c.ID {
+		t.Errorf("got the college №%d, want №%d", byName.ID, c.ID)
+	}
+
+	byID, err := r.Colleges.GetByID(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("cannot get the college by its ID: %v", err)
+	}
+	if byID.GeofenceMode != models.GeofenceModeFlag {
+		t.Errorf("got the geofence mode %q, want the default %q", byID.GeofenceMode, models.GeofenceModeFlag)
+	}
+	if byID.LateGrace != 5*time.Minute || byID.EarlyCheckIn != 15*time.Minute {
+		t.Errorf("got the grace periods %v and %v, want the defaults", byID.LateGrace, byID.EarlyCheckIn)
+	}
+
+	if _, err := r.Colleges.Get(ctx, "Unknown"); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for an unknown name, want ErrNotFound", err)
+	}
+	if _, err := r.Colleges.GetByID(ctx, c.ID+1000); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for an unknown ID, want ErrNotFound", err)
+	}
+	if _, err := r.Colleges.Delete(ctx, c.ID+1000); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v deleting an unknown college, want ErrNotFound", err)
+	}
+}
+
+func testCollegeUniqueName(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+
+	err := r.Colleges.Create(ctx, &models.College{Name: "College"})
+	if !errors.Is(err, abstractions.ErrDuplicate) {
+		t.Fatalf("got %v for a taken name, want ErrDuplicate", err)
+	}
+
+	// the name of a deleted college can be taken
+	if _, err := r.Colleges.Delete(ctx, c.ID); err != nil {
+		t.Fatalf("cannot delete the college: %v", err)
+	}
+	createCollege(t, r, "College")
+
+	// which is why the deleted one cannot come back
+	if err := r.Colleges.Restore(ctx, c.ID); !errors.Is(err, abstractions.ErrDuplicate) {
+		t.Errorf("got %v restoring a college with a taken name, want ErrDuplicate", err)
+	}
+}
+
+func testCollegeGeofenceMode(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+
+	fence := &geo.Fence{Center: &geo.Point{Latitude: 55.75, Longitude: 37.61}, Radius: 100}
+
+	if err := r.Colleges.SetGeofence(ctx, c.ID, fence, models.GeofenceModeReject); err != nil {
+		t.Fatalf("cannot set the geofence: %v", err)
+	}
+
+	got, err := r.Colleges.GetByID(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("cannot get the college: %v", err)
+	}
+	if got.Geofence == nil || got.Geofence.Radius != 100 || got.GeofenceMode != models.GeofenceModeReject {
+		t.Errorf("got the geofence %+v in the %q mode, want the one set", got.Geofence, got.GeofenceMode)
+	}
+
+	err = r.Colleges.SetGeofence(ctx, c.ID, fence, "bogus")
+	if !errors.Is(err, abstractions.ErrCheckViolation) {
+		t.Errorf("got %v for an unknown mode, want ErrCheckViolation", err)
+	}
+}
+
+func testCollegeDeletion(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	u := createUser(t, r, c.ID, "student@example.com", "student")
+	a := createAttendance(t, r, u.ID, c.ID, nil)
+
+	if err := r.Colleges.Restore(ctx, c.ID); !errors.Is(err, abstractions.ErrNotDeleted) {
+		t.Errorf("got %v restoring a college that is not deleted, want ErrNotDeleted", err)
+	}
+
+	if _, err := r.Colleges.Delete(ctx, c.ID); err != nil {
+		t.Fatalf("cannot delete the college: %v", err)
+	}
+
+	if _, err := r.Colleges.GetByID(ctx, c.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for a deleted college, want ErrNotFound", err)
+	}
+	if _, err := r.Attendances.Get(ctx, a.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for an attendance of a deleted college, want ErrNotFound", err)
+	}
+	if _, err := r.Colleges.Delete(ctx, c.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v deleting the college twice, want ErrNotFound", err)
+	}
+
+	deleted, err := r.Colleges.GetDeleted(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("cannot get the deleted colleges: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != c.ID || deleted[0].DeletedAt == nil {
+		t.Errorf("got %d deleted colleges, want the deleted one", len(deleted))
+	}
+
+	// the deleted records are listed by their college
+	other := createCollege(t, r, "Other")
+
+	if deleted, err := r.Colleges.GetDeleted(ctx, other.ID); err != nil || len(deleted) != 0 {
+		t.Errorf("got %d deleted colleges for another college (%v), want 0", len(deleted), err)
+	}
+	if deleted, err := r.Attendances.GetDeleted(ctx, other.ID); err != nil || len(deleted) != 0 {
+		t.Errorf("got %d deleted attendances of another college (%v), want 0", len(deleted), err)
+	}
+	if deleted, err := r.Attendances.GetDeleted(ctx, c.ID); err != nil || len(deleted) != 1 {
+		t.Errorf("got %d deleted attendances of the college (%v), want 1", len(deleted), err)
+	}
+
+	// the attendances come back with the college
+	if err := r.Colleges.Restore(ctx, c.ID); err != nil {
+		t.Fatalf("cannot restore the college: %v", err)
+	}
+	if _, err := r.Colleges.GetByID(ctx, c.ID); err != nil {
+		t.Errorf("cannot get the restored college: %v", err)
+	}
+	if _, err := r.Attendances.Get(ctx, a.ID); err != nil {
+		t.Errorf("cannot get the restored attendance: %v", err)
+	}
+}
+
+func testCollegePurge(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	kept := createCollege(t, r, "Kept")
+
+	if _, err := r.Colleges.Delete(ctx, c.ID); err != nil {
+		t.Fatalf("cannot delete the college: %v", err)
+	}
+
+	// the college has been deleted after the moment
+	purged, err := r.Colleges.Purge(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("cannot purge the colleges: %v", err)
+	}
+	if purged != 0 {
+		t.Errorf("purged %d colleges deleted after the moment, want 0", purged)
+	}
+
+	purged, err = r.Colleges.Purge(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("cannot purge the colleges: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("purged %d colleges, want 1", purged)
+	}
+
+	if err := r.Colleges.Restore(ctx, c.ID); !errors.Is(err, abstractions.ErrNotDeleted) {
+		t.Errorf("got %v restoring a purged college, want ErrNotDeleted", err)
+	}
+	if _, err := r.Colleges.GetByID(ctx, kept.ID); err != nil {
+		t.Errorf("cannot get the college that is not deleted: %v", err)
+	}
+}
+
+func testUserLookups(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	u := createUser(t, r, c.ID, "student@example.com", "student")
+
+	byEmail, err := r.Users.Get(ctx, "student@example.com")
+	if err != nil {
+		t.Fatalf("cannot get the user by their email: %v", err)
+	}
+	if byEmail.ID != u.ID || byEmail.CollegeID != c.ID || byEmail.Role != "student" {
+		t.Errorf("got the user %+v, want the one created", byEmail)
+	}
+
+	if _, err := r.Users.Get(ctx, "unknown@example.com"); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for an unknown email, want ErrNotFound", err)
+	}
+	if _, err := r.Users.GetByID(ctx, u.ID+1000); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for an unknown ID, want ErrNotFound", err)
+	}
+
+	username := "Renamed"
+	if _, err := r.Users.Update(ctx, u.ID, &username, nil); err != nil {
+		t.Fatalf("cannot update the user: %v", err)
+	}
+
+	byID, err := r.Users.GetByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("cannot get the user by their ID: %v", err)
+	}
+	if byID.Username != username || byID.Email != "student@example.com" {
+		t.Errorf("got the user %+v, want only the username changed", byID)
+	}
+}
+
+func testUserUniqueEmail(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	u := createUser(t, r, c.ID, "student@example.com", "student")
+	other := createUser(t, r, c.ID, "other@example.com", "student")
+
+	err := r.Users.Create(ctx, &models.User{
+		Username:     "Student",
+		Email:        "student@example.com",
+		PasswordHash: "hash",
+		Role:         "student",
+		CollegeID:    c.ID,
+	})
+	if !errors.Is(err, abstractions.ErrDuplicate) {
+		t.Fatalf("got %v for a taken email, want ErrDuplicate", err)
+	}
+
+	email := "student@example.com"
+	if _, err := r.Users.Update(ctx, other.ID, nil, &email); !errors.Is(err, abstractions.ErrDuplicate) {
+		t.Errorf("got %v changing the email to a taken one, want ErrDuplicate", err)
+	}
+
+	// the email of a deleted user can be taken
+	if _, err := r.Users.Delete(ctx, u.ID); err != nil {
+		t.Fatalf("cannot delete the user: %v", err)
+	}
+	createUser(t, r, c.ID, "student@example.com", "student")
+
+	if err := r.Users.Restore(ctx, u.ID); !errors.Is(err, abstractions.ErrDuplicate) {
+		t.Errorf("got %v restoring a user with a taken email, want ErrDuplicate", err)
+	}
+}
+
+func testUserConstraints(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	u := createUser(t, r, c.ID, "student@example.com", "student")
+
+	err := r.Users.Create(ctx, &models.User{
+		Username:     "Pirate",
+		Email:        "pirate@example.com",
+		PasswordHash: "hash",
+		Role:         "pirate",
+		CollegeID:    c.ID,
+	})
+	if !errors.Is(err, abstractions.ErrCheckViolation) {
+		t.Errorf("got %v for an unknown role, want ErrCheckViolation", err)
+	}
+
+	err = r.Users.Create(ctx, &models.User{
+		Username:     "Stranger",
+		Email:        "stranger@example.com",
+		PasswordHash: "hash",
+		Role:         "student",
+		CollegeID:    c.ID + 1000,
+	})
+	if !errors.Is(err, abstractions.ErrForeignKey) {
+		t.Errorf("got %v for an unknown college, want ErrForeignKey", err)
+	}
+
+	curatorID := u.ID + 1000
+	if err := r.Users.SetCurator(ctx, u.ID, &curatorID); !errors.Is(err, abstractions.ErrForeignKey) {
+		t.Errorf("got %v for an unknown curator, want ErrForeignKey", err)
+	}
+
+	teacher := createUser(t, r, c.ID, "teacher@example.com", "teacher")
+	if err := r.Users.SetCurator(ctx, u.ID, &teacher.ID); err != nil {
+		t.Fatalf("cannot set the curator: %v", err)
+	}
+
+	got, err := r.Users.GetByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("cannot get the user: %v", err)
+	}
+	if got.CuratorID == nil || *got.CuratorID != teacher.ID {
+		t.Errorf("got the curator %v, want №%d", got.CuratorID, teacher.ID)
+	}
+}
+
+func testUserDeletion(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	u := createUser(t, r, c.ID, "student@example.com", "student")
+	a := createAttendance(t, r, u.ID, c.ID, nil)
+
+	// the attendance deleted before is not restored with the user
+	gone := createAttendance(t, r, u.ID, c.ID, nil)
+	if _, err := r.Attendances.Delete(ctx, gone.ID); err != nil {
+		t.Fatalf("cannot delete the attendance: %v", err)
+	}
+
+	if _, err := r.Users.Delete(ctx, u.ID); err != nil {
+		t.Fatalf("cannot delete the user: %v", err)
+	}
+
+	if _, err := r.Users.GetByID(ctx, u.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for a deleted user, want ErrNotFound", err)
+	}
+	if _, err := r.Users.Get(ctx, "student@example.com"); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for the email of a deleted user, want ErrNotFound", err)
+	}
+	if _, err := r.Attendances.Get(ctx, a.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for an attendance of a deleted user, want ErrNotFound", err)
+	}
+	if _, err := r.Users.Delete(ctx, u.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v deleting the user twice, want ErrNotFound", err)
+	}
+
+	if err := r.Users.Restore(ctx, u.ID); err != nil {
+		t.Fatalf("cannot restore the user: %v", err)
+	}
+	if _, err := r.Users.GetByID(ctx, u.ID); err != nil {
+		t.Errorf("cannot get the restored user: %v", err)
+	}
+	if _, err := r.Attendances.Get(ctx, a.ID); err != nil {
+		t.Errorf("cannot get the attendance restored with the user: %v", err)
+	}
+	if _, err := r.Attendances.Get(ctx, gone.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for the attendance deleted before the user, want ErrNotFound", err)
+	}
+
+	// the user is purged after their attendances
+	if _, err := r.Users.Delete(ctx, u.ID); err != nil {
+		t.Fatalf("cannot delete the user: %v", err)
+	}
+	if _, err := r.Attendances.Purge(ctx, time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("cannot purge the attendances: %v", err)
+	}
+
+	purged, err := r.Users.Purge(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("cannot purge the users: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("purged %d users, want 1", purged)
+	}
+}
+
+func testPurgeKeepsAttendances(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	u := createUser(t, r, c.ID, "student@example.com", "student")
+	a := createAttendance(t, r, u.ID, c.ID, nil)
+	kept := createAttendance(t, r, u.ID, c.ID, nil)
+
+	if _, err := r.Users.Delete(ctx, u.ID); err != nil {
+		t.Fatalf("cannot delete the user: %v", err)
+	}
+	if _, err := r.Colleges.Delete(ctx, c.ID); err != nil {
+		t.Fatalf("cannot delete the college: %v", err)
+	}
+	if err := r.Attendances.Restore(ctx, kept.ID); err != nil {
+		t.Fatalf("cannot restore the attendance: %v", err)
+	}
+
+	// the user and the college wait for their attendances, deleted or not
+	purged, err := r.Users.Purge(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("cannot purge the users: %v", err)
+	}
+	if purged != 0 {
+		t.Errorf("purged %d users with attendances, want 0", purged)
+	}
+
+	purged, err = r.Colleges.Purge(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("cannot purge the colleges: %v", err)
+	}
+	if purged != 0 {
+		t.Errorf("purged %d colleges with attendances, want 0", purged)
+	}
+
+	if _, err := r.Attendances.Get(ctx, kept.ID); err != nil {
+		t.Errorf("cannot get the attendance that is not deleted: %v", err)
+	}
+	if err := r.Attendances.Restore(ctx, a.ID); err != nil {
+		t.Errorf("cannot restore the deleted attendance: %v", err)
+	}
+}
+
+func testAttendanceLookups(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	u := createUser(t, r, c.ID, "student@example.com", "student")
+	a := createAttendance(t, r, u.ID, c.ID, nil)
+
+	got, err := r.Attendances.Get(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("cannot get the attendance: %v", err)
+	}
+	if got.Status != models.AttendanceStatusOutsideLesson || got.GeofenceVerdict != geo.VerdictUnknown {
+		t.Errorf("got the status %q with the verdict %q, want the defaults", got.Status, got.GeofenceVerdict)
+	}
+
+	if _, err := r.Attendances.Get(ctx, a.ID+1000); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for an unknown ID, want ErrNotFound", err)
+	}
+
+	span, err := r.Attendances.GetByStudentAndDatespan(ctx, u.ID, a.Date.Add(-time.Hour), a.Date.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("cannot get the attendances of the span: %v", err)
+	}
+	if len(span) != 1 || span[0].ID != a.ID {
+		t.Errorf("got %d attendances of the span, want the one created", len(span))
+	}
+
+	span, err = r.Attendances.GetByStudentAndDatespan(ctx, u.ID, a.Date.Add(time.Hour), a.Date.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("cannot get the attendances of the span: %v", err)
+	}
+	if len(span) != 0 {
+		t.Errorf("got %d attendances outside the span, want 0", len(span))
+	}
+}
+
+func testAttendanceDeletion(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	u := createUser(t, r, c.ID, "student@example.com", "student")
+	a := createAttendance(t, r, u.ID, c.ID, nil)
+
+	if _, err := r.Attendances.Delete(ctx, a.ID+1000); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v deleting an unknown attendance, want ErrNotFound", err)
+	}
+	if err := r.Attendances.Restore(ctx, a.ID); !errors.Is(err, abstractions.ErrNotDeleted) {
+		t.Errorf("got %v restoring an attendance that is not deleted, want ErrNotDeleted", err)
+	}
+
+	if _, err := r.Attendances.Delete(ctx, a.ID); err != nil {
+		t.Fatalf("cannot delete the attendance: %v", err)
+	}
+	if _, err := r.Attendances.Get(ctx, a.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for a deleted attendance, want ErrNotFound", err)
+	}
+
+	if err := r.Attendances.Restore(ctx, a.ID); err != nil {
+		t.Fatalf("cannot restore the attendance: %v", err)
+	}
+	if _, err := r.Attendances.Get(ctx, a.ID); err != nil {
+		t.Errorf("cannot get the restored attendance: %v", err)
+	}
+
+	if _, err := r.Attendances.Delete(ctx, a.ID); err != nil {
+		t.Fatalf("cannot delete the attendance: %v", err)
+	}
+
+	purged, err := r.Attendances.Purge(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatalf("cannot purge the attendances: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("purged %d attendances, want 1", purged)
+	}
+	if err := r.Attendances.Restore(ctx, a.ID); !errors.Is(err, abstractions.ErrNotDeleted) {
+		t.Errorf("got %v restoring a purged attendance, want ErrNotDeleted", err)
+	}
+}
+
+func testAttendanceReferences(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	u := createUser(t, r, c.ID, "student@example.com", "student")
+
+	err := r.Attendances.Create(ctx, &models.Attendance{UserID: u.ID + 1000, CollegeID: c.ID, Date: time.Now()})
+	if !errors.Is(err, abstractions.ErrForeignKey) {
+		t.Errorf("got %v for an unknown user, want ErrForeignKey", err)
+	}
+
+	err = r.Attendances.Create(ctx, &models.Attendance{UserID: u.ID, CollegeID: c.ID + 1000, Date: time.Now()})
+	if !errors.Is(err, abstractions.ErrForeignKey) {
+		t.Errorf("got %v for an unknown college, want ErrForeignKey", err)
+	}
+}
+
+func testAbsenceMaterialization(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	teacher := createUser(t, r, c.ID, "teacher@example.com", "teacher")
+	present := createUser(t, r, c.ID, "present@example.com", "student")
+	absent := createUser(t, r, c.ID, "absent@example.com", "student")
+
+	startsAt := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	lesson := &models.Lesson{
+		CollegeID:  c.ID,
+		TeacherID:  teacher.ID,
+		Title:      "Lesson",
+		StartsAt:   startsAt,
+		EndsAt:     startsAt.Add(90 * time.Minute),
+		StudentIDs: []uint{present.ID, absent.ID},
+	}
+	if err := r.Lessons.Create(ctx, lesson); err != nil {
+		t.Fatalf("cannot create the lesson: %v", err)
+	}
+
+	createAttendance(t, r, present.ID, c.ID, &lesson.ID)
+
+	written, err := r.Attendances.MaterializeAbsences(ctx, lesson.ID)
+	if err != nil {
+		t.Fatalf("cannot materialize the absences: %v", err)
+	}
+	if written != 1 {
+		t.Errorf("wrote %d absences, want 1", written)
+	}
+
+	// re-running it writes nothing
+	written, err = r.Attendances.MaterializeAbsences(ctx, lesson.ID)
+	if err != nil {
+		t.Fatalf("cannot materialize the absences again: %v", err)
+	}
+	if written != 0 {
+		t.Errorf("wrote %d absences again, want 0", written)
+	}
+
+	got, err := r.Lessons.Get(ctx, lesson.ID)
+	if err != nil {
+		t.Fatalf("cannot get the lesson: %v", err)
+	}
+	if got.AbsencesMaterializedAt == nil {
+		t.Error("the lesson has not been marked materialized")
+	}
+
+	absence, err := r.Attendances.GetByLessonAndStudent(ctx, lesson.ID, absent.ID)
+	if err != nil {
+		t.Fatalf("cannot get the absence: %v", err)
+	}
+	if absence.Status != models.AttendanceStatusAbsent || !absence.Date.Equal(startsAt) {
+		t.Errorf("got the %q attendance at %v, want an absence at the start", absence.Status, absence.Date)
+	}
+
+	// a late scan replaces the absence
+	scan := createAttendance(t, r, absent.ID, c.ID, &lesson.ID)
+
+	attendances, err := r.Attendances.GetByLesson(ctx, lesson.ID)
+	if err != nil {
+		t.Fatalf("cannot get the attendances of the lesson: %v", err)
+	}
+	if len(attendances) != 2 {
+		t.Fatalf("got %d attendances of the lesson, want 2", len(attendances))
+	}
+	for _, a := range attendances {
+		if a.Status == models.AttendanceStatusAbsent {
+			t.Errorf("the absence of the student №%d has not been replaced", a.UserID)
+		}
+	}
+
+	latest, err := r.Attendances.GetLatestByStudent(ctx, absent.ID, 10)
+	if err != nil {
+		t.Fatalf("cannot get the latest attendances: %v", err)
+	}
+	if len(latest) != 1 || latest[0].ID != scan.ID {
+		t.Errorf("got %d latest attendances, want the scan", len(latest))
+	}
+}
+
+// Creates a college with the name
+func createCollege(t *testing.T, r Repos, name string) *models.College {
+	t.Helper()
+
+	c := &models.College{Name: name}
+	if err := r.Colleges.Create(context.Background(), c); err != nil {
+		t.Fatalf("cannot create the college %q: %v", name, err)
+	}
+
+	return c
+}
+
+// Creates a user of the college with the email and the role
+func createUser(t *testing.T, r Repos, collegeID uint, email string, role string) *models.User {
+	t.Helper()
+
+	u := &models.User{
+		Username:     email,
+		Email:        email,
+		PasswordHash: "hash",
+		Role:         role,
+		CollegeID:    collegeID,
+	}
+	if err := r.Users.Create(context.Background(), u); err != nil {
+		t.Fatalf("cannot create the user %q: %v", email, err)
+	}
+
+	return u
+}
+
+// Creates an on-time attendance of the student, lessonID may be nil
+func createAttendance(t *testing.T, r Repos, userID uint, collegeID uint, lessonID *uint) *models.Attendance {
+	t.Helper()
+
+	a := &models.Attendance{
+		UserID:    userID,
+		CollegeID: collegeID,
+		Date:      time.Now().Truncate(time.Second),
+		LessonID:  lessonID,
+	}
+	if lessonID != nil {
+		a.Status = models.AttendanceStatusOnTime
+	}
+
+	if err := r.Attendances.Create(context.Background(), a); err != nil {
+		t.Fatalf("cannot create the attendance: %v", err)
+	}
+
+	return a
+}
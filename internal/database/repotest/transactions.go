@@ -0,0 +1,106 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+func testTransactionRollback(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	u := createUser(t, r, c.ID, "student@example.com", "student")
+
+	before, err := r.Colleges.GetByID(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("cannot get the college: %v", err)
+	}
+
+	failure := errors.New("failure")
+	published := false
+
+	a := &models.Attendance{
+		UserID:    u.ID,
+		CollegeID: c.ID,
+		Date:      time.Now().Truncate(time.Second),
+	}
+
+	err = r.Transactor.InTx(ctx, func(ctx context.Context) error {
+		if err := r.Colleges.Create(ctx, &models.College{Name: "Rolled back"}); err != nil {
+			return err
+		}
+
+		// the nested transaction joins the running one
+		err := r.Transactor.InTx(ctx, func(ctx context.Context) error {
+			return r.Attendances.Create(ctx, a)
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := r.Colleges.SetGracePeriods(ctx, c.ID, time.Hour, time.Hour); err != nil {
+			return err
+		}
+
+		abstractions.AfterCommit(ctx, func() { published = true })
+
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("got %v, want the error of the transaction", err)
+	}
+
+	if published {
+		t.Error("the side effect of the rolled back transaction has run")
+	}
+	if _, err := r.Colleges.Get(ctx, "Rolled back"); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for the created college, want ErrNotFound", err)
+	}
+	if _, err := r.Attendances.Get(ctx, a.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for the attendance of the nested transaction, want ErrNotFound", err)
+	}
+
+	got, err := r.Colleges.GetByID(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("cannot get the college: %v", err)
+	}
+	if got.LateGrace != before.LateGrace || got.EarlyCheckIn != before.EarlyCheckIn {
+		t.Errorf("got the grace periods %v and %v, want the ones before the transaction", got.LateGrace, got.EarlyCheckIn)
+	}
+}
+
+func testTransactionCommit(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	published := false
+
+	err := r.Transactor.InTx(ctx, func(ctx context.Context) error {
+		if err := r.Colleges.Create(ctx, &models.College{Name: "Committed"}); err != nil {
+			return err
+		}
+
+		abstractions.AfterCommit(ctx, func() { published = true })
+
+		// the side effects wait for the commit
+		if published {
+			t.Error("the side effect has run before the commit")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("cannot run the transaction: %v", err)
+	}
+
+	if !published {
+		t.Error("the side effect has not run after the commit")
+	}
+	if _, err := r.Colleges.Get(ctx, "Committed"); err != nil {
+		t.Errorf("cannot get the committed college: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+func testWebhookSubscriptions(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	other := createCollege(t, r, "Other")
+
+	first := createSubscription(t, r, c.ID, models.EventAttendanceCreated)
+	second := createSubscription(t, r, c.ID, models.EventAttendanceCreated, models.EventAttendanceDeleted)
+	createSubscription(t, r, other.ID, models.EventAttendanceCreated)
+
+	if !first.Active || first.CreatedAt.IsZero() {
+		t.Errorf("got the active %v subscription created at %v, want an active one", first.Active, first.CreatedAt)
+	}
+
+	err := r.Webhooks.CreateSubscription(ctx, &models.WebhookSubscription{
+		CollegeID: c.ID + 1000,
+		URL:       "https://example.com/hook",
+		Secret:    "secret",
+		Events:    []string{models.EventAttendanceCreated},
+	})
+	if !errors.Is(err, abstractions.ErrForeignKey) {
+		t.Errorf("got %v for an unknown college, want ErrForeignKey", err)
+	}
+
+	got, err := r.Webhooks.GetSubscription(ctx, second.ID)
+	if err != nil {
+		t.Fatalf("cannot get the subscription: %v", err)
+	}
+	if got.Secret != "secret" || !slices.Equal(got.Events, second.Events) {
+		t.Errorf("got the secret %q and the events %v, want the ones created", got.Secret, got.Events)
+	}
+
+	subs, err := r.Webhooks.GetSubscriptions(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("cannot get the subscriptions: %v", err)
+	}
+	if len(subs) != 2 || subs[0].ID != first.ID || subs[1].ID != second.ID {
+		t.Errorf("got %d subscriptions of the college, want the 2 created in order", len(subs))
+	}
+
+	if err := r.Webhooks.DeleteSubscription(ctx, first.ID); err != nil {
+		t.Fatalf("cannot delete the subscription: %v", err)
+	}
+	if _, err := r.Webhooks.GetSubscription(ctx, first.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v for a deleted subscription, want ErrNotFound", err)
+	}
+	if err := r.Webhooks.DeleteSubscription(ctx, first.ID); !errors.Is(err, abstractions.ErrNotFound) {
+		t.Errorf("got %v deleting a deleted subscription, want ErrNotFound", err)
+	}
+}
+
+func testWebhookOutbox(t *testing.T, r Repos) {
+	ctx := context.Background()
+
+	c := createCollege(t, r, "College")
+	other := createCollege(t, r, "Other")
+
+	created := createSubscription(t, r, c.ID, models.EventAttendanceCreated)
+	both := createSubscription(t, r, c.ID, models.EventAttendanceCreated, models.EventAttendanceDeleted)
+	createSubscription(t, r, other.ID, models.EventAttendanceCreated)
+
+	enqueue := func(collegeID uint, event string, want int) {
+		t.Helper()
+
+		written, err := r.Webhooks.Enqueue(ctx, collegeID, event, []byte(`{"id":1}`))
+		if err != nil {
+			t.Fatalf("cannot enqueue the %s event: %v", event, err)
+		}
+		if written != want {
+			t.Errorf("wrote %d deliveries of the %s event, want %d", written, event, want)
+		}
+	}
+
+	enqueue(c.ID, models.EventAttendanceCreated, 2)
+	enqueue(c.ID, models.EventAttendanceDeleted, 1)
+	enqueue(c.ID, models.EventAttendanceUpdated, 0)
+	enqueue(other.ID, models.EventAttendanceDeleted, 0)
+	enqueue(other.ID, models.EventAttendanceCreated, 1)
+
+	now := time.Now().Add(time.Second)
+
+	claimed, err := r.Webhooks.ClaimDue(ctx, now, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("cannot claim the deliveries: %v", err)
+	}
+	if len(claimed) != 4 {
+		t.Fatalf("claimed %d deliveries, want 4", len(claimed))
+	}
+
+	var ofCollege []*models.WebhookDelivery
+	for _, d := range claimed {
+		if d.URL == "" || d.Secret == "" {
+			t.Errorf("the delivery №%d has been claimed without its endpoint", d.ID)
+		}
+		if d.SubscriptionID == created.ID || d.SubscriptionID == both.ID {
+			ofCollege = append(ofCollege, d)
+		}
+	}
+	if len(ofCollege) != 3 {
+		t.Fatalf("claimed %d deliveries of the college, want 3", len(ofCollege))
+	}
+
+	// the leased deliveries are not claimed again
+	again, err := r.Webhooks.ClaimDue(ctx, now, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("cannot claim the deliveries again: %v", err)
+	}
+	if len(again) != 0 {
+		t.Errorf("claimed %d leased deliveries, want 0", len(again))
+	}
+
+	next := now.Add(time.Hour)
+	if err := r.Webhooks.MarkDelivered(ctx, ofCollege[0].ID, 200); err != nil {
+		t.Fatalf("cannot mark the delivery delivered: %v", err)
+	}
+	if err := r.Webhooks.MarkAttemptFailed(ctx, ofCollege[1].ID, 500, "server error", &next); err != nil {
+		t.Fatalf("cannot record the failed attempt: %v", err)
+	}
+	if err := r.Webhooks.MarkAttemptFailed(ctx, ofCollege[2].ID, 0, "refused", nil); err != nil {
+		t.Fatalf("cannot record the last attempt: %v", err)
+	}
+
+	statuses := map[uint]*models.WebhookDelivery{}
+	for _, sub := range []uint{created.ID, both.ID} {
+		deliveries, err := r.Webhooks.GetDeliveries(ctx, sub, 10)
+		if err != nil {
+			t.Fatalf("cannot get the deliveries: %v", err)
+		}
+
+		for _, d := range deliveries {
+			statuses[d.ID] = d
+		}
+	}
+
+	delivered := statuses[ofCollege[0].ID]
+	if delivered == nil || delivered.Status != models.DeliveryStatusDelivered ||
+		delivered.Attempts != 1 || delivered.LastStatusCode != 200 || delivered.DeliveredAt == nil {
+		t.Errorf("got the delivered one %+v, want it delivered at the first attempt", delivered)
+	}
+
+	retried := statuses[ofCollege[1].ID]
+	if retried == nil || retried.Status != models.DeliveryStatusPending ||
+		retried.Attempts != 1 || retried.LastError != "server error" {
+		t.Errorf("got the retried one %+v, want it pending after an attempt", retried)
+	}
+
+	failed := statuses[ofCollege[2].ID]
+	if failed == nil || failed.Status != models.DeliveryStatusFailed || failed.LastError != "refused" {
+		t.Errorf("got the failed one %+v, want it failed", failed)
+	}
+
+	// a subscription takes its deliveries with it
+	if err := r.Webhooks.DeleteSubscription(ctx, both.ID); err != nil {
+		t.Fatalf("cannot delete the subscription: %v", err)
+	}
+
+	deliveries, err := r.Webhooks.GetDeliveries(ctx, both.ID, 10)
+	if err != nil {
+		t.Fatalf("cannot get the deliveries: %v", err)
+	}
+	if len(deliveries) != 0 {
+		t.Errorf("got %d deliveries of a deleted subscription, want 0", len(deliveries))
+	}
+}
+
+// Creates a subscription of the college to the events
+func createSubscription(t *testing.T, r Repos, collegeID uint, events ...string) *models.WebhookSubscription {
+	t.Helper()
+
+	s := &models.WebhookSubscription{
+		CollegeID: collegeID,
+		URL:       "https://example.com/hook",
+		Secret:    "secret",
+		Events:    events,
+	}
+	if err := r.Webhooks.CreateSubscription(context.Background(), s); err != nil {
+		t.Fatalf("cannot create the subscription: %v", err)
+	}
+
+	return s
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Key of the cancel function of the statement's timeout
+const timeoutCancelKey = "database:timeout_cancel"
+
+// Limits every statement to the timeout, so a slow query
+// fails with context.DeadlineExceeded instead of holding the request
+type queryTimeout struct {
+	timeout time.Duration
+}
+
+// Returns the name of the plugin
+func (p queryTimeout) Name() string {
+	return "database:query_timeout"
+}
+
+// Registers the callbacks around the statements.
+// Row and Rows are skipped, since their result is read after the callbacks
+func (p queryTimeout) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	errs := []error{
+		cb.Create().Before("*").Register("database:timeout_start", p.start),
+		cb.Create().After("*").Register("database:timeout_stop", p.stop),
+		cb.Query().Before("*").Register("database:timeout_start", p.start),
+		cb.Query().After("*").Register("database:timeout_stop", p.stop),
+		cb.Update().Before("*").Register("database:timeout_start", p.start),
+		cb.Update().After("*").Register("database:timeout_stop", p.stop),
+		cb.Delete().Before("*").Register("database:timeout_start", p.start),
+		cb.Delete().After("*").Register("database:timeout_stop", p.stop),
+		cb.Raw().Before("*").Register("database:timeout_start", p.start),
+		cb.Raw().After("*").Register("database:timeout_stop", p.stop),
+	}
+
+	return errors.Join(errs...)
+}
+
+// Replaces the context of the statement with the one that has the timeout
+func (p queryTimeout) start(db *gorm.DB) {
+	ctx, cancel := context.WithTimeout(db.Statement.Context, p.timeout)
+
+	db.Statement.Context = ctx
+	db.InstanceSet(timeoutCancelKey, cancel)
+}
+
+// Releases the timeout of the statement
+func (p queryTimeout) stop(db *gorm.DB) {
+	if cancel, ok := db.InstanceGet(timeoutCancelKey); ok {
+		cancel.(context.CancelFunc)()
+	}
+}
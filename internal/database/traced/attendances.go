@@ -0,0 +1,140 @@
+package traced
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents a repository of attendances tracing its methods
+type Attendances struct {
+	next abstractions.AttendancesRepo
+}
+
+// Creates new repo of attendances tracing the one passed
+func NewAttendances(next abstractions.AttendancesRepo) *Attendances {
+	return &Attendances{next: next}
+}
+
+// Adds a new record to the db
+func (r *Attendances) Create(ctx context.Context, a *models.Attendance) (err error) {
+	ctx, span := start(ctx, "Attendances.Create")
+	defer func() { end(span, err) }()
+
+	return r.next.Create(ctx, a)
+}
+
+// Returns an attendance by an ID
+func (r *Attendances) Get(ctx context.Context, id uint) (_ *models.Attendance, err error) {
+	ctx, span := start(ctx, "Attendances.Get")
+	defer func() { end(span, err) }()
+
+	return r.next.Get(ctx, id)
+}
+
+// Returns the attendances of the user and date span
+func (r *Attendances) GetByStudentAndDatespan(ctx context.Context, id uint, from time.Time, to time.Time) (_ []*models.Attendance, err error) {
+	ctx, span := start(ctx, "Attendances.GetByStudentAndDatespan")
+	defer func() { end(span, err) }()
+
+	return r.next.GetByStudentAndDatespan(ctx, id, from, to)
+}
+
+// Returns the attendances of the lesson
+func (r *Attendances) GetByLesson(ctx context.Context, lessonID uint) (_ []*models.Attendance, err error) {
+	ctx, span := start(ctx, "Attendances.GetByLesson")
+	defer func() { end(span, err) }()
+
+	return r.next.GetByLesson(ctx, lessonID)
+}
+
+// Returns the latest attendances of the student matched with lessons
+func (r *Attendances) GetLatestByStudent(ctx context.Context, id uint, limit int) (_ []*models.Attendance, err error) {
+	ctx, span := start(ctx, "Attendances.GetLatestByStudent")
+	defer func() { end(span, err) }()
+
+	return r.next.GetLatestByStudent(ctx, id, limit)
+}
+
+// Writes absent records for the lesson's students without an attendance
+// and returns how many have been written. Re-running it is safe
+func (r *Attendances) MaterializeAbsences(ctx context.Context, lessonID uint) (_ int, err error) {
+	ctx, span := start(ctx, "Attendances.MaterializeAbsences")
+	defer func() { end(span, err) }()
+
+	return r.next.MaterializeAbsences(ctx, lessonID)
+}
+
+// Returns the attendance of the student at the lesson
+func (r *Attendances) GetByLessonAndStudent(ctx context.Context, lessonID uint, studentID uint) (_ *models.Attendance, err error) {
+	ctx, span := start(ctx, "Attendances.GetByLessonAndStudent")
+	defer func() { end(span, err) }()
+
+	return r.next.GetByLessonAndStudent(ctx, lessonID, studentID)
+}
+
+// Creates the attendance marked by a teacher and records the change
+func (r *Attendances) Mark(ctx context.Context, a *models.Attendance, c *models.AttendanceChange) (err error) {
+	ctx, span := start(ctx, "Attendances.Mark")
+	defer func() { end(span, err) }()
+
+	return r.next.Mark(ctx, a, c)
+}
+
+// Changes the status of the attendance and records the change
+func (r *Attendances) ChangeStatus(ctx context.Context, id uint, status string, minutesLate int, c *models.AttendanceChange) (err error) {
+	ctx, span := start(ctx, "Attendances.ChangeStatus")
+	defer func() { end(span, err) }()
+
+	return r.next.ChangeStatus(ctx, id, status, minutesLate, c)
+}
+
+// Deletes the attendance unmarked by a teacher and records the change
+func (r *Attendances) Unmark(ctx context.Context, id uint, c *models.AttendanceChange) (err error) {
+	ctx, span := start(ctx, "Attendances.Unmark")
+	defer func() { end(span, err) }()
+
+	return r.next.Unmark(ctx, id, c)
+}
+
+// Returns the history of the manual changes of the attendance
+func (r *Attendances) GetHistory(ctx context.Context, id uint) (_ []*models.AttendanceChange, err error) {
+	ctx, span := start(ctx, "Attendances.GetHistory")
+	defer func() { end(span, err) }()
+
+	return r.next.GetHistory(ctx, id)
+}
+
+// Deletes an attendance by an ID
+func (r *Attendances) Delete(ctx context.Context, id uint) (_ uint, err error) {
+	ctx, span := start(ctx, "Attendances.Delete")
+	defer func() { end(span, err) }()
+
+	return r.next.Delete(ctx, id)
+}
+
+// Returns the soft-deleted attendances of the college
+func (r *Attendances) GetDeleted(ctx context.Context, collegeID uint) (_ []*models.Attendance, err error) {
+	ctx, span := start(ctx, "Attendances.GetDeleted")
+	defer func() { end(span, err) }()
+
+	return r.next.GetDeleted(ctx, collegeID)
+}
+
+// Restores the soft-deleted record with the ID passed
+func (r *Attendances) Restore(ctx context.Context, id uint) (err error) {
+	ctx, span := start(ctx, "Attendances.Restore")
+	defer func() { end(span, err) }()
+
+	return r.next.Restore(ctx, id)
+}
+
+// Permanently deletes the attendances soft-deleted before the moment passed
+func (r *Attendances) Purge(ctx context.Context, before time.Time) (_ int, err error) {
+	ctx, span := start(ctx, "Attendances.Purge")
+	defer func() { end(span, err) }()
+
+	return r.next.Purge(ctx, before)
+}
@@ -0,0 +1,34 @@
+package traced
+
+import (
+	"context"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents a repository of the audit log tracing its methods
+type Audit struct {
+	next abstractions.AuditRepo
+}
+
+// Creates new repo of the audit log tracing the one passed
+func NewAudit(next abstractions.AuditRepo) *Audit {
+	return &Audit{next: next}
+}
+
+// Appends a record to the log
+func (r *Audit) Append(ctx context.Context, a *models.AuditRecord) (err error) {
+	ctx, span := start(ctx, "Audit.Append")
+	defer func() { end(span, err) }()
+
+	return r.next.Append(ctx, a)
+}
+
+// Returns the records matching the filter, the newest first
+func (r *Audit) List(ctx context.Context, f models.AuditFilter) (_ []*models.AuditRecord, err error) {
+	ctx, span := start(ctx, "Audit.List")
+	defer func() { end(span, err) }()
+
+	return r.next.List(ctx, f)
+}
@@ -0,0 +1,92 @@
+package traced
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/geo"
+)
+
+// Represents a repository of colleges tracing its methods
+type Colleges struct {
+	next abstractions.CollegesRepo
+}
+
+// Creates new repo of colleges tracing the one passed
+func NewColleges(next abstractions.CollegesRepo) *Colleges {
+	return &Colleges{next: next}
+}
+
+// Adds a college to the db
+func (r *Colleges) Create(ctx context.Context, c *models.College) (err error) {
+	ctx, span := start(ctx, "Colleges.Create")
+	defer func() { end(span, err) }()
+
+	return r.next.Create(ctx, c)
+}
+
+// Returns college by its name
+func (r *Colleges) Get(ctx context.Context, name string) (_ *models.College, err error) {
+	ctx, span := start(ctx, "Colleges.Get")
+	defer func() { end(span, err) }()
+
+	return r.next.Get(ctx, name)
+}
+
+// Returns college by its ID
+func (r *Colleges) GetByID(ctx context.Context, id uint) (_ *models.College, err error) {
+	ctx, span := start(ctx, "Colleges.GetByID")
+	defer func() { end(span, err) }()
+
+	return r.next.GetByID(ctx, id)
+}
+
+// Sets the geofence of the college, nil fence removes it
+func (r *Colleges) SetGeofence(ctx context.Context, id uint, fence *geo.Fence, mode string) (err error) {
+	ctx, span := start(ctx, "Colleges.SetGeofence")
+	defer func() { end(span, err) }()
+
+	return r.next.SetGeofence(ctx, id, fence, mode)
+}
+
+// Sets the grace periods used for classifying the arrivals
+func (r *Colleges) SetGracePeriods(ctx context.Context, id uint, lateGrace time.Duration, earlyCheckIn time.Duration) (err error) {
+	ctx, span := start(ctx, "Colleges.SetGracePeriods")
+	defer func() { end(span, err) }()
+
+	return r.next.SetGracePeriods(ctx, id, lateGrace, earlyCheckIn)
+}
+
+// Deletes the college and returns its ID
+func (r *Colleges) Delete(ctx context.Context, id uint) (_ uint, err error) {
+	ctx, span := start(ctx, "Colleges.Delete")
+	defer func() { end(span, err) }()
+
+	return r.next.Delete(ctx, id)
+}
+
+// Returns the college if it is soft-deleted
+func (r *Colleges) GetDeleted(ctx context.Context, collegeID uint) (_ []*models.College, err error) {
+	ctx, span := start(ctx, "Colleges.GetDeleted")
+	defer func() { end(span, err) }()
+
+	return r.next.GetDeleted(ctx, collegeID)
+}
+
+// Restores the soft-deleted record with the ID passed
+func (r *Colleges) Restore(ctx context.Context, id uint) (err error) {
+	ctx, span := start(ctx, "Colleges.Restore")
+	defer func() { end(span, err) }()
+
+	return r.next.Restore(ctx, id)
+}
+
+// Permanently deletes the colleges soft-deleted before the moment passed
+func (r *Colleges) Purge(ctx context.Context, before time.Time) (_ int, err error) {
+	ctx, span := start(ctx, "Colleges.Purge")
+	defer func() { end(span, err) }()
+
+	return r.next.Purge(ctx, before)
+}
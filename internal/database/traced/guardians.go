@@ -0,0 +1,58 @@
+package traced
+
+import (
+	"context"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents a repository of guardians tracing its methods
+type Guardians struct {
+	next abstractions.GuardiansRepo
+}
+
+// Creates new repo of guardians tracing the one passed
+func NewGuardians(next abstractions.GuardiansRepo) *Guardians {
+	return &Guardians{next: next}
+}
+
+// Links the student to the guardian, linking them again does nothing
+func (r *Guardians) Link(ctx context.Context, l *models.GuardianLink) (err error) {
+	ctx, span := start(ctx, "Guardians.Link")
+	defer func() { end(span, err) }()
+
+	return r.next.Link(ctx, l)
+}
+
+// Removes the link and returns false if there has been none
+func (r *Guardians) Unlink(ctx context.Context, guardianID uint, studentID uint) (_ bool, err error) {
+	ctx, span := start(ctx, "Guardians.Unlink")
+	defer func() { end(span, err) }()
+
+	return r.next.Unlink(ctx, guardianID, studentID)
+}
+
+// Checks whether the student is linked to the guardian
+func (r *Guardians) IsLinked(ctx context.Context, guardianID uint, studentID uint) (_ bool, err error) {
+	ctx, span := start(ctx, "Guardians.IsLinked")
+	defer func() { end(span, err) }()
+
+	return r.next.IsLinked(ctx, guardianID, studentID)
+}
+
+// Returns the students linked to the guardian
+func (r *Guardians) GetStudents(ctx context.Context, guardianID uint) (_ []*models.User, err error) {
+	ctx, span := start(ctx, "Guardians.GetStudents")
+	defer func() { end(span, err) }()
+
+	return r.next.GetStudents(ctx, guardianID)
+}
+
+// Returns the guardians linked to the student
+func (r *Guardians) GetGuardians(ctx context.Context, studentID uint) (_ []*models.User, err error) {
+	ctx, span := start(ctx, "Guardians.GetGuardians")
+	defer func() { end(span, err) }()
+
+	return r.next.GetGuardians(ctx, studentID)
+}
@@ -0,0 +1,94 @@
+package traced
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents a repository of jobs tracing its methods
+type Jobs struct {
+	next abstractions.JobsRepo
+}
+
+// Creates new repo of jobs tracing the one passed
+func NewJobs(next abstractions.JobsRepo) *Jobs {
+	return &Jobs{next: next}
+}
+
+// Adds a job to the queue, a job with a unique key
+// that has already been enqueued is skipped and false is returned
+func (r *Jobs) Enqueue(ctx context.Context, j *models.Job) (_ bool, err error) {
+	ctx, span := start(ctx, "Jobs.Enqueue")
+	defer func() { end(span, err) }()
+
+	return r.next.Enqueue(ctx, j)
+}
+
+// Returns a job by its ID
+func (r *Jobs) Get(ctx context.Context, id uint) (_ *models.Job, err error) {
+	ctx, span := start(ctx, "Jobs.Get")
+	defer func() { end(span, err) }()
+
+	return r.next.Get(ctx, id)
+}
+
+// Returns the latest jobs with the status
+func (r *Jobs) GetByStatus(ctx context.Context, status string, limit int) (_ []*models.Job, err error) {
+	ctx, span := start(ctx, "Jobs.GetByStatus")
+	defer func() { end(span, err) }()
+
+	return r.next.GetByStatus(ctx, status, limit)
+}
+
+// Claims up to limit due jobs of the kinds and marks them running,
+// they are claimed again only if the lease passes before they finish
+func (r *Jobs) Claim(ctx context.Context, kinds []string, now time.Time, limit int, lease time.Duration) (_ []*models.Job, err error) {
+	ctx, span := start(ctx, "Jobs.Claim")
+	defer func() { end(span, err) }()
+
+	return r.next.Claim(ctx, kinds, now, limit, lease)
+}
+
+// Marks the job as done
+func (r *Jobs) Complete(ctx context.Context, id uint) (err error) {
+	ctx, span := start(ctx, "Jobs.Complete")
+	defer func() { end(span, err) }()
+
+	return r.next.Complete(ctx, id)
+}
+
+// Records a failed attempt, the job is run again at runAt
+func (r *Jobs) Retry(ctx context.Context, id uint, runAt time.Time, errMsg string) (err error) {
+	ctx, span := start(ctx, "Jobs.Retry")
+	defer func() { end(span, err) }()
+
+	return r.next.Retry(ctx, id, runAt, errMsg)
+}
+
+// Moves the job to the dead letters
+func (r *Jobs) Kill(ctx context.Context, id uint, errMsg string) (err error) {
+	ctx, span := start(ctx, "Jobs.Kill")
+	defer func() { end(span, err) }()
+
+	return r.next.Kill(ctx, id, errMsg)
+}
+
+// Puts a dead job back to the queue with fresh attempts
+func (r *Jobs) Requeue(ctx context.Context, id uint, runAt time.Time) (err error) {
+	ctx, span := start(ctx, "Jobs.Requeue")
+	defer func() { end(span, err) }()
+
+	return r.next.Requeue(ctx, id, runAt)
+}
+
+// Permanently deletes the jobs done before the moment
+// and returns how many have been deleted
+func (r *Jobs) PurgeDone(ctx context.Context, before time.Time) (_ int, err error) {
+	ctx, span := start(ctx, "Jobs.PurgeDone")
+	defer func() { end(span, err) }()
+
+	return r.next.PurgeDone(ctx, before)
+}
@@ -0,0 +1,61 @@
+package traced
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents a repository of lessons tracing its methods
+type Lessons struct {
+	next abstractions.LessonsRepo
+}
+
+// Creates new repo of lessons tracing the one passed
+func NewLessons(next abstractions.LessonsRepo) *Lessons {
+	return &Lessons{next: next}
+}
+
+// Adds a lesson with its students to the db
+func (r *Lessons) Create(ctx context.Context, l *models.Lesson) (err error) {
+	ctx, span := start(ctx, "Lessons.Create")
+	defer func() { end(span, err) }()
+
+	return r.next.Create(ctx, l)
+}
+
+// Returns a lesson by its ID
+func (r *Lessons) Get(ctx context.Context, id uint) (_ *models.Lesson, err error) {
+	ctx, span := start(ctx, "Lessons.Get")
+	defer func() { end(span, err) }()
+
+	return r.next.Get(ctx, id)
+}
+
+// Returns the student's lesson that runs at the moment passed,
+// the lesson is matched the early duration before its start
+func (r *Lessons) GetByStudentAt(ctx context.Context, studentID uint, at time.Time, early time.Duration) (_ *models.Lesson, err error) {
+	ctx, span := start(ctx, "Lessons.GetByStudentAt")
+	defer func() { end(span, err) }()
+
+	return r.next.GetByStudentAt(ctx, studentID, at, early)
+}
+
+// Returns the lessons that have ended before the moment passed
+// and have no absences materialized yet
+func (r *Lessons) GetEndedUnmaterialized(ctx context.Context, before time.Time) (_ []*models.Lesson, err error) {
+	ctx, span := start(ctx, "Lessons.GetEndedUnmaterialized")
+	defer func() { end(span, err) }()
+
+	return r.next.GetEndedUnmaterialized(ctx, before)
+}
+
+// Deletes a lesson by its ID
+func (r *Lessons) Delete(ctx context.Context, id uint) (_ uint, err error) {
+	ctx, span := start(ctx, "Lessons.Delete")
+	defer func() { end(span, err) }()
+
+	return r.next.Delete(ctx, id)
+}
@@ -0,0 +1,66 @@
+package traced
+
+import (
+	"context"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents a repository of notifications tracing its methods
+type Notifications struct {
+	next abstractions.NotificationsRepo
+}
+
+// Creates new repo of notifications tracing the one passed
+func NewNotifications(next abstractions.NotificationsRepo) *Notifications {
+	return &Notifications{next: next}
+}
+
+// Returns the preference of the user or nil if they have not set one
+func (r *Notifications) GetPreference(ctx context.Context, userID uint) (_ *models.NotificationPreference, err error) {
+	ctx, span := start(ctx, "Notifications.GetPreference")
+	defer func() { end(span, err) }()
+
+	return r.next.GetPreference(ctx, userID)
+}
+
+// Creates or replaces the preference of the user
+func (r *Notifications) SetPreference(ctx context.Context, p *models.NotificationPreference) (err error) {
+	ctx, span := start(ctx, "Notifications.SetPreference")
+	defer func() { end(span, err) }()
+
+	return r.next.SetPreference(ctx, p)
+}
+
+// Adds a rule to the db
+func (r *Notifications) CreateRule(ctx context.Context, rule *models.NotificationRule) (err error) {
+	ctx, span := start(ctx, "Notifications.CreateRule")
+	defer func() { end(span, err) }()
+
+	return r.next.CreateRule(ctx, rule)
+}
+
+// Returns a rule by its ID
+func (r *Notifications) GetRule(ctx context.Context, id uint) (_ *models.NotificationRule, err error) {
+	ctx, span := start(ctx, "Notifications.GetRule")
+	defer func() { end(span, err) }()
+
+	return r.next.GetRule(ctx, id)
+}
+
+// Returns the rules of the college
+func (r *Notifications) GetRules(ctx context.Context, collegeID uint) (_ []*models.NotificationRule, err error) {
+	ctx, span := start(ctx, "Notifications.GetRules")
+	defer func() { end(span, err) }()
+
+	return r.next.GetRules(ctx, collegeID)
+}
+
+// Deletes a rule by its ID
+func (r *Notifications) DeleteRule(ctx context.Context, id uint) (err error) {
+	ctx, span := start(ctx, "Notifications.DeleteRule")
+	defer func() { end(span, err) }()
+
+	return r.next.DeleteRule(ctx, id)
+}
@@ -0,0 +1,31 @@
+// Contains the repositories tracing the methods of the ones they wrap,
+// so every call is seen as a span between the request and its queries
+package traced
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name of the instrumentation of the repositories
+const tracerName = "github.com/cyberbrain-dev/na-meste-api/internal/database/traced"
+
+// Starts the span of the repository method
+func start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, method, trace.WithSpanKind(trace.SpanKindInternal))
+}
+
+// Ends the span of the repository method,
+// the records that are not found are not the failures of the storage
+func end(span trace.Span, err error) {
+	if errors.Is(err, abstractions.ErrNotFound) {
+		err = nil
+	}
+
+	tracing.End(span, err)
+}
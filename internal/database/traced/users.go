@@ -0,0 +1,99 @@
+package traced
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents a repository of users tracing its methods
+type Users struct {
+	next abstractions.UsersRepo
+}
+
+// Creates new repo of users tracing the one passed
+func NewUsers(next abstractions.UsersRepo) *Users {
+	return &Users{next: next}
+}
+
+// Adds a new user record to the database
+func (r *Users) Create(ctx context.Context, u *models.User) (err error) {
+	ctx, span := start(ctx, "Users.Create")
+	defer func() { end(span, err) }()
+
+	return r.next.Create(ctx, u)
+}
+
+// Returns a user by their email
+func (r *Users) Get(ctx context.Context, email string) (_ *models.User, err error) {
+	ctx, span := start(ctx, "Users.Get")
+	defer func() { end(span, err) }()
+
+	return r.next.Get(ctx, email)
+}
+
+// Returns a user by their ID
+func (r *Users) GetByID(ctx context.Context, id uint) (_ *models.User, err error) {
+	ctx, span := start(ctx, "Users.GetByID")
+	defer func() { end(span, err) }()
+
+	return r.next.GetByID(ctx, id)
+}
+
+// Updates user with the ID passed
+func (r *Users) Update(ctx context.Context, id uint, username *string, email *string) (_ uint, err error) {
+	ctx, span := start(ctx, "Users.Update")
+	defer func() { end(span, err) }()
+
+	return r.next.Update(ctx, id, username, email)
+}
+
+// Sets the role of the user with the ID passed
+func (r *Users) SetRole(ctx context.Context, id uint, role string) (err error) {
+	ctx, span := start(ctx, "Users.SetRole")
+	defer func() { end(span, err) }()
+
+	return r.next.SetRole(ctx, id, role)
+}
+
+// Sets the curator of the student with the ID passed, nil removes them
+func (r *Users) SetCurator(ctx context.Context, id uint, curatorID *uint) (err error) {
+	ctx, span := start(ctx, "Users.SetCurator")
+	defer func() { end(span, err) }()
+
+	return r.next.SetCurator(ctx, id, curatorID)
+}
+
+// Deletes user with the ID passed
+func (r *Users) Delete(ctx context.Context, id uint) (_ uint, err error) {
+	ctx, span := start(ctx, "Users.Delete")
+	defer func() { end(span, err) }()
+
+	return r.next.Delete(ctx, id)
+}
+
+// Returns the soft-deleted users of the college
+func (r *Users) GetDeleted(ctx context.Context, collegeID uint) (_ []*models.User, err error) {
+	ctx, span := start(ctx, "Users.GetDeleted")
+	defer func() { end(span, err) }()
+
+	return r.next.GetDeleted(ctx, collegeID)
+}
+
+// Restores the soft-deleted record with the ID passed
+func (r *Users) Restore(ctx context.Context, id uint) (err error) {
+	ctx, span := start(ctx, "Users.Restore")
+	defer func() { end(span, err) }()
+
+	return r.next.Restore(ctx, id)
+}
+
+// Permanently deletes the users soft-deleted before the moment passed
+func (r *Users) Purge(ctx context.Context, before time.Time) (_ int, err error) {
+	ctx, span := start(ctx, "Users.Purge")
+	defer func() { end(span, err) }()
+
+	return r.next.Purge(ctx, before)
+}
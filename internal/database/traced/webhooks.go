@@ -0,0 +1,94 @@
+package traced
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents a repository of webhooks tracing its methods
+type Webhooks struct {
+	next abstractions.WebhooksRepo
+}
+
+// Creates new repo of webhooks tracing the one passed
+func NewWebhooks(next abstractions.WebhooksRepo) *Webhooks {
+	return &Webhooks{next: next}
+}
+
+// Adds a subscription to the db
+func (r *Webhooks) CreateSubscription(ctx context.Context, s *models.WebhookSubscription) (err error) {
+	ctx, span := start(ctx, "Webhooks.CreateSubscription")
+	defer func() { end(span, err) }()
+
+	return r.next.CreateSubscription(ctx, s)
+}
+
+// Returns a subscription by its ID
+func (r *Webhooks) GetSubscription(ctx context.Context, id uint) (_ *models.WebhookSubscription, err error) {
+	ctx, span := start(ctx, "Webhooks.GetSubscription")
+	defer func() { end(span, err) }()
+
+	return r.next.GetSubscription(ctx, id)
+}
+
+// Returns the subscriptions of the college
+func (r *Webhooks) GetSubscriptions(ctx context.Context, collegeID uint) (_ []*models.WebhookSubscription, err error) {
+	ctx, span := start(ctx, "Webhooks.GetSubscriptions")
+	defer func() { end(span, err) }()
+
+	return r.next.GetSubscriptions(ctx, collegeID)
+}
+
+// Deletes a subscription with its deliveries
+func (r *Webhooks) DeleteSubscription(ctx context.Context, id uint) (err error) {
+	ctx, span := start(ctx, "Webhooks.DeleteSubscription")
+	defer func() { end(span, err) }()
+
+	return r.next.DeleteSubscription(ctx, id)
+}
+
+// Writes a pending delivery for every active subscription
+// of the college to the event and returns how many have been written
+func (r *Webhooks) Enqueue(ctx context.Context, collegeID uint, event string, payload []byte) (_ int, err error) {
+	ctx, span := start(ctx, "Webhooks.Enqueue")
+	defer func() { end(span, err) }()
+
+	return r.next.Enqueue(ctx, collegeID, event, payload)
+}
+
+// Claims up to limit pending deliveries that are due,
+// they are not claimed again until the lease passes
+func (r *Webhooks) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) (_ []*models.WebhookDelivery, err error) {
+	ctx, span := start(ctx, "Webhooks.ClaimDue")
+	defer func() { end(span, err) }()
+
+	return r.next.ClaimDue(ctx, now, limit, lease)
+}
+
+// Marks the delivery as delivered
+func (r *Webhooks) MarkDelivered(ctx context.Context, id uint, statusCode int) (err error) {
+	ctx, span := start(ctx, "Webhooks.MarkDelivered")
+	defer func() { end(span, err) }()
+
+	return r.next.MarkDelivered(ctx, id, statusCode)
+}
+
+// Records a failed attempt, the delivery is retried at next
+// or is failed for good if next is nil
+func (r *Webhooks) MarkAttemptFailed(ctx context.Context, id uint, statusCode int, errMsg string, next *time.Time) (err error) {
+	ctx, span := start(ctx, "Webhooks.MarkAttemptFailed")
+	defer func() { end(span, err) }()
+
+	return r.next.MarkAttemptFailed(ctx, id, statusCode, errMsg, next)
+}
+
+// Returns the latest deliveries of the subscription
+func (r *Webhooks) GetDeliveries(ctx context.Context, subscriptionID uint, limit int) (_ []*models.WebhookDelivery, err error) {
+	ctx, span := start(ctx, "Webhooks.GetDeliveries")
+	defer func() { end(span, err) }()
+
+	return r.next.GetDeliveries(ctx, subscriptionID, limit)
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/tracing"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// Name of the instrumentation of the statements
+const tracerName = "github.com/cyberbrain-dev/na-meste-api/internal/database"
+
+// Key of the span of the statement
+const traceSpanKey = "database:trace_span"
+
+// Starts a span for every statement, so the slow queries
+// are seen under the repository methods that run them
+type queryTracing struct{}
+
+// Returns the name of the plugin
+func (p queryTracing) Name() string {
+	return "database:query_tracing"
+}
+
+// Registers the callbacks around the statements
+func (p queryTracing) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+
+	errs := []error{
+		cb.Create().Before("*").Register("database:trace_start", p.start("INSERT")),
+		cb.Create().After("*").Register("database:trace_stop", p.stop),
+		cb.Query().Before("*").Register("database:trace_start", p.start("SELECT")),
+		cb.Query().After("*").Register("database:trace_stop", p.stop),
+		cb.Update().Before("*").Register("database:trace_start", p.start("UPDATE")),
+		cb.Update().After("*").Register("database:trace_stop", p.stop),
+		cb.Delete().Before("*").Register("database:trace_start", p.start("DELETE")),
+		cb.Delete().After("*").Register("database:trace_stop", p.stop),
+		cb.Raw().Before("*").Register("database:trace_start", p.start("RAW")),
+		cb.Raw().After("*").Register("database:trace_stop", p.stop),
+		cb.Row().Before("*").Register("database:trace_start", p.start("ROW")),
+		cb.Row().After("*").Register("database:trace_stop", p.stop),
+	}
+
+	return errors.Join(errs...)
+}
+
+// Returns a callback that starts the span of the operation
+// and replaces the context of the statement with the one that has the span
+func (p queryTracing) start(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		name := operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+
+		ctx, span := otel.Tracer(tracerName).Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemPostgreSQL,
+				semconv.DBOperationName(operation),
+			),
+		)
+
+		db.Statement.Context = ctx
+		db.InstanceSet(traceSpanKey, span)
+	}
+}
+
+// Ends the span of the statement with its SQL
+func (p queryTracing) stop(db *gorm.DB) {
+	v, ok := db.InstanceGet(traceSpanKey)
+	if !ok {
+		return
+	}
+	span := v.(trace.Span)
+
+	span.SetAttributes(semconv.DBQueryText(db.Statement.SQL.String()))
+
+	// not finding a record is not a failure of the db
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+
+	tracing.End(span, err)
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"gorm.io/gorm"
+)
+
+// Key of the running transaction in the context
+type txKey struct{}
+
+// Represents a runner of the transactions of the db
+type Transactor struct {
+	db *gorm.DB
+}
+
+// Creates a new transactor of the db passed
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// Runs fn in a transaction, a call within a running transaction joins it
+func (t *Transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	ctx, hooks := abstractions.WithCommitHooks(ctx)
+
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	if err != nil {
+		return err
+	}
+
+	hooks.Run()
+
+	return nil
+}
+
+// Returns the running transaction of the context or the db passed,
+// so the statements of a repository join the transaction of the caller
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+
+	return db.WithContext(ctx)
+}
@@ -0,0 +1,25 @@
+// Contains the composition of the event publishers
+package events
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Publishes every event to all of the publishers
+type Fanout []abstractions.EventPublisher
+
+// Publishes the event to every publisher, a failing one does not stop the rest
+func (f Fanout) Publish(ctx context.Context, collegeID uint, event string, data any) error {
+	var errs []error
+
+	for _, p := range f {
+		if err := p.Publish(ctx, collegeID, event, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,86 @@
+package feed
+
+import "sync"
+
+// Delivers the events to the subscribers of this instance
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// Represents a subscriber receiving the events that pass its filter
+type Subscription struct {
+	// the events, closed when the subscription or the broker is closed
+	C <-chan Event
+
+	c      chan Event
+	filter func(Event) bool
+	broker *Broker
+}
+
+// Creates a new broker
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribes to the events passing the filter,
+// up to buffer events are kept for a slow subscriber
+func (b *Broker) Subscribe(filter func(Event) bool, buffer int) *Subscription {
+	c := make(chan Event, buffer)
+	s := &Subscription{C: c, c: c, filter: filter, broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(c)
+		return s
+	}
+
+	b.subs[s] = struct{}{}
+
+	return s
+}
+
+// Sends the event to every subscriber it passes the filter of.
+// A subscriber with a full buffer misses the event
+func (b *Broker) Broadcast(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subs {
+		if !s.filter(e) {
+			continue
+		}
+
+		select {
+		case s.c <- e:
+		default:
+		}
+	}
+}
+
+// Closes every subscription, used on shutdown
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for s := range b.subs {
+		close(s.c)
+		delete(b.subs, s)
+	}
+}
+
+// Stops receiving the events
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	if _, ok := s.broker.subs[s]; ok {
+		close(s.c)
+		delete(s.broker.subs, s)
+	}
+}
@@ -0,0 +1,117 @@
+// Contains the live feed of the attendance events
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents an event of the feed
+type Event struct {
+	Type       string          `json:"type"`
+	CollegeID  uint            `json:"college_id"`
+	LessonID   *uint           `json:"lesson_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Represents a channel shared by the instances of the server
+type Bus interface {
+	// Sends the payload to every instance including this one
+	Notify(ctx context.Context, payload []byte) error
+}
+
+// Publishes the attendance events to the subscribers of all the instances
+type Feed struct {
+	logger *slog.Logger
+	broker *Broker
+	bus    Bus
+}
+
+// Creates a new feed, the events are broadcast
+// only within the instance if the bus is nil
+func NewFeed(logger *slog.Logger, broker *Broker, bus Bus) *Feed {
+	return &Feed{
+		logger: logger.With(slog.String("component", "feed.Feed")),
+		broker: broker,
+		bus:    bus,
+	}
+}
+
+// Publishes the attendance events, the others are skipped
+func (f *Feed) Publish(ctx context.Context, collegeID uint, event string, data any) error {
+	var lessonID *uint
+
+	switch event {
+	case models.EventAttendanceCreated, models.EventAttendanceUpdated, models.EventAttendanceDeleted:
+		switch a := data.(type) {
+		case *models.Attendance:
+			lessonID = a.LessonID
+		case models.Attendance:
+			lessonID = a.LessonID
+		}
+	case models.EventAbsenceMaterialized:
+		if a, ok := data.(models.AbsencesMaterialized); ok {
+			lessonID = &a.LessonID
+		}
+	default:
+		return nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("cannot marshal the %s event: %w", event, err)
+	}
+
+	e := Event{
+		Type:       event,
+		CollegeID:  collegeID,
+		LessonID:   lessonID,
+		OccurredAt: time.Now().UTC(),
+		Data:       raw,
+	}
+
+	// the bus of Postgres delivers on commit, the broker has to wait for it
+	if f.bus == nil {
+		abstractions.AfterCommit(ctx, func() { f.broker.Broadcast(e) })
+		return nil
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("cannot marshal the %s event: %w", event, err)
+	}
+
+	if err := f.bus.Notify(ctx, payload); err != nil {
+		return fmt.Errorf("cannot publish the %s event to the feed: %w", event, err)
+	}
+
+	return nil
+}
+
+// Broadcasts an event received from the bus to the subscribers of this instance
+func (f *Feed) Receive(payload []byte) {
+	var e Event
+	if err := json.Unmarshal(payload, &e); err != nil {
+		f.logger.Error("received an invalid event", slog.Any("err", err))
+		return
+	}
+
+	f.broker.Broadcast(e)
+}
+
+// Subscribes to the events of this instance passing the filter
+func (f *Feed) Subscribe(filter func(Event) bool, buffer int) *Subscription {
+	return f.broker.Subscribe(filter, buffer)
+}
+
+// Closes every subscription, so the streams end before the shutdown
+func (f *Feed) Close() {
+	f.broker.Close()
+}
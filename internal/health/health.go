@@ -0,0 +1,75 @@
+// Contains the readiness checks of the dependencies
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Represents a dependency checked before the instance takes the traffic
+type Check struct {
+	Name string
+	// Returns an error if the dependency is not usable,
+	// it must give up when the context is done
+	Run func(ctx context.Context) error
+}
+
+// Represents the result of a check
+type Result struct {
+	Name string
+	Err  error
+}
+
+// Tells whether the instance is ready to take the traffic
+type Probe struct {
+	checks  []Check
+	timeout time.Duration
+
+	// set once the graceful shutdown has begun
+	draining atomic.Bool
+}
+
+// Creates a new probe running every check within the timeout
+func NewProbe(timeout time.Duration, checks ...Check) *Probe {
+	return &Probe{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Marks the instance as shutting down, so it is not ready anymore
+func (p *Probe) Drain() {
+	p.draining.Store(true)
+}
+
+// Checks whether the instance is shutting down
+func (p *Probe) Draining() bool {
+	return p.draining.Load()
+}
+
+// Runs the checks at once and returns their results in the order they were added
+func (p *Probe) Check(ctx context.Context) []Result {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	results := make([]Result, len(p.checks))
+
+	var wg sync.WaitGroup
+	for i, c := range p.checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			results[i] = Result{Name: c.Name, Err: c.Run(ctx)}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
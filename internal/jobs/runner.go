@@ -0,0 +1,318 @@
+// Contains the runner of the background jobs queued in Postgres
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/tracing"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/cron"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/retry"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name of the instrumentation of the runner
+const tracerName = "github.com/cyberbrain-dev/na-meste-api/internal/jobs"
+
+// Handles a job of a kind, the job is retried if an error is returned.
+// The context is cancelled when the lease passes or the draining times out
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Represents the settings of the runner
+type Options struct {
+	// How many jobs are handled at once
+	Workers int
+	// How often the queue is polled
+	PollInterval time.Duration
+	// How long a job may run before another worker takes it over
+	Lease time.Duration
+	// After how many failed attempts a job goes to the dead letters
+	MaxAttempts int
+	// Bounds of the exponential backoff between the attempts
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// How long the running jobs are waited for on shutdown
+	DrainTimeout time.Duration
+	// Source of the current time, time.Now if nil
+	Clock func() time.Time
+}
+
+// Represents a job enqueued by a cron expression
+type schedule struct {
+	kind string
+	cron *cron.Schedule
+	next time.Time
+}
+
+// Runs the registered handlers on the jobs claimed from the queue
+type Runner struct {
+	logger    *slog.Logger
+	repo      abstractions.JobsRepo
+	opts      Options
+	handlers  map[string]Handler
+	schedules []*schedule
+}
+
+// Creates a new runner
+func NewRunner(logger *slog.Logger, repo abstractions.JobsRepo, opts Options) *Runner {
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+
+	return &Runner{
+		logger:   logger.With(slog.String("job", "jobs.Runner")),
+		repo:     repo,
+		opts:     opts,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Registers the handler of the jobs of the kind,
+// it must be called before the runner is started
+func (r *Runner) Handle(kind string, h Handler) {
+	r.handlers[kind] = h
+}
+
+// Enqueues a job of the kind every time the cron expression fires.
+// Every instance schedules it, but a moment is enqueued only once
+func (r *Runner) Schedule(spec string, kind string) error {
+	s, err := cron.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule of %s: %w", kind, err)
+	}
+
+	r.schedules = append(r.schedules, &schedule{
+		kind: kind,
+		cron: s,
+		next: s.Next(r.opts.Clock()),
+	})
+
+	return nil
+}
+
+// Enqueues a job of the kind to run as soon as possible
+func (r *Runner) Enqueue(ctx context.Context, kind string, payload any) error {
+	return r.EnqueueAt(ctx, kind, payload, r.opts.Clock())
+}
+
+// Enqueues a job of the kind to run at the moment
+func (r *Runner) EnqueueAt(ctx context.Context, kind string, payload any, runAt time.Time) error {
+	return r.enqueue(ctx, kind, nil, payload, runAt)
+}
+
+// Enqueues a job of the kind only once for the key
+func (r *Runner) EnqueueUnique(ctx context.Context, kind string, key string, payload any) error {
+	return r.enqueue(ctx, kind, &key, payload, r.opts.Clock())
+}
+
+// Marshals the payload and writes the job to the queue.
+// With the context of a transaction the job is written in it,
+// so the job is queued only if the write enqueueing it is committed
+func (r *Runner) enqueue(ctx context.Context, kind string, key *string, payload any, runAt time.Time) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("cannot marshal the payload of %s: %w", kind, err)
+	}
+
+	_, err = r.repo.Enqueue(ctx, &models.Job{
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: r.opts.MaxAttempts,
+		RunAt:       runAt,
+		UniqueKey:   key,
+	})
+
+	return err
+}
+
+// Runs the workers until the context is cancelled,
+// then waits for the running jobs to finish and returns
+func (r *Runner) Run(ctx context.Context) {
+	kinds := make([]string, 0, len(r.handlers))
+	for kind := range r.handlers {
+		kinds = append(kinds, kind)
+	}
+
+	// the jobs are not cancelled with ctx, so they can finish while draining
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	// the free workers
+	slots := make(chan struct{}, r.opts.Workers)
+	for i := 0; i < r.opts.Workers; i++ {
+		slots <- struct{}{}
+	}
+
+	var wg sync.WaitGroup
+
+	ticker := time.NewTicker(r.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.enqueueScheduled(ctx, r.opts.Clock())
+
+		free := len(slots)
+		if free > 0 {
+			jobs, err := r.repo.Claim(ctx, kinds, r.opts.Clock(), free, r.opts.Lease)
+			if err != nil {
+				r.logger.ErrorContext(ctx, "failed to claim the jobs", slog.Any("err", err))
+			}
+
+			for _, job := range jobs {
+				<-slots
+				wg.Add(1)
+
+				go func() {
+					defer func() {
+						slots <- struct{}{}
+						wg.Done()
+					}()
+
+					r.run(jobsCtx, job)
+				}()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			r.drain(&wg, cancelJobs)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Waits for the running jobs and cancels them if the draining times out
+func (r *Runner) drain(wg *sync.WaitGroup, cancelJobs context.CancelFunc) {
+	r.logger.Info("draining the running jobs...")
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(r.opts.DrainTimeout):
+		r.logger.Warn("draining timed out, cancelling the running jobs")
+
+		// the cancelled jobs are taken over by another instance after the lease
+		cancelJobs()
+		<-done
+	}
+
+	r.logger.Info("running jobs have been drained")
+}
+
+// Runs a job and records its result
+func (r *Runner) run(ctx context.Context, job *models.Job) {
+	logger := r.logger.With(
+		slog.Any("job_id", job.ID),
+		slog.String("kind", job.Kind),
+		slog.Int("attempt", job.Attempts),
+	)
+
+	// every job has its own trace, so its logs and queries are told apart
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "job "+job.Kind,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.Int64("job.id", int64(job.ID)),
+			attribute.Int("job.attempt", job.Attempts),
+		),
+	)
+
+	ctx, cancel := context.WithTimeout(ctx, r.opts.Lease)
+	defer cancel()
+
+	err := r.call(ctx, job)
+	defer func() { tracing.End(span, err) }()
+	if err == nil {
+		if err := r.repo.Complete(ctx, job.ID); err != nil {
+			logger.ErrorContext(ctx, "failed to complete the job", slog.Any("err", err))
+		}
+
+		return
+	}
+
+	logger.WarnContext(ctx, "job has failed", slog.Any("err", err))
+
+	maxAttempts := job.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = r.opts.MaxAttempts
+	}
+
+	// the job has run out of attempts
+	if job.Attempts >= maxAttempts {
+		if err := r.repo.Kill(ctx, job.ID, err.Error()); err != nil {
+			logger.ErrorContext(ctx, "failed to kill the job", slog.Any("err", err))
+			return
+		}
+
+		logger.ErrorContext(ctx, "job has been moved to the dead letters")
+
+		return
+	}
+
+	runAt := r.opts.Clock().Add(retry.Backoff(job.Attempts, r.opts.BaseBackoff, r.opts.MaxBackoff))
+	if err := r.repo.Retry(ctx, job.ID, runAt, err.Error()); err != nil {
+		logger.ErrorContext(ctx, "failed to retry the job", slog.Any("err", err))
+	}
+}
+
+// Calls the handler of the job turning a panic into an error
+func (r *Runner) call(ctx context.Context, job *models.Job) (err error) {
+	h, ok := r.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler of %s", job.Kind)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("handler panicked: %v", p)
+		}
+	}()
+
+	return h(ctx, job.Payload)
+}
+
+// Enqueues the scheduled jobs that are due
+func (r *Runner) enqueueScheduled(ctx context.Context, now time.Time) {
+	for _, s := range r.schedules {
+		for !s.next.IsZero() && !s.next.After(now) {
+			// the key makes the other instances skip the same moment
+			key := fmt.Sprintf("cron:%s:%d", s.kind, s.next.Unix())
+
+			_, err := r.repo.Enqueue(ctx, &models.Job{
+				Kind:        s.kind,
+				Payload:     json.RawMessage("{}"),
+				MaxAttempts: r.opts.MaxAttempts,
+				RunAt:       s.next,
+				UniqueKey:   &key,
+			})
+			if err != nil {
+				r.logger.ErrorContext(
+					ctx,
+					"failed to enqueue the scheduled job",
+					slog.String("kind", s.kind),
+					slog.Any("err", err),
+				)
+
+				// trying again on the next poll
+				return
+			}
+
+			// the missed moments are skipped
+			s.next = s.cron.Next(now)
+		}
+	}
+}
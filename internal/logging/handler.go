@@ -0,0 +1,63 @@
+// Contains the logging tools shared by the API and its workers
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	"github.com/cyberbrain-dev/na-meste-api/internal/tracing"
+)
+
+// Represents a handler adding the trace and the caller
+// of the context passed to the logger to every record
+type Handler struct {
+	next slog.Handler
+}
+
+// Wraps the handler, so the records are written with the trace and the caller.
+// A handler wrapped already is returned as it is
+func NewHandler(next slog.Handler) slog.Handler {
+	if h, ok := next.(*Handler); ok {
+		return h
+	}
+
+	return &Handler{next: next}
+}
+
+// Reports whether the wrapped handler writes the records of the level
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Adds the attributes of the context to the record and passes it on
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	cloned := false
+
+	for _, attr := range []slog.Attr{tracing.LogAttr(ctx), principal.LogAttr(ctx)} {
+		// the attributes are empty if the context carries nothing
+		if attr.Key == "" {
+			continue
+		}
+
+		// the record may be shared with other handlers
+		if !cloned {
+			r = r.Clone()
+			cloned = true
+		}
+
+		r.AddAttrs(attr)
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+// Returns the handler with the attributes added to every record
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{next: h.next.WithAttrs(attrs)}
+}
+
+// Returns the handler putting the attributes of the records into the group
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,113 @@
+// Contains the Prometheus metrics of the API
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace of the metrics
+const namespace = "na_meste"
+
+// Route label of the requests matching no route,
+// so the unknown paths do not blow up the series
+const RouteUnmatched = "unmatched"
+
+// Methods of the logins
+const (
+	LoginPassword = "password"
+	LoginLDAP     = "ldap"
+	LoginOIDC     = "oidc"
+)
+
+// Represents the metrics of the instance kept in their own registry
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests         *prometheus.HistogramVec
+	attendances      *prometheus.CounterVec
+	failedLogins     *prometheus.CounterVec
+	rejectedCheckIns *prometheus.CounterVec
+}
+
+// Creates the metrics with the collectors of the runtime and the process,
+// the extra collectors (e.g. of the storage) are registered as well
+func New(extra ...prometheus.Collector) (*Metrics, error) {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the HTTP requests by the route pattern.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		attendances: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "attendances_created_total",
+			Help:      "Attendances registered by the scanners, the device is the user ID of the scanner.",
+		}, []string{"college_id", "device"}),
+		failedLogins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "failed_logins_total",
+			Help:      "Logins refused by the API.",
+		}, []string{"method", "reason"}),
+		rejectedCheckIns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rejected_check_ins_total",
+			Help:      "Check-ins of the scanners refused by the API by the problem code, the failures of the server excluded.",
+		}, []string{"reason"}),
+	}
+
+	all := append([]prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.attendances,
+		m.failedLogins,
+		m.rejectedCheckIns,
+	}, extra...)
+
+	for _, c := range all {
+		if err := m.registry.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Returns the handler exposing the metrics in the Prometheus format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Records the duration of a handled request
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// Counts an attendance registered by the scanner in the college.
+// The scanners are accounts of the colleges, so the devices are as many as them
+func (m *Metrics) AttendanceCreated(collegeID uint, scannerID uint) {
+	m.attendances.WithLabelValues(
+		strconv.FormatUint(uint64(collegeID), 10),
+		strconv.FormatUint(uint64(scannerID), 10),
+	).Inc()
+}
+
+// Counts a login refused for the reason
+func (m *Metrics) LoginFailed(method, reason string) {
+	m.failedLogins.WithLabelValues(method, reason).Inc()
+}
+
+// Counts a check-in refused with the code of the problem
+func (m *Metrics) CheckInRejected(reason string) {
+	m.rejectedCheckIns.WithLabelValues(reason).Inc()
+}
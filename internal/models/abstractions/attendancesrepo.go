@@ -1,6 +1,7 @@
 package abstractions
 
 import (
+	"context"
 	"time"
 
 	"github.com/cyberbrain-dev/na-meste-api/internal/models"
@@ -8,14 +9,48 @@ import (
 
 type AttendancesRepo interface {
 	// Adds a new record to the db
-	Create(a *models.Attendance) error
+	Create(ctx context.Context, a *models.Attendance) error
 
 	// Returns an attendance by an ID
-	Get(id uint) (*models.Attendance, error)
+	Get(ctx context.Context, id uint) (*models.Attendance, error)
 
 	// Returns the attendances of the user and date span
-	GetByStudentAndDatespan(id uint, from time.Time, to time.Time) ([]*models.Attendance, error)
+	GetByStudentAndDatespan(ctx context.Context, id uint, from time.Time, to time.Time) ([]*models.Attendance, error)
+
+	// Returns the attendances of the lesson
+	GetByLesson(ctx context.Context, lessonID uint) ([]*models.Attendance, error)
+
+	// Returns the latest attendances of the student matched with lessons
+	GetLatestByStudent(ctx context.Context, id uint, limit int) ([]*models.Attendance, error)
+
+	// Writes absent records for the lesson's students without an attendance
+	// and returns how many have been written. Re-running it is safe
+	MaterializeAbsences(ctx context.Context, lessonID uint) (int, error)
+
+	// Returns the attendance of the student at the lesson
+	GetByLessonAndStudent(ctx context.Context, lessonID uint, studentID uint) (*models.Attendance, error)
+
+	// Creates the attendance marked by a teacher and records the change
+	Mark(ctx context.Context, a *models.Attendance, c *models.AttendanceChange) error
+
+	// Changes the status of the attendance and records the change
+	ChangeStatus(ctx context.Context, id uint, status string, minutesLate int, c *models.AttendanceChange) error
+
+	// Deletes the attendance unmarked by a teacher and records the change
+	Unmark(ctx context.Context, id uint, c *models.AttendanceChange) error
+
+	// Returns the history of the manual changes of the attendance
+	GetHistory(ctx context.Context, id uint) ([]*models.AttendanceChange, error)
 
 	// Deletes an attendance by an ID
-	Delete(id uint) (uint, error)
+	Delete(ctx context.Context, id uint) (uint, error)
+
+	// Returns the soft-deleted attendances of the college
+	GetDeleted(ctx context.Context, collegeID uint) ([]*models.Attendance, error)
+
+	// Restores the soft-deleted record with the ID passed
+	Restore(ctx context.Context, id uint) error
+
+	// Permanently deletes the attendances soft-deleted before the moment passed
+	Purge(ctx context.Context, before time.Time) (int, error)
 }
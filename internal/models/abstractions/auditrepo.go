@@ -0,0 +1,15 @@
+package abstractions
+
+import (
+	"context"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+)
+
+// Represents an abstract append-only audit log
+type AuditRepo interface {
+	// Appends a record to the log
+	Append(ctx context.Context, a *models.AuditRecord) error
+
+	// Returns the records matching the filter, the newest first
+	List(ctx context.Context, f models.AuditFilter) ([]*models.AuditRecord, error)
+}
@@ -1,15 +1,40 @@
 package abstractions
 
-import "github.com/cyberbrain-dev/na-meste-api/internal/models"
+import (
+	"context"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/geo"
+)
 
 // Represents an abstract colleges repository
 type CollegesRepo interface {
 	// Adds a college to the db
-	Create(c *models.College) error
+	Create(ctx context.Context, c *models.College) error
 
 	// Returns college by its name
-	Get(name string) (*models.College, error)
+	Get(ctx context.Context, name string) (*models.College, error)
+
+	// Returns college by its ID
+	GetByID(ctx context.Context, id uint) (*models.College, error)
+
+	// Sets the geofence of the college, nil fence removes it
+	SetGeofence(ctx context.Context, id uint, fence *geo.Fence, mode string) error
+
+	// Sets the grace periods used for classifying the arrivals
+	SetGracePeriods(ctx context.Context, id uint, lateGrace time.Duration, earlyCheckIn time.Duration) error
 
 	// Deletes the college and returns its ID
-	Delete(id uint) (uint, error)
+	Delete(ctx context.Context, id uint) (uint, error)
+
+	// Returns the college if it is soft-deleted
+	GetDeleted(ctx context.Context, collegeID uint) ([]*models.College, error)
+
+	// Restores the soft-deleted record with the ID passed
+	Restore(ctx context.Context, id uint) error
+
+	// Permanently deletes the colleges soft-deleted before the moment passed.
+	// The ones still referred to by the attendances, even deleted, are kept
+	Purge(ctx context.Context, before time.Time) (int, error)
 }
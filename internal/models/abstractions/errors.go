@@ -0,0 +1,23 @@
+package abstractions
+
+import "errors"
+
+// Errors returned by the repositories, the errors of the storage are wrapped by them
+var (
+	// The record does not exist.
+	// The lookups of a single record return it instead of a nil record
+	ErrNotFound = errors.New("record does not exist")
+
+	// The record clashes with an existing one on a unique key
+	ErrDuplicate = errors.New("record already exists")
+
+	// The record refers to a record that does not exist
+	// or is still referred to by the other records
+	ErrForeignKey = errors.New("record violates a foreign key")
+
+	// The record breaks a check of the storage
+	ErrCheckViolation = errors.New("record violates a check constraint")
+)
+
+// Returned by the repositories when restoring a record that is not soft-deleted
+var ErrNotDeleted = errors.New("record does not exist or is not deleted")
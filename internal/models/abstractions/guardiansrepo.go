@@ -0,0 +1,24 @@
+package abstractions
+
+import (
+	"context"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+)
+
+// Represents an abstract repository of the links between guardians and students
+type GuardiansRepo interface {
+	// Links the student to the guardian, linking them again does nothing
+	Link(ctx context.Context, l *models.GuardianLink) error
+
+	// Removes the link and returns false if there has been none
+	Unlink(ctx context.Context, guardianID uint, studentID uint) (bool, error)
+
+	// Checks whether the student is linked to the guardian
+	IsLinked(ctx context.Context, guardianID uint, studentID uint) (bool, error)
+
+	// Returns the students linked to the guardian
+	GetStudents(ctx context.Context, guardianID uint) ([]*models.User, error)
+
+	// Returns the guardians linked to the student
+	GetGuardians(ctx context.Context, studentID uint) ([]*models.User, error)
+}
@@ -0,0 +1,50 @@
+package abstractions
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+)
+
+// Represents an abstract repository of the background jobs
+type JobsRepo interface {
+	// Adds a job to the queue, a job with a unique key
+	// that has already been enqueued is skipped and false is returned
+	Enqueue(ctx context.Context, j *models.Job) (bool, error)
+
+	// Returns a job by its ID
+	Get(ctx context.Context, id uint) (*models.Job, error)
+
+	// Returns the latest jobs with the status
+	GetByStatus(ctx context.Context, status string, limit int) ([]*models.Job, error)
+
+	// Claims up to limit due jobs of the kinds and marks them running,
+	// they are claimed again only if the lease passes before they finish
+	Claim(ctx context.Context, kinds []string, now time.Time, limit int, lease time.Duration) ([]*models.Job, error)
+
+	// Marks the job as done
+	Complete(ctx context.Context, id uint) error
+
+	// Records a failed attempt, the job is run again at runAt
+	Retry(ctx context.Context, id uint, runAt time.Time, errMsg string) error
+
+	// Moves the job to the dead letters
+	Kill(ctx context.Context, id uint, errMsg string) error
+
+	// Puts a dead job back to the queue with fresh attempts
+	Requeue(ctx context.Context, id uint, runAt time.Time) error
+
+	// Permanently deletes the jobs done before the moment
+	// and returns how many have been deleted
+	PurgeDone(ctx context.Context, before time.Time) (int, error)
+}
+
+// Represents a queue the work is handed to
+type JobQueue interface {
+	// Enqueues a job of the kind with the payload marshalled to json
+	Enqueue(ctx context.Context, kind string, payload any) error
+
+	// Enqueues a job like Enqueue, but only once for the key
+	EnqueueUnique(ctx context.Context, kind string, key string, payload any) error
+}
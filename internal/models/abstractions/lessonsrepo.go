@@ -0,0 +1,28 @@
+package abstractions
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+)
+
+// Represents an abstract lessons repository
+type LessonsRepo interface {
+	// Adds a lesson with its students to the db
+	Create(ctx context.Context, l *models.Lesson) error
+
+	// Returns a lesson by its ID
+	Get(ctx context.Context, id uint) (*models.Lesson, error)
+
+	// Returns the student's lesson that runs at the moment passed,
+	// the lesson is matched the early duration before its start
+	GetByStudentAt(ctx context.Context, studentID uint, at time.Time, early time.Duration) (*models.Lesson, error)
+
+	// Returns the lessons that have ended before the moment passed
+	// and have no absences materialized yet
+	GetEndedUnmaterialized(ctx context.Context, before time.Time) ([]*models.Lesson, error)
+
+	// Deletes a lesson by its ID
+	Delete(ctx context.Context, id uint) (uint, error)
+}
@@ -0,0 +1,27 @@
+package abstractions
+
+import (
+	"context"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+)
+
+// Represents an abstract repository of the notification preferences and rules
+type NotificationsRepo interface {
+	// Returns the preference of the user or nil if they have not set one
+	GetPreference(ctx context.Context, userID uint) (*models.NotificationPreference, error)
+
+	// Creates or replaces the preference of the user
+	SetPreference(ctx context.Context, p *models.NotificationPreference) error
+
+	// Adds a rule to the db
+	CreateRule(ctx context.Context, rule *models.NotificationRule) error
+
+	// Returns a rule by its ID
+	GetRule(ctx context.Context, id uint) (*models.NotificationRule, error)
+
+	// Returns the rules of the college
+	GetRules(ctx context.Context, collegeID uint) ([]*models.NotificationRule, error)
+
+	// Deletes a rule by its ID
+	DeleteRule(ctx context.Context, id uint) error
+}
@@ -0,0 +1,57 @@
+package abstractions
+
+import (
+	"context"
+	"sync"
+)
+
+// Represents an abstract runner of the transactions of the storage
+type Transactor interface {
+	// Runs fn in a transaction. The repositories called with the context passed to fn
+	// write in the transaction, which is committed if fn returns nil and rolled back otherwise.
+	// The side effects deferred by AfterCommit run once the transaction commits.
+	// A call with the context of a running transaction joins it
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Key of the side effects deferred until the running transaction commits
+type commitHooksKey struct{}
+
+// Represents the side effects deferred until a transaction commits
+type CommitHooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// Returns a copy of the context collecting the side effects deferred by AfterCommit.
+// The transactors call it when a transaction starts and run the hooks once it commits
+func WithCommitHooks(ctx context.Context) (context.Context, *CommitHooks) {
+	hooks := &CommitHooks{}
+	return context.WithValue(ctx, commitHooksKey{}, hooks), hooks
+}
+
+// Runs the side effects in the order they were deferred
+func (h *CommitHooks) Run() {
+	h.mu.Lock()
+	fns := h.fns
+	h.fns = nil
+	h.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// Runs fn once the transaction of the context commits or at once outside a transaction,
+// so the rolled back writes are never seen outside the storage
+func AfterCommit(ctx context.Context, fn func()) {
+	hooks, ok := ctx.Value(commitHooksKey{}).(*CommitHooks)
+	if !ok {
+		fn()
+		return
+	}
+
+	hooks.mu.Lock()
+	hooks.fns = append(hooks.fns, fn)
+	hooks.mu.Unlock()
+}
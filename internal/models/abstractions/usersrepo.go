@@ -1,18 +1,42 @@
 package abstractions
 
-import "github.com/cyberbrain-dev/na-meste-api/internal/models"
+import (
+	"context"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+)
 
 // Represents an abstract users repository
 type UsersRepo interface {
 	// Adds a new user record to the database
-	Create(u *models.User) error
+	Create(ctx context.Context, u *models.User) error
+
+	// Returns a user by their email
+	Get(ctx context.Context, email string) (*models.User, error)
 
-	// Returns a user with the ID passed if the one exists
-	Get(id string) (*models.User, error)
+	// Returns a user by their ID
+	GetByID(ctx context.Context, id uint) (*models.User, error)
 
 	// Updates user with the ID passed
-	Update(id uint, username *string, email *string) (uint, error)
+	Update(ctx context.Context, id uint, username *string, email *string) (uint, error)
+
+	// Sets the role of the user with the ID passed
+	SetRole(ctx context.Context, id uint, role string) error
+
+	// Sets the curator of the student with the ID passed, nil removes them
+	SetCurator(ctx context.Context, id uint, curatorID *uint) error
 
 	// Deletes user with the ID passed
-	Delete(id uint) (uint, error)
+	Delete(ctx context.Context, id uint) (uint, error)
+
+	// Returns the soft-deleted users of the college
+	GetDeleted(ctx context.Context, collegeID uint) ([]*models.User, error)
+
+	// Restores the soft-deleted record with the ID passed
+	Restore(ctx context.Context, id uint) error
+
+	// Permanently deletes the users soft-deleted before the moment passed.
+	// The ones still referred to by the attendances, even deleted, are kept
+	Purge(ctx context.Context, before time.Time) (int, error)
 }
@@ -0,0 +1,47 @@
+package abstractions
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+)
+
+// Represents an abstract repository of webhook subscriptions and their outbox
+type WebhooksRepo interface {
+	// Adds a subscription to the db
+	CreateSubscription(ctx context.Context, s *models.WebhookSubscription) error
+
+	// Returns a subscription by its ID
+	GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error)
+
+	// Returns the subscriptions of the college
+	GetSubscriptions(ctx context.Context, collegeID uint) ([]*models.WebhookSubscription, error)
+
+	// Deletes a subscription with its deliveries
+	DeleteSubscription(ctx context.Context, id uint) error
+
+	// Writes a pending delivery for every active subscription
+	// of the college to the event and returns how many have been written
+	Enqueue(ctx context.Context, collegeID uint, event string, payload []byte) (int, error)
+
+	// Claims up to limit pending deliveries that are due,
+	// they are not claimed again until the lease passes
+	ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+
+	// Marks the delivery as delivered
+	MarkDelivered(ctx context.Context, id uint, statusCode int) error
+
+	// Records a failed attempt, the delivery is retried at next
+	// or is failed for good if next is nil
+	MarkAttemptFailed(ctx context.Context, id uint, statusCode int, errMsg string, next *time.Time) error
+
+	// Returns the latest deliveries of the subscription
+	GetDeliveries(ctx context.Context, subscriptionID uint, limit int) ([]*models.WebhookDelivery, error)
+}
+
+// Represents a publisher of the domain events
+type EventPublisher interface {
+	// Publishes the event of the college with the data passed
+	Publish(ctx context.Context, collegeID uint, event string, data any) error
+}
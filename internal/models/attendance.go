@@ -1,10 +1,67 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/pkg/geo"
+)
+
+// Statuses of an attendance against the lesson schedule
+const (
+	AttendanceStatusOnTime        = "on_time"
+	AttendanceStatusLate          = "late"
+	AttendanceStatusOutsideLesson = "outside_lesson"
+	AttendanceStatusAbsent        = "absent"
+)
 
 type Attendance struct {
 	ID        uint
 	UserID    uint
 	CollegeID uint
 	Date      time.Time
+
+	Latitude  *float64
+	Longitude *float64
+	Accuracy  *float64
+
+	GeofenceVerdict geo.Verdict
+
+	LessonID    *uint
+	Status      string
+	MinutesLate int
+
+	DeletedAt *time.Time
+}
+
+// Represents the totals of the attendances by their statuses
+type AttendanceSummary struct {
+	Total         int `json:"total"`
+	OnTime        int `json:"on_time"`
+	Late          int `json:"late"`
+	OutsideLesson int `json:"outside_lesson"`
+	Absent        int `json:"absent"`
+	MinutesLate   int `json:"minutes_late"`
+}
+
+// Counts the attendances by their statuses
+func Summarize(atts []*Attendance) AttendanceSummary {
+	var s AttendanceSummary
+
+	for _, a := range atts {
+		s.Total++
+
+		switch a.Status {
+		case AttendanceStatusOnTime:
+			s.OnTime++
+		case AttendanceStatusLate:
+			s.Late++
+			s.MinutesLate += a.MinutesLate
+		case AttendanceStatusOutsideLesson:
+			s.OutsideLesson++
+		case AttendanceStatusAbsent:
+			s.Absent++
+		}
+	}
+
+	return s
 }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Actions a teacher can do with an attendance
+const (
+	AttendanceActionMark         = "mark"
+	AttendanceActionUnmark       = "unmark"
+	AttendanceActionChangeStatus = "change_status"
+)
+
+type AttendanceChange struct {
+	ID           uint
+	AttendanceID uint
+	LessonID     uint
+	StudentID    uint
+	ChangedBy    uint
+	ChangedAt    time.Time
+	Action       string
+	OldStatus    string
+	NewStatus    string
+	Reason       string
+}
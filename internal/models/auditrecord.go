@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditRecord struct {
+	ID         uint
+	OccurredAt time.Time
+
+	ActorID   *uint
+	ActorRole string
+
+	Action   string
+	Entity   string
+	EntityID string
+
+	Before json.RawMessage
+	After  json.RawMessage
+
+	RequestID  string
+	IP         string
+	StatusCode int
+}
+
+// Represents the filters of the audit log, zero values match everything
+type AuditFilter struct {
+	ActorID  *uint
+	Action   string
+	Entity   string
+	EntityID string
+	From     time.Time
+	To       time.Time
+
+	Limit  int
+	Offset int
+}
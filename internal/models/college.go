@@ -1,7 +1,27 @@
 // Contains all the domain models for the application
 package models
 
+import (
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/pkg/geo"
+)
+
+// What happens to the attendances outside the geofence
+const (
+	GeofenceModeFlag   = "flag"
+	GeofenceModeReject = "reject"
+)
+
 type College struct {
 	ID   uint
 	Name string
+
+	Geofence     *geo.Fence
+	GeofenceMode string
+
+	LateGrace    time.Duration
+	EarlyCheckIn time.Duration
+
+	DeletedAt *time.Time
 }
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+type GuardianLink struct {
+	GuardianID uint
+	StudentID  uint
+	CreatedAt  time.Time
+}
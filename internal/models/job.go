@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Statuses of a background job
+const (
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusDone    = "done"
+	// the job has run out of attempts and waits for an admin
+	JobStatusDead = "dead"
+)
+
+type Job struct {
+	ID          uint
+	Kind        string
+	Payload     json.RawMessage
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LockedUntil *time.Time
+	LastError   string
+	// a job with the same key is enqueued only once
+	UniqueKey  *string
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
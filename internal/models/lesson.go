@@ -0,0 +1,34 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+type Lesson struct {
+	ID        uint
+	CollegeID uint
+	TeacherID uint
+	Title     string
+	StartsAt  time.Time
+	EndsAt    time.Time
+
+	StudentIDs []uint
+
+	AbsencesMaterializedAt *time.Time
+}
+
+// Classifies an arrival at the lesson.
+// Arrivals within the grace period after the start are on time
+func (l *Lesson) Classify(at time.Time, grace time.Duration) (status string, minutesLate int) {
+	if l == nil || at.After(l.EndsAt) {
+		return AttendanceStatusOutsideLesson, 0
+	}
+
+	late := at.Sub(l.StartsAt)
+	if late <= grace {
+		return AttendanceStatusOnTime, 0
+	}
+
+	return AttendanceStatusLate, int(math.Ceil(late.Minutes()))
+}
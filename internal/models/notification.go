@@ -0,0 +1,47 @@
+package models
+
+// Channels the notifications are sent through
+const (
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+)
+
+// Languages of the notifications
+const (
+	LanguageRussian = "ru"
+	LanguageEnglish = "en"
+)
+
+// Kinds of the notification rules
+const (
+	// the student has missed Threshold lessons in a row
+	RuleAbsencesInRow = "absences_in_row"
+	// the attendance rate of the student this month is below Threshold percent
+	RuleRateBelow = "rate_below"
+)
+
+// Represents the channels a user wants to be notified through
+type NotificationPreference struct {
+	UserID         uint
+	Email          bool
+	Telegram       bool
+	TelegramChatID string
+	Language       string
+}
+
+// Returns the preference of a user who has not set one
+func DefaultNotificationPreference(userID uint) *NotificationPreference {
+	return &NotificationPreference{
+		UserID:   userID,
+		Email:    true,
+		Language: LanguageRussian,
+	}
+}
+
+// Represents a rule of a college when the curators and guardians are notified
+type NotificationRule struct {
+	ID        uint
+	CollegeID uint
+	Kind      string
+	Threshold int
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type User struct {
 	ID           uint
 	Username     string
@@ -8,4 +10,7 @@ type User struct {
 	Role         string
 
 	CollegeID uint
+	CuratorID *uint
+
+	DeletedAt *time.Time
 }
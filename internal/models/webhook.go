@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Events sent to the webhooks
+const (
+	EventAttendanceCreated   = "attendance.created"
+	EventAttendanceUpdated   = "attendance.updated"
+	EventAttendanceDeleted   = "attendance.deleted"
+	EventAbsenceMaterialized = "absence.materialized"
+)
+
+// Statuses of a webhook delivery
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Represents the data of the absence.materialized event
+type AbsencesMaterialized struct {
+	LessonID uint `json:"lesson_id"`
+	Absences int  `json:"absences"`
+}
+
+type WebhookSubscription struct {
+	ID        uint
+	CollegeID uint
+	URL       string
+	Secret    string
+	Events    []string
+	Active    bool
+	CreatedAt time.Time
+}
+
+type WebhookDelivery struct {
+	ID             uint
+	SubscriptionID uint
+	Event          string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastAttemptAt  *time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+
+	// filled when the delivery is claimed for sending
+	URL    string
+	Secret string
+}
@@ -0,0 +1,291 @@
+// Contains the notifications of the curators and guardians about absences
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/notify"
+)
+
+// Kinds of the jobs of the notifications
+const (
+	// checks the rules against the absences of an ended lesson
+	JobKindEvaluate = "notifications.evaluate"
+	// sends a rendered message through a channel
+	JobKindSend = "notifications.send"
+)
+
+// Represents the payload of the evaluation job
+type evaluation struct {
+	LessonID uint `json:"lesson_id"`
+}
+
+// Represents the payload of the sending job
+type delivery struct {
+	Channel string `json:"channel"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Checks the rules of the colleges after the lessons and notifies
+// the curators and guardians of the absent students
+type Notifier struct {
+	logger      *slog.Logger
+	repo        abstractions.NotificationsRepo
+	users       abstractions.UsersRepo
+	guardians   abstractions.GuardiansRepo
+	lessons     abstractions.LessonsRepo
+	attendances abstractions.AttendancesRepo
+	queue       abstractions.JobQueue
+	senders     map[string]notify.Sender
+}
+
+// Creates a new notifier sending through the channels passed
+func NewNotifier(
+	logger *slog.Logger,
+	repo abstractions.NotificationsRepo,
+	users abstractions.UsersRepo,
+	guardians abstractions.GuardiansRepo,
+	lessons abstractions.LessonsRepo,
+	attendances abstractions.AttendancesRepo,
+	queue abstractions.JobQueue,
+	senders map[string]notify.Sender,
+) *Notifier {
+	return &Notifier{
+		logger:      logger.With(slog.String("job", "notifications.Notifier")),
+		repo:        repo,
+		users:       users,
+		guardians:   guardians,
+		lessons:     lessons,
+		attendances: attendances,
+		queue:       queue,
+		senders:     senders,
+	}
+}
+
+// Queues the evaluation of the rules once the absences of a lesson are written
+func (n *Notifier) Publish(ctx context.Context, collegeID uint, event string, data any) error {
+	if event != models.EventAbsenceMaterialized {
+		return nil
+	}
+
+	absences, ok := data.(models.AbsencesMaterialized)
+	if !ok {
+		return fmt.Errorf("unexpected data of the %s event", event)
+	}
+
+	return n.queue.Enqueue(ctx, JobKindEvaluate, evaluation{LessonID: absences.LessonID})
+}
+
+// Handles the evaluation job: checks the rules for every absent student of the lesson
+func (n *Notifier) HandleEvaluate(ctx context.Context, payload json.RawMessage) error {
+	var job evaluation
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	lesson, err := n.lessons.Get(ctx, job.LessonID)
+	// the lesson has been deleted meanwhile
+	if errors.Is(err, abstractions.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rules, err := n.repo.GetRules(ctx, lesson.CollegeID)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	atts, err := n.attendances.GetByLesson(ctx, lesson.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range atts {
+		if a.Status != models.AttendanceStatusAbsent {
+			continue
+		}
+
+		for _, rule := range rules {
+			if err := n.evaluate(ctx, lesson, a.UserID, rule); err != nil {
+				return fmt.Errorf("rule №%d, student №%d: %w", rule.ID, a.UserID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Handles the sending job
+func (n *Notifier) HandleSend(ctx context.Context, payload json.RawMessage) error {
+	var job delivery
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	sender, ok := n.senders[job.Channel]
+	if !ok {
+		return fmt.Errorf("channel %s is not configured", job.Channel)
+	}
+
+	return sender.Send(ctx, notify.Message{
+		To:      job.To,
+		Subject: job.Subject,
+		Body:    job.Body,
+	})
+}
+
+// Checks the rule for the student absent at the lesson
+// and queues the messages if it is broken
+func (n *Notifier) evaluate(ctx context.Context, lesson *models.Lesson, studentID uint, rule *models.NotificationRule) error {
+	data := messageData{
+		Lesson:    lesson.Title,
+		Date:      lesson.StartsAt.Format("02.01.2006 15:04"),
+		Threshold: rule.Threshold,
+	}
+
+	// the key makes the same breach notified only once
+	var key string
+
+	switch rule.Kind {
+	case models.RuleAbsencesInRow:
+		latest, err := n.attendances.GetLatestByStudent(ctx, studentID, rule.Threshold+1)
+		if err != nil {
+			return err
+		}
+
+		streak := 0
+		for _, a := range latest {
+			if a.Status != models.AttendanceStatusAbsent {
+				break
+			}
+			streak++
+		}
+
+		// notifying when the streak reaches the threshold, not on every next absence
+		if streak != rule.Threshold {
+			return nil
+		}
+
+		key = fmt.Sprintf("lesson:%d", lesson.ID)
+
+	case models.RuleRateBelow:
+		from := time.Date(lesson.StartsAt.Year(), lesson.StartsAt.Month(), 1, 0, 0, 0, 0, lesson.StartsAt.Location())
+		to := from.AddDate(0, 1, 0).Add(-time.Nanosecond)
+
+		atts, err := n.attendances.GetByStudentAndDatespan(ctx, studentID, from, to)
+		if err != nil {
+			return err
+		}
+
+		summary := models.Summarize(atts)
+		if summary.Total == 0 {
+			return nil
+		}
+
+		rate := (summary.Total - summary.Absent) * 100 / summary.Total
+		if rate >= rule.Threshold {
+			return nil
+		}
+
+		data.Rate = rate
+		key = from.Format("month:2006-01")
+
+	default:
+		return nil
+	}
+
+	student, err := n.users.GetByID(ctx, studentID)
+	if errors.Is(err, abstractions.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	data.Student = student.Username
+
+	// the curator and the guardians of the student are notified
+	var recipients []*models.User
+
+	if student.CuratorID != nil {
+		curator, err := n.users.GetByID(ctx, *student.CuratorID)
+		if err != nil && !errors.Is(err, abstractions.ErrNotFound) {
+			return err
+		}
+		if curator != nil {
+			recipients = append(recipients, curator)
+		}
+	}
+
+	guardians, err := n.guardians.GetGuardians(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	recipients = append(recipients, guardians...)
+
+	for _, recipient := range recipients {
+		pref, err := n.repo.GetPreference(ctx, recipient.ID)
+		if err != nil {
+			return err
+		}
+		if pref == nil {
+			pref = models.DefaultNotificationPreference(recipient.ID)
+		}
+
+		monthData := data
+		if names, ok := months[pref.Language]; ok {
+			monthData.Month = names[lesson.StartsAt.Month()-1]
+		} else {
+			monthData.Month = months[models.LanguageRussian][lesson.StartsAt.Month()-1]
+		}
+
+		subject, body, err := render(pref.Language, rule.Kind, monthData)
+		if err != nil {
+			return err
+		}
+
+		addresses := map[string]string{}
+		if pref.Email && recipient.Email != "" {
+			addresses[models.ChannelEmail] = recipient.Email
+		}
+		if pref.Telegram && pref.TelegramChatID != "" {
+			addresses[models.ChannelTelegram] = pref.TelegramChatID
+		}
+
+		for channel, to := range addresses {
+			err := n.queue.EnqueueUnique(
+				ctx,
+				JobKindSend,
+				fmt.Sprintf("notify:%d:%d:%s:%d:%s", rule.ID, studentID, key, recipient.ID, channel),
+				delivery{Channel: channel, To: to, Subject: subject, Body: body},
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	n.logger.InfoContext(
+		ctx,
+		"rule has been broken",
+		slog.Any("rule_id", rule.ID),
+		slog.Any("student_id", studentID),
+		slog.Int("recipients", len(recipients)),
+	)
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package notifications_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/memory"
+	"github.com/cyberbrain-dev/na-meste-api/internal/events"
+	"github.com/cyberbrain-dev/na-meste-api/internal/jobs"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/notifications"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/notify"
+)
+
+func TestNotifier(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	s := memory.NewStore()
+	colleges := memory.NewColleges(s)
+	users := memory.NewUsers(s)
+	lessons := memory.NewLessons(s)
+	attendances := memory.NewAttendances(s)
+	guardians := memory.NewGuardians(s)
+	repo := memory.NewNotifications(s)
+	queue := memory.NewJobs(s)
+
+	email := notify.NewFake(nil)
+	telegram := notify.NewFake(nil)
+
+	runner := jobs.NewRunner(logger, queue, jobs.Options{MaxAttempts: 3})
+	notifier := notifications.NewNotifier(
+		logger, repo, users, guardians, lessons, attendances, runner,
+		map[string]notify.Sender{models.ChannelEmail: email, models.ChannelTelegram: telegram},
+	)
+
+	college := &models.College{Name: "college"}
+	if err := colleges.Create(ctx, college); err != nil {
+		t.Fatalf("cannot seed the college: %v", err)
+	}
+
+	user := func(role string, curatorID *uint) *models.User {
+		t.Helper()
+
+		u := &models.User{
+			Username:  role,
+			Email:     role + "@example.com",
+			Role:      role,
+			CollegeID: college.ID,
+			CuratorID: curatorID,
+		}
+		if err := users.Create(ctx, u); err != nil {
+			t.Fatalf("cannot seed the %s: %v", role, err)
+		}
+
+		return u
+	}
+
+	curator := user("teacher", nil)
+	guardian := user("guardian", nil)
+	student := user("student", &curator.ID)
+
+	if err := guardians.Link(ctx, &models.GuardianLink{GuardianID: guardian.ID, StudentID: student.ID}); err != nil {
+		t.Fatalf("cannot link the guardian: %v", err)
+	}
+
+	// the curator is notified through both channels, the guardian by email by default
+	err := repo.SetPreference(ctx, &models.NotificationPreference{
+		UserID:         curator.ID,
+		Email:          true,
+		Telegram:       true,
+		TelegramChatID: "42",
+		Language:       models.LanguageEnglish,
+	})
+	if err != nil {
+		t.Fatalf("cannot set the preference: %v", err)
+	}
+
+	rule := &models.NotificationRule{CollegeID: college.ID, Kind: models.RuleAbsencesInRow, Threshold: 1}
+	if err := repo.CreateRule(ctx, rule); err != nil {
+		t.Fatalf("cannot seed the rule: %v", err)
+	}
+
+	lesson := &models.Lesson{
+		CollegeID:  college.ID,
+		TeacherID:  curator.ID,
+		Title:      "Math",
+		StartsAt:   time.Now().Add(-2 * time.Hour),
+		EndsAt:     time.Now().Add(-time.Hour),
+		StudentIDs: []uint{student.ID},
+	}
+	if err := lessons.Create(ctx, lesson); err != nil {
+		t.Fatalf("cannot seed the lesson: %v", err)
+	}
+
+	written, err := attendances.MaterializeAbsences(ctx, lesson.ID)
+	if err != nil || written != 1 {
+		t.Fatalf("absences = %d, %v, want 1", written, err)
+	}
+
+	// runs the claimed jobs of the kind by the handler
+	drain := func(kind string, handle jobs.Handler) int {
+		t.Helper()
+
+		claimed, err := queue.Claim(ctx, []string{kind}, time.Now(), 100, time.Minute)
+		if err != nil {
+			t.Fatalf("cannot claim the %s jobs: %v", kind, err)
+		}
+
+		for _, job := range claimed {
+			if err := handle(ctx, job.Payload); err != nil {
+				t.Fatalf("%s has failed: %v", kind, err)
+			}
+			if err := queue.Complete(ctx, job.ID); err != nil {
+				t.Fatalf("cannot complete the job: %v", err)
+			}
+		}
+
+		return len(claimed)
+	}
+
+	publisher := events.Fanout{notifier}
+	absences := models.AbsencesMaterialized{LessonID: lesson.ID, Absences: written}
+
+	t.Run("fanout", func(t *testing.T) {
+		if err := publisher.Publish(ctx, college.ID, models.EventAbsenceMaterialized, absences); err != nil {
+			t.Fatalf("cannot publish the event: %v", err)
+		}
+
+		if n := drain(notifications.JobKindEvaluate, notifier.HandleEvaluate); n != 1 {
+			t.Fatalf("evaluations = %d, want 1", n)
+		}
+
+		// the curator by email and Telegram, the guardian by email
+		if n := drain(notifications.JobKindSend, notifier.HandleSend); n != 3 {
+			t.Fatalf("messages = %d, want 3", n)
+		}
+
+		sent := email.Sent()
+		if len(sent) != 2 {
+			t.Fatalf("emails = %+v, want 2", sent)
+		}
+		to := map[string]bool{sent[0].To: true, sent[1].To: true}
+		if !to[curator.Email] || !to[guardian.Email] {
+			t.Fatalf("emails are sent to %v, want %s and %s", to, curator.Email, guardian.Email)
+		}
+
+		if sent := telegram.Sent(); len(sent) != 1 || sent[0].To != "42" {
+			t.Fatalf("telegram messages = %+v, want one to 42", sent)
+		}
+	})
+
+	t.Run("deduplication", func(t *testing.T) {
+		// the same breach is evaluated again, e.g. by a retried job
+		if err := publisher.Publish(ctx, college.ID, models.EventAbsenceMaterialized, absences); err != nil {
+			t.Fatalf("cannot publish the event: %v", err)
+		}
+
+		if n := drain(notifications.JobKindEvaluate, notifier.HandleEvaluate); n != 1 {
+			t.Fatalf("evaluations = %d, want 1", n)
+		}
+
+		if n := drain(notifications.JobKindSend, notifier.HandleSend); n != 0 {
+			t.Fatalf("messages = %d, want none", n)
+		}
+
+		if n := len(email.Sent()) + len(telegram.Sent()); n != 3 {
+			t.Fatalf("messages sent = %d, want 3", n)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package notifications
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+)
+
+// Represents the data the messages are rendered with
+type messageData struct {
+	Student   string
+	Lesson    string
+	Date      string
+	Threshold int
+	Rate      int
+	Month     string
+}
+
+// Represents the templates of a message
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// Templates of the messages by the language and the kind of the rule
+var templates = map[string]map[string]messageTemplate{
+	models.LanguageRussian: {
+		models.RuleAbsencesInRow: parse(
+			"{{.Student}}: пропусков подряд — {{.Threshold}}",
+			"{{.Student}} пропускает занятия подряд, пропусков: {{.Threshold}}.\n"+
+				"Последнее пропущенное занятие: «{{.Lesson}}», {{.Date}}.",
+		),
+		models.RuleRateBelow: parse(
+			"{{.Student}}: посещаемость {{.Rate}}%",
+			"Посещаемость студента {{.Student}} за {{.Month}} составляет {{.Rate}}%, "+
+				"что ниже порога в {{.Threshold}}%.\n"+
+				"Последнее пропущенное занятие: «{{.Lesson}}», {{.Date}}.",
+		),
+	},
+	models.LanguageEnglish: {
+		models.RuleAbsencesInRow: parse(
+			"{{.Student}}: {{.Threshold}} absences in a row",
+			"{{.Student}} has missed {{.Threshold}} lessons in a row.\n"+
+				"The latest missed lesson: \"{{.Lesson}}\", {{.Date}}.",
+		),
+		models.RuleRateBelow: parse(
+			"{{.Student}}: attendance {{.Rate}}%",
+			"The attendance of {{.Student}} in {{.Month}} is {{.Rate}}%, "+
+				"which is below the threshold of {{.Threshold}}%.\n"+
+				"The latest missed lesson: \"{{.Lesson}}\", {{.Date}}.",
+		),
+	},
+}
+
+// Names of the months in the languages
+var months = map[string][]string{
+	models.LanguageRussian: {
+		"январь", "февраль", "март", "апрель", "май", "июнь",
+		"июль", "август", "сентябрь", "октябрь", "ноябрь", "декабрь",
+	},
+	models.LanguageEnglish: {
+		"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December",
+	},
+}
+
+// Parses the templates of a message
+func parse(subject string, body string) messageTemplate {
+	return messageTemplate{
+		subject: template.Must(template.New("subject").Parse(subject)),
+		body:    template.Must(template.New("body").Parse(body)),
+	}
+}
+
+// Renders the message of the rule in the language,
+// the Russian one is used for an unknown language
+func render(language string, kind string, data messageData) (subject string, body string, err error) {
+	byKind, ok := templates[language]
+	if !ok {
+		byKind = templates[models.LanguageRussian]
+	}
+
+	t := byKind[kind]
+
+	var s, b strings.Builder
+	if err := t.subject.Execute(&s, data); err != nil {
+		return "", "", err
+	}
+	if err := t.body.Execute(&b, data); err != nil {
+		return "", "", err
+	}
+
+	return s.String(), b.String(), nil
+}
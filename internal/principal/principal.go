@@ -0,0 +1,62 @@
+// Contains the authenticated caller of a request
+package principal
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+// Represents the authenticated caller of a request
+type Principal struct {
+	UserID    uint
+	Role      string
+	CollegeID uint
+	// ID of the login session the token was issued for
+	SessionID string
+	// What the token is restricted to, none means everything the role allows
+	Scopes []string
+}
+
+// Checks whether the principal has one of the roles
+func (p *Principal) HasRole(roles ...string) bool {
+	return slices.Contains(roles, p.Role)
+}
+
+// Checks whether the token of the principal allows the scope
+func (p *Principal) HasScope(scope string) bool {
+	return len(p.Scopes) == 0 || slices.Contains(p.Scopes, scope)
+}
+
+// Key of the principal in a context
+type principalKey struct{}
+
+// Returns a copy of the context carrying the principal
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// Returns the principal of the context, false if the caller is anonymous
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// Returns the ID of the user of the context, 0 if the caller is anonymous
+func UserID(ctx context.Context) uint {
+	if p, ok := FromContext(ctx); ok {
+		return p.UserID
+	}
+
+	return 0
+}
+
+// Returns the log attribute of the caller, it is empty and so
+// dropped by the logger if the caller is anonymous
+func LogAttr(ctx context.Context) slog.Attr {
+	if p, ok := FromContext(ctx); ok {
+		return slog.Any("user_id", p.UserID)
+	}
+
+	return slog.Attr{}
+}
@@ -0,0 +1,82 @@
+// Contains the background job permanently deleting the soft-deleted records
+package retention
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Permanently deletes the records soft-deleted longer than the retention period ago
+type Purger struct {
+	logger      *slog.Logger
+	colleges    abstractions.CollegesRepo
+	users       abstractions.UsersRepo
+	attendances abstractions.AttendancesRepo
+	jobs        abstractions.JobsRepo
+	period      time.Duration
+}
+
+// Kind of the job purging the records
+const JobKind = "retention.purge"
+
+// Creates a new purger keeping the records for the period
+func NewPurger(
+	logger *slog.Logger,
+	colleges abstractions.CollegesRepo,
+	users abstractions.UsersRepo,
+	attendances abstractions.AttendancesRepo,
+	jobs abstractions.JobsRepo,
+	period time.Duration,
+) *Purger {
+	return &Purger{
+		logger:      logger.With(slog.String("job", "retention.Purger")),
+		colleges:    colleges,
+		users:       users,
+		attendances: attendances,
+		jobs:        jobs,
+		period:      period,
+	}
+}
+
+// Purges the records deleted before the retention period ending now
+func (p *Purger) Purge(ctx context.Context, now time.Time) error {
+	before := now.Add(-p.period)
+
+	// the attendances go first, the users and the colleges still referred to are kept
+	attendances, err := p.attendances.Purge(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	users, err := p.users.Purge(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	colleges, err := p.colleges.Purge(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	// the done jobs are kept as long as the deleted records
+	jobs, err := p.jobs.PurgeDone(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	if attendances+users+colleges+jobs > 0 {
+		p.logger.InfoContext(
+			ctx,
+			"deleted records have been purged",
+			slog.Int("attendances", attendances),
+			slog.Int("users", users),
+			slog.Int("colleges", colleges),
+			slog.Int("jobs", jobs),
+		)
+	}
+
+	return nil
+}
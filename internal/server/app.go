@@ -0,0 +1,404 @@
+// Contains the HTTP API with its background workers
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/absences"
+	"github.com/cyberbrain-dev/na-meste-api/internal/config"
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/traced"
+	"github.com/cyberbrain-dev/na-meste-api/internal/events"
+	"github.com/cyberbrain-dev/na-meste-api/internal/feed"
+	"github.com/cyberbrain-dev/na-meste-api/internal/health"
+	"github.com/cyberbrain-dev/na-meste-api/internal/jobs"
+	"github.com/cyberbrain-dev/na-meste-api/internal/logging"
+	"github.com/cyberbrain-dev/na-meste-api/internal/metrics"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/notifications"
+	"github.com/cyberbrain-dev/na-meste-api/internal/retention"
+	"github.com/cyberbrain-dev/na-meste-api/internal/webhooks"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/ldapauth"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/notify"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/oidc"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Represents the repositories of the storage
+type Repos struct {
+	Colleges      abstractions.CollegesRepo
+	Users         abstractions.UsersRepo
+	Attendances   abstractions.AttendancesRepo
+	Lessons       abstractions.LessonsRepo
+	Audit         abstractions.AuditRepo
+	Webhooks      abstractions.WebhooksRepo
+	Jobs          abstractions.JobsRepo
+	Guardians     abstractions.GuardiansRepo
+	Notifications abstractions.NotificationsRepo
+
+	// Runs the writes of the repositories in a transaction
+	Transactor abstractions.Transactor
+}
+
+// Represents the bus of the live feed shared by the instances
+type Bus interface {
+	feed.Bus
+
+	// Passes the payloads of every instance to handle until the context is cancelled
+	Listen(ctx context.Context, handle func(payload []byte))
+}
+
+// Represents the settings of the app
+type Options struct {
+	Logger *slog.Logger
+	Config config.Configuration
+	Repos  Repos
+
+	// Bus of the live feed, a single instance broadcasts the feed by itself if nil
+	Bus Bus
+	// Checks of the storage run by the readiness probe next to the check of the job queue
+	Checks []health.Check
+	// Collectors of the storage exposed next to the metrics of the API
+	Collectors []prometheus.Collector
+	// Source of the current time, time.Now if nil
+	Clock func() time.Time
+}
+
+// Represents the API with its background workers
+type App struct {
+	logger *slog.Logger
+	cfg    config.Configuration
+	bus    Bus
+
+	router     *chi.Mux
+	probe      *health.Probe
+	srv        *http.Server
+	listener   net.Listener
+	runner     *jobs.Runner
+	dispatcher *webhooks.Dispatcher
+	feed       *feed.Feed
+
+	// the contexts of the requests are cancelled
+	// if the requests outlive the shutdown, which aborts their queries
+	cancelRequests context.CancelFunc
+
+	// the workers run until the shutdown
+	workersCtx  context.Context
+	stopWorkers context.CancelFunc
+	workers     sync.WaitGroup
+}
+
+// Creates the app, nothing is started until Start is called
+func New(opts Options) (*App, error) {
+	// the records are written with the trace and the caller of their context
+	logger := slog.New(logging.NewHandler(opts.Logger.Handler()))
+	cfg := opts.Config
+	// the calls of the repositories are traced
+	repos := traceRepos(opts.Repos)
+
+	clock := opts.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	// setting up the identity providers of the colleges
+	providers := make(map[uint]*oidc.Provider)
+	for _, p := range cfg.OIDC.Providers {
+		providers[p.CollegeID] = oidc.NewProvider(oidc.Config{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		})
+	}
+	states := oidc.NewStateStore([]byte(cfg.OIDC.StateSecret), cfg.OIDC.StateTTL)
+
+	// setting up the LDAP directories of the colleges
+	backends := make(map[uint]*ldapauth.Authenticator)
+	for _, b := range cfg.LDAP.Backends {
+		var groupRoles []ldapauth.GroupRole
+		for _, gr := range b.GroupRoles {
+			groupRoles = append(groupRoles, ldapauth.GroupRole{Group: gr.Group, Role: gr.Role})
+		}
+
+		backends[b.CollegeID] = ldapauth.NewAuthenticator(ldapauth.Config{
+			URL:            b.URL,
+			StartTLS:       b.StartTLS,
+			BindDN:         b.BindDN,
+			BindPassword:   b.BindPassword,
+			BaseDN:         b.BaseDN,
+			UserFilter:     b.UserFilter,
+			NameAttribute:  b.NameAttribute,
+			GroupAttribute: b.GroupAttribute,
+			GroupRoles:     groupRoles,
+			DefaultRole:    b.DefaultRole,
+			Timeout:        b.Timeout,
+		})
+	}
+
+	// setting up the background jobs
+	runner := jobs.NewRunner(logger, repos.Jobs, jobs.Options{
+		Workers:      cfg.Jobs.Workers,
+		PollInterval: cfg.Jobs.PollInterval,
+		Lease:        cfg.Jobs.Lease,
+		MaxAttempts:  cfg.Jobs.MaxAttempts,
+		BaseBackoff:  cfg.Jobs.BaseBackoff,
+		MaxBackoff:   cfg.Jobs.MaxBackoff,
+		DrainTimeout: cfg.Jobs.DrainTimeout,
+		Clock:        clock,
+	})
+
+	notifier := notifications.NewNotifier(
+		logger,
+		repos.Notifications, repos.Users, repos.Guardians, repos.Lessons, repos.Attendances,
+		runner,
+		newSenders(logger, cfg.Notifications),
+	)
+
+	// a single instance broadcasts the feed by itself
+	var bus feed.Bus
+	if opts.Bus != nil {
+		bus = opts.Bus
+	}
+	liveFeed := feed.NewFeed(logger, feed.NewBroker(), bus)
+
+	// events are written to the outbox of the webhooks,
+	// checked against the notification rules and streamed to the feed
+	publisher := events.Fanout{webhooks.NewPublisher(repos.Webhooks), notifier, liveFeed}
+
+	materializer := absences.NewMaterializer(logger, repos.Lessons, repos.Attendances, repos.Transactor, publisher)
+	purger := retention.NewPurger(logger, repos.Colleges, repos.Users, repos.Attendances, repos.Jobs, cfg.Retention.Period)
+
+	runner.Handle(notifications.JobKindEvaluate, notifier.HandleEvaluate)
+	runner.Handle(notifications.JobKindSend, notifier.HandleSend)
+	runner.Handle(absences.JobKind, func(ctx context.Context, payload json.RawMessage) error {
+		return materializer.MaterializeDue(ctx, clock())
+	})
+	runner.Handle(retention.JobKind, func(ctx context.Context, payload json.RawMessage) error {
+		return purger.Purge(ctx, clock())
+	})
+
+	if err := runner.Schedule(cfg.Absences.Schedule, absences.JobKind); err != nil {
+		return nil, err
+	}
+	if err := runner.Schedule(cfg.Retention.Schedule, retention.JobKind); err != nil {
+		return nil, err
+	}
+
+	// the instance is ready once its storage and the job queue are reachable
+	checks := append([]health.Check(nil), opts.Checks...)
+	checks = append(checks, health.Check{
+		Name: "jobs",
+		Run: func(ctx context.Context) error {
+			_, err := repos.Jobs.GetByStatus(ctx, models.JobStatusPending, 1)
+			return err
+		},
+	})
+	probe := health.NewProbe(cfg.Health.Timeout, checks...)
+
+	m, err := metrics.New(opts.Collectors...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot set up the metrics: %w", err)
+	}
+
+	router, err := NewRouter(logger, cfg, Dependencies{
+		Repos:         repos,
+		Events:        publisher,
+		Feed:          liveFeed,
+		OIDCProviders: providers,
+		OIDCStates:    states,
+		LDAPBackends:  backends,
+		Probe:         probe,
+		Metrics:       m,
+		Clock:         clock,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+
+	// creating an HTTP server
+	srv := &http.Server{
+		Addr:         cfg.HTTPServer.Address,
+		Handler:      router,
+		ReadTimeout:  cfg.HTTPServer.Timeout,
+		WriteTimeout: cfg.HTTPServer.Timeout,
+		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return requestsCtx
+		},
+	}
+
+	// the feed streams are ended, so they do not hold the shutdown
+	srv.RegisterOnShutdown(liveFeed.Close)
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+
+	return &App{
+		logger: logger,
+		cfg:    cfg,
+		bus:    opts.Bus,
+		router: router,
+		probe:  probe,
+		srv:    srv,
+		runner: runner,
+		dispatcher: webhooks.NewDispatcher(logger, repos.Webhooks, webhooks.Options{
+			Interval:     cfg.Webhooks.Interval,
+			BatchSize:    cfg.Webhooks.BatchSize,
+			MaxAttempts:  cfg.Webhooks.MaxAttempts,
+			BaseBackoff:  cfg.Webhooks.BaseBackoff,
+			MaxBackoff:   cfg.Webhooks.MaxBackoff,
+			Timeout:      cfg.Webhooks.Timeout,
+			AllowPrivate: cfg.Webhooks.AllowPrivateTargets,
+			Clock:        clock,
+		}),
+		feed:           liveFeed,
+		cancelRequests: cancelRequests,
+		workersCtx:     workersCtx,
+		stopWorkers:    stopWorkers,
+	}, nil
+}
+
+// Returns the handler of the API
+func (a *App) Handler() http.Handler {
+	return a.router
+}
+
+// Starts listening on the configured address and runs the background workers
+func (a *App) Start() error {
+	a.logger.Info(
+		"launching the server...",
+		slog.String("address", a.cfg.HTTPServer.Address),
+	)
+
+	// listening before returning, so the address is known and taken
+	listener, err := net.Listen("tcp", a.cfg.HTTPServer.Address)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", a.cfg.HTTPServer.Address, err)
+	}
+	a.listener = listener
+
+	// running the server in separate gorutine
+	go func() {
+		if err := a.srv.Serve(listener); err != nil {
+			a.logger.Warn("server not running", slog.Any("err", err))
+		}
+	}()
+
+	a.logger.Info("server started", slog.String("address", a.Addr()))
+
+	// running the background jobs until the shutdown
+	a.run(a.runner.Run)
+	a.run(a.dispatcher.Run)
+	if a.bus != nil {
+		a.run(func(ctx context.Context) { a.bus.Listen(ctx, a.feed.Receive) })
+	}
+
+	return nil
+}
+
+// Returns the address the server listens on, it is empty until the app is started
+func (a *App) Addr() string {
+	if a.listener == nil {
+		return ""
+	}
+
+	return a.listener.Addr().String()
+}
+
+// Gracefully stops the server and waits for the background workers.
+// The requests still running when the context is done are aborted
+func (a *App) Shutdown(ctx context.Context) error {
+	// the readiness fails first, so no new traffic is sent to the instance
+	a.probe.Drain()
+
+	// stopping the background jobs,
+	// the running ones are drained while the server is shutting down
+	a.stopWorkers()
+
+	// the requests are still served until the balancer sees the readiness fail
+	delay := time.NewTimer(a.cfg.Health.DrainDelay)
+	select {
+	case <-delay.C:
+	case <-ctx.Done():
+		delay.Stop()
+	}
+
+	// if the server hasn't closed all the connections
+	// during the timeout it is shutdowned by force
+	err := a.srv.Shutdown(ctx)
+	if err != nil {
+		// aborting the requests that are still running
+		a.cancelRequests()
+		a.srv.Close()
+	}
+
+	// waiting for the running jobs, so the storage can be closed after
+	a.workers.Wait()
+
+	return err
+}
+
+// Runs the worker until the shutdown
+func (a *App) run(worker func(ctx context.Context)) {
+	a.workers.Add(1)
+
+	go func() {
+		defer a.workers.Done()
+
+		worker(a.workersCtx)
+	}()
+}
+
+// Wraps the repositories, so their methods are traced
+func traceRepos(r Repos) Repos {
+	return Repos{
+		Colleges:      traced.NewColleges(r.Colleges),
+		Users:         traced.NewUsers(r.Users),
+		Attendances:   traced.NewAttendances(r.Attendances),
+		Lessons:       traced.NewLessons(r.Lessons),
+		Audit:         traced.NewAudit(r.Audit),
+		Webhooks:      traced.NewWebhooks(r.Webhooks),
+		Jobs:          traced.NewJobs(r.Jobs),
+		Guardians:     traced.NewGuardians(r.Guardians),
+		Notifications: traced.NewNotifications(r.Notifications),
+		Transactor:    r.Transactor,
+	}
+}
+
+// Returns the senders of the notification channels
+func newSenders(logger *slog.Logger, cfg config.Notifications) map[string]notify.Sender {
+	senders := map[string]notify.Sender{
+		models.ChannelEmail:    notify.NewFake(logger.With(slog.String("channel", models.ChannelEmail))),
+		models.ChannelTelegram: notify.NewFake(logger.With(slog.String("channel", models.ChannelTelegram))),
+	}
+	if cfg.SMTP.Driver == "smtp" {
+		senders[models.ChannelEmail] = notify.NewSMTP(notify.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.SMTP.From,
+		})
+	}
+	if cfg.Telegram.Driver == "telegram" {
+		senders[models.ChannelTelegram] = notify.NewTelegram(
+			cfg.Telegram.APIURL,
+			cfg.Telegram.Token,
+			cfg.Telegram.Timeout,
+		)
+	}
+
+	return senders
+}
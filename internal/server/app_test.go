@@ -0,0 +1,332 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/buildinfo"
+	"github.com/cyberbrain-dev/na-meste-api/internal/health"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/notifications"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestAppStartAndShutdown(t *testing.T) {
+	app, err := server.New(server.Options{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config: testConfig(t, "requests"),
+		Repos:  memoryRepos(),
+	})
+	if err != nil {
+		t.Fatalf("cannot create the app: %v", err)
+	}
+
+	if err := app.Start(); err != nil {
+		t.Fatalf("cannot start the app: %v", err)
+	}
+
+	resp, err := http.Get("http://" + app.Addr() + "/")
+	if err != nil {
+		t.Fatalf("cannot reach the app: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := app.Shutdown(ctx); err != nil {
+		t.Fatalf("cannot shut down the app: %v", err)
+	}
+
+	if _, err := http.Get("http://" + app.Addr() + "/"); err == nil {
+		t.Fatalf("app is still serving after the shutdown")
+	}
+}
+
+func TestAppInvalidConfig(t *testing.T) {
+	cfg := testConfig(t, "everything")
+
+	_, err := server.New(server.Options{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config: cfg,
+		Repos:  memoryRepos(),
+	})
+	if err == nil {
+		t.Fatalf("app is created with an invalid openapi.validation")
+	}
+}
+
+func TestAppClock(t *testing.T) {
+	repos := memoryRepos()
+	h := &harness{repos: repos}
+
+	college := h.college(t)
+	teacher := h.user(t, "teacher", college.ID)
+	student := h.user(t, "student", college.ID)
+
+	now := time.Now()
+	lesson := h.lesson(t, teacher, now.Add(-time.Hour), now.Add(time.Hour), student)
+
+	// the lesson is over by the clock of the app
+	app, err := server.New(server.Options{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config: testConfig(t, "requests"),
+		Repos:  repos,
+		Clock:  func() time.Time { return now.Add(2 * time.Hour) },
+	})
+	if err != nil {
+		t.Fatalf("cannot create the app: %v", err)
+	}
+
+	h.srv = httptest.NewServer(app.Handler())
+	t.Cleanup(func() {
+		h.srv.Close()
+		app.Shutdown(context.Background())
+	})
+
+	res := h.do(t, http.MethodPost, "/lessons/"+itoa(lesson.ID)+"/absences", token(t, teacher), nil)
+	expect(t, res, http.StatusOK, "")
+	if res.Body["absences"] != float64(1) {
+		t.Fatalf("absences = %v, want 1", res.Body["absences"])
+	}
+
+	// the corrections are stamped by the clock of the app too
+	path := "/lessons/" + itoa(lesson.ID) + "/attendances/" + itoa(student.ID)
+
+	res = h.do(t, http.MethodPut, path, token(t, teacher), map[string]any{"status": "on_time", "reason": "Was there"})
+	expect(t, res, http.StatusOK, "")
+
+	changes, err := repos.Attendances.GetHistory(context.Background(), id(t, res, "attendance_id"))
+	if err != nil {
+		t.Fatalf("cannot get the history: %v", err)
+	}
+	if len(changes) != 1 || !changes[0].ChangedAt.Equal(now.Add(2*time.Hour)) {
+		t.Fatalf("history = %+v, want a change at %v", changes, now.Add(2*time.Hour))
+	}
+}
+
+func TestProbes(t *testing.T) {
+	h := newHarness(t, "all")
+
+	res := h.do(t, http.MethodGet, "/healthz", "", nil)
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodGet, "/version", "", nil)
+	expect(t, res, http.StatusOK, "")
+	if res.Body["version"] != buildinfo.Version {
+		t.Fatalf("version = %v, want %q", res.Body["version"], buildinfo.Version)
+	}
+
+	res = h.do(t, http.MethodGet, "/readyz", "", nil)
+	expect(t, res, http.StatusOK, "")
+	if checks, _ := res.Body["checks"].(map[string]any); checks["jobs"] != "OK" {
+		t.Fatalf("checks = %v, want the job queue OK", res.Body["checks"])
+	}
+}
+
+func TestReadinessChecks(t *testing.T) {
+	h := &harness{repos: memoryRepos()}
+
+	cfg := testConfig(t, "requests")
+	cfg.Health.Timeout = 100 * time.Millisecond
+
+	app, err := server.New(server.Options{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config: cfg,
+		Repos:  h.repos,
+		Checks: []health.Check{
+			{Name: "postgres", Run: func(ctx context.Context) error { return errors.New("connection refused") }},
+			{Name: "schema", Run: func(ctx context.Context) error {
+				// the slow checks are given up after the timeout
+				<-ctx.Done()
+				return ctx.Err()
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("cannot create the app: %v", err)
+	}
+
+	h.srv = httptest.NewServer(app.Handler())
+	defer h.srv.Close()
+
+	res := h.do(t, http.MethodGet, "/readyz", "", nil)
+	expect(t, res, http.StatusServiceUnavailable, "")
+
+	checks, _ := res.Body["checks"].(map[string]any)
+	if checks["postgres"] != "unavailable" || checks["schema"] != "unavailable" || checks["jobs"] != "OK" {
+		t.Fatalf("checks = %v", checks)
+	}
+
+	// the process is alive anyway
+	res = h.do(t, http.MethodGet, "/healthz", "", nil)
+	expect(t, res, http.StatusOK, "")
+}
+
+func TestReadinessOnShutdown(t *testing.T) {
+	cfg := testConfig(t, "requests")
+	cfg.Health.DrainDelay = 300 * time.Millisecond
+
+	app, err := server.New(server.Options{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config: cfg,
+		Repos:  memoryRepos(),
+	})
+	if err != nil {
+		t.Fatalf("cannot create the app: %v", err)
+	}
+
+	if err := app.Start(); err != nil {
+		t.Fatalf("cannot start the app: %v", err)
+	}
+
+	// probes the app's own server, nil if it refuses the connection
+	readyz := func() map[string]any {
+		t.Helper()
+
+		resp, err := http.Get("http://" + app.Addr() + "/readyz")
+		if err != nil {
+			return nil
+		}
+		defer resp.Body.Close()
+
+		var body map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("cannot decode the readiness: %v", err)
+		}
+		body["code"] = resp.StatusCode
+
+		return body
+	}
+
+	if res := readyz(); res == nil || res["code"] != http.StatusOK {
+		t.Fatalf("got the readiness %v before the shutdown, want 200", res)
+	}
+
+	shutdown := make(chan error)
+	go func() {
+		shutdown <- app.Shutdown(context.Background())
+	}()
+
+	// the instance tells it is shutting down while still taking the connections
+	time.Sleep(50 * time.Millisecond)
+
+	res := readyz()
+	if res == nil || res["code"] != http.StatusServiceUnavailable || res["status"] != "shutting_down" {
+		t.Fatalf("got the readiness %v during the drain delay, want 503 shutting_down", res)
+	}
+
+	select {
+	case err := <-shutdown:
+		if err != nil {
+			t.Fatalf("cannot shut down the app: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the app has not shut down")
+	}
+
+	if res := readyz(); res != nil {
+		t.Fatalf("got the readiness %v after the shutdown, want the connection refused", res)
+	}
+}
+
+func TestWorkerLogs(t *testing.T) {
+	// the spans are created by the global provider as in cmd/na-meste-api
+	prevProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	t.Cleanup(func() { otel.SetTracerProvider(prevProvider) })
+
+	logs := &syncBuffer{}
+
+	cfg := testConfig(t, "requests")
+	cfg.Jobs.PollInterval = 10 * time.Millisecond
+
+	repos := memoryRepos()
+
+	app, err := server.New(server.Options{
+		Logger: slog.New(slog.NewJSONHandler(logs, nil)),
+		Config: cfg,
+		Repos:  repos,
+	})
+	if err != nil {
+		t.Fatalf("cannot create the app: %v", err)
+	}
+
+	if err := app.Start(); err != nil {
+		t.Fatalf("cannot start the app: %v", err)
+	}
+	t.Cleanup(func() { app.Shutdown(context.Background()) })
+
+	// a notification to no channel fails at once
+	job := &models.Job{Kind: notifications.JobKindSend, Payload: []byte("{}"), MaxAttempts: 1}
+	if _, err := repos.Jobs.Enqueue(context.Background(), job); err != nil {
+		t.Fatalf("cannot enqueue the job: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(logs.String(), "job has been moved to the dead letters") {
+		if time.Now().After(deadline) {
+			t.Fatalf("the job has not failed: %s", logs.String())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// every record of the job is written with its trace
+	traced := 0
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("cannot decode the record %q: %v", line, err)
+		}
+
+		if record["job_id"] == nil {
+			continue
+		}
+		if record["trace_id"] == nil {
+			t.Errorf("the record of the job has no trace id: %s", line)
+		}
+
+		traced++
+	}
+	if traced == 0 {
+		t.Fatalf("no records of the job: %s", logs.String())
+	}
+}
+
+// Represents a buffer the workers may write to while it is read
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
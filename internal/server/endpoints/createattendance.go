@@ -1,39 +1,46 @@
 package endpoints
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"io"
 	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/cyberbrain-dev/na-meste-api/internal/metrics"
 	"github.com/cyberbrain-dev/na-meste-api/internal/models"
 	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
-	"github.com/cyberbrain-dev/na-meste-api/pkg/errfmt"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/geo"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-playground/validator/v10"
 )
 
 // An andpoint for registring an attendance
-func CreateAttendance(logger *slog.Logger, repo abstractions.AttendancesRepo) http.HandlerFunc {
+// checking the scanner's location against the college's geofence
+// and classifying the arrival against the student's lesson schedule
+func CreateAttendance(
+	logger *slog.Logger,
+	repo abstractions.AttendancesRepo,
+	colleges abstractions.CollegesRepo,
+	lessons abstractions.LessonsRepo,
+	tx abstractions.Transactor,
+	events abstractions.EventPublisher,
+	m *metrics.Metrics,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// name of the endpoint
 		ep := "endpoints.CreateAttendance"
 
 		// a struct for server's response
 		type response struct {
-			Status string `json:"status"`
-			Error  string `json:"error,omitempty"`
-		}
-
-		// decoder of the body's json
-		decoder := json.NewDecoder(r.Body)
-		// encodes the response to the response body
-		encoder := json.NewEncoder(w)
+			Status          string      `json:"status"`
+			GeofenceVerdict geo.Verdict `json:"geofence_verdict,omitempty"`
 
-		// setting the type of response
-		w.Header().Set("Content-Type", "application/json")
+			AttendanceStatus string `json:"attendance_status,omitempty"`
+			MinutesLate      int    `json:"minutes_late,omitempty"`
+		}
 
 		// editing the logger
 		logger := logger.With(
@@ -46,89 +53,148 @@ func CreateAttendance(logger *slog.Logger, repo abstractions.AttendancesRepo) ht
 			StudentID uint      `json:"student_id" validate:"required"`
 			CollegeID uint      `json:"college_id" validate:"required"`
 			Date      time.Time `json:"date" validate:"required"`
+
+			// location of the device and its accuracy in meters
+			Latitude  *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,gte=-90,lte=90"`
+			Longitude *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,gte=-180,lte=180"`
+			Accuracy  *float64 `json:"accuracy" validate:"omitempty,gte=0"`
 		}
 
-		// decoding the request's body
-		err := decoder.Decode(&req)
-		// if the body's empty
-		if errors.Is(err, io.EOF) {
-			logger.Error("request body is empty")
+		// decoding and validating the request
+		if code, ok := decodeRequestCode(w, r, logger, &req); !ok {
+			m.CheckInRejected(code)
+			return
+		}
 
-			w.WriteHeader(http.StatusBadRequest)
+		// getting the college for its geofence
+		college, err := colleges.GetByID(r.Context(), req.CollegeID)
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "college does not exist", slog.Any("college_id", req.CollegeID))
 
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Request body is empty",
-			})
+			m.CheckInRejected(respond.CodeNotFound)
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "College does not exist")
 
 			return
 		}
-		// if another error occurs
 		if err != nil {
-			logger.Error("cannot decode the request body")
-
-			w.WriteHeader(http.StatusBadRequest)
+			logger.ErrorContext(r.Context(), "cannot get the college", slog.Any("err", err))
 
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Cannot decode the request body",
-			})
+			rejectCheckIn(m, err)
+			respond.Failure(w, r, err, "Failed to create the attendance")
 
 			return
 		}
 
-		// logging...
-		logger.Info(
-			"request body decoded",
-			slog.Any("request", req),
-		)
+		// checking the location against the geofence
+		verdict := geo.VerdictUnknown
+		if req.Latitude != nil && req.Longitude != nil {
+			accuracy := 0.0
+			if req.Accuracy != nil {
+				accuracy = *req.Accuracy
+			}
+
+			verdict = college.Geofence.Check(
+				geo.Point{Latitude: *req.Latitude, Longitude: *req.Longitude},
+				accuracy,
+			)
+		}
+
+		// in the reject mode the location must be sent and be not outside the fence
+		if college.Geofence != nil &&
+			college.GeofenceMode == models.GeofenceModeReject &&
+			(verdict == geo.VerdictOutside || verdict == geo.VerdictUnknown) {
+			logger.ErrorContext(
+				r.Context(),
+				"attendance is rejected by the geofence",
+				slog.String("verdict", string(verdict)),
+			)
 
-		// validating the request
-		if err := vld.Struct(&req); err != nil {
-			validateErr := err.(validator.ValidationErrors)
+			m.CheckInRejected(respond.CodeOutsideGeofence)
+			respond.Error(w, r, http.StatusForbidden, respond.CodeOutsideGeofence, "Location is outside the college's geofence")
 
-			logger.Error("invalid request", slog.Any("err", err))
+			return
+		}
 
-			w.WriteHeader(http.StatusBadRequest)
+		// finding the lesson the student is checking in to
+		lesson, err := lessons.GetByStudentAt(r.Context(), req.StudentID, req.Date, college.EarlyCheckIn)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the lesson", slog.Any("err", err))
 
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  errfmt.ValidationErrorsToString(validateErr),
-			})
+			rejectCheckIn(m, err)
+			respond.Failure(w, r, err, "Failed to create the attendance")
 
 			return
 		}
 
+		// classifying the arrival
+		status, minutesLate := lesson.Classify(req.Date, college.LateGrace)
+
+		var lessonID *uint
+		if lesson != nil {
+			lessonID = &lesson.ID
+		}
+
 		// creating the attendance
 		attendance := models.Attendance{
-			UserID:    req.StudentID,
-			CollegeID: req.CollegeID,
-			Date:      req.Date,
+			UserID:          req.StudentID,
+			CollegeID:       req.CollegeID,
+			Date:            req.Date,
+			Latitude:        req.Latitude,
+			Longitude:       req.Longitude,
+			Accuracy:        req.Accuracy,
+			GeofenceVerdict: verdict,
+			LessonID:        lessonID,
+			Status:          status,
+			MinutesLate:     minutesLate,
 		}
 
-		// adding the attendance to the database
-		if err := repo.Create(&attendance); err != nil {
-			logger.Error("failed to create the attendance")
+		// adding the attendance to the database together with its event,
+		// so the subscribers learn only of the saved attendances
+		err = tx.InTx(r.Context(), func(ctx context.Context) error {
+			if err := repo.Create(ctx, &attendance); err != nil {
+				return err
+			}
 
-			w.WriteHeader(http.StatusInternalServerError)
+			return events.Publish(ctx, attendance.CollegeID, models.EventAttendanceCreated, attendance)
+		})
+		if err != nil {
+			logger.ErrorContext(r.Context(), "failed to create the attendance", slog.Any("err", err))
 
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Failed to create the attendance",
-			})
+			rejectCheckIn(m, err)
+			respond.Failure(w, r, err, "Failed to create the attendance")
 
 			return
 		}
 
-		// if everything is fine
-		logger.Info("failed to create the attendance")
+		myMw.RecordChange(r.Context(), "attendance", attendance.ID, nil, attendance)
+		m.AttendanceCreated(attendance.CollegeID, principal.UserID(r.Context()))
 
-		w.WriteHeader(http.StatusCreated)
+		// if everything is fine
+		logger.InfoContext(
+			r.Context(),
+			"attendance has been created",
+			slog.String("geofence_verdict", string(verdict)),
+			slog.String("status", status),
+		)
 
-		encoder.Encode(response{
-			Status: "OK",
+		respond.JSON(w, http.StatusCreated, response{
+			Status:           "OK",
+			GeofenceVerdict:  verdict,
+			AttendanceStatus: status,
+			MinutesLate:      minutesLate,
 		})
 
 		return
 	}
 }
+
+// Counts the failed check-in as refused unless the server has failed
+// or the scanner has given up on it, so the outages do not look like refusals
+func rejectCheckIn(m *metrics.Metrics, err error) {
+	status, code := respond.FailureCode(err)
+	if status >= http.StatusInternalServerError || status == respond.StatusClientClosedRequest {
+		return
+	}
+
+	m.CheckInRejected(code)
+}
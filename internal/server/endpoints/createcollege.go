@@ -1,17 +1,15 @@
 package endpoints
 
 import (
-	"encoding/json"
 	"errors"
-	"io"
 	"log/slog"
 	"net/http"
 
 	"github.com/cyberbrain-dev/na-meste-api/internal/models"
 	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
-	"github.com/cyberbrain-dev/na-meste-api/pkg/errfmt"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-playground/validator/v10"
 )
 
 // Returns a handler for college creation
@@ -20,20 +18,6 @@ func CreateCollege(logger *slog.Logger, repo abstractions.CollegesRepo) http.Han
 		// name of the endpoint
 		ep := "endpoints.CreateCollege"
 
-		// an anonymous struct for server's response
-		type response struct {
-			Status string `json:"status"`
-			Error  string `json:"error,omitempty"`
-		}
-
-		// decoder of the body's json
-		decoder := json.NewDecoder(r.Body)
-		// encodes the response to the response body
-		encoder := json.NewEncoder(w)
-
-		// setting the type of response
-		w.Header().Set("Content-Type", "application/json")
-
 		// editing the logger
 		logger := logger.With(
 			slog.String("ep", ep),
@@ -45,54 +29,8 @@ func CreateCollege(logger *slog.Logger, repo abstractions.CollegesRepo) http.Han
 			Name string `json:"name" validate:"required"`
 		}
 
-		// decoding the request's body
-		err := decoder.Decode(&req)
-		// if the body's empty
-		if errors.Is(err, io.EOF) {
-			logger.Error("request body is empty")
-
-			w.WriteHeader(http.StatusBadRequest)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Request body is empty",
-			})
-
-			return
-		}
-		// if another error occurs
-		if err != nil {
-			logger.Error("cannot decode the request body")
-
-			w.WriteHeader(http.StatusBadRequest)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Cannot decode the request body",
-			})
-
-			return
-		}
-
-		// logging...
-		logger.Info(
-			"request body decoded",
-			slog.Any("request", req),
-		)
-
-		// validating the request
-		if err := vld.Struct(&req); err != nil {
-			validateErr := err.(validator.ValidationErrors)
-
-			logger.Error("invalid request", slog.Any("err", err))
-
-			w.WriteHeader(http.StatusBadRequest)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  errfmt.ValidationErrorsToString(validateErr),
-			})
-
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
 			return
 		}
 
@@ -103,30 +41,32 @@ func CreateCollege(logger *slog.Logger, repo abstractions.CollegesRepo) http.Han
 
 		// trying to write college to a db
 		// and handling an error if the one occurs
-		if err := repo.Create(&college); err != nil {
-			logger.Error("cannot add college to db", slog.Any("err", err))
+		err := repo.Create(r.Context(), &college)
+		if errors.Is(err, abstractions.ErrDuplicate) {
+			logger.ErrorContext(r.Context(), "college with this name already exists", slog.String("name", college.Name))
 
-			w.WriteHeader(http.StatusBadRequest)
+			respond.Error(w, r, http.StatusConflict, respond.CodeConflict, "College with this name already exists")
 
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Cannot add college to db",
-			})
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot add college to db", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot add college to db")
 
 			return
 		}
 
+		myMw.RecordChange(r.Context(), "college", college.ID, nil, college)
+
 		// logging...
-		logger.Info(
+		logger.InfoContext(
+			r.Context(),
 			"college has been successfully added",
 			slog.String("name", college.Name),
 		)
 
 		// OK response
-		w.WriteHeader(http.StatusCreated)
-
-		encoder.Encode(response{
-			Status: "OK",
-		})
+		respond.OK(w, http.StatusCreated)
 	}
 }
@@ -0,0 +1,130 @@
+package endpoints
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for scheduling a lesson of the caller.
+// The lesson and its students must belong to the caller's college
+func CreateLesson(logger *slog.Logger, repo abstractions.LessonsRepo, users abstractions.UsersRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.CreateLesson"
+
+		// a struct for server's response
+		type response struct {
+			Status   string `json:"status"`
+			LessonID uint   `json:"lesson_id,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the teacher
+		caller, ok := principal.FromContext(r.Context())
+		if !ok {
+			logger.ErrorContext(r.Context(), "no principal in the context")
+
+			respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
+
+			return
+		}
+
+		// client's request for scheduling the lesson
+		var req struct {
+			CollegeID  uint      `json:"college_id" validate:"required"`
+			Title      string    `json:"title" validate:"required,max=200"`
+			StartsAt   time.Time `json:"starts_at" validate:"required"`
+			EndsAt     time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+			StudentIDs []uint    `json:"student_ids" validate:"required,min=1,dive,required"`
+		}
+
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
+			return
+		}
+
+		// teachers schedule the lessons of their own college only
+		if req.CollegeID != caller.CollegeID {
+			logger.ErrorContext(r.Context(), "college of the lesson is not the teacher's one", slog.Any("college_id", req.CollegeID))
+
+			respond.Error(w, r, http.StatusForbidden, respond.CodeCollegeMismatch, "Lesson must belong to your college")
+
+			return
+		}
+
+		// the students must be the ones of the college
+		for _, id := range req.StudentIDs {
+			student, err := users.GetByID(r.Context(), id)
+			if errors.Is(err, abstractions.ErrNotFound) {
+				logger.ErrorContext(r.Context(), "student does not exist", slog.Any("student_id", id))
+
+				respond.Error(w, r, http.StatusUnprocessableEntity, respond.CodeInvalidReference, "Student does not exist")
+
+				return
+			}
+			if err != nil {
+				logger.ErrorContext(r.Context(), "cannot get the student", slog.Any("err", err))
+
+				respond.Failure(w, r, err, "Cannot add lesson to db")
+
+				return
+			}
+
+			if student.Role != "student" || student.CollegeID != caller.CollegeID {
+				logger.ErrorContext(r.Context(), "user is not a student of the college", slog.Any("student_id", id))
+
+				respond.Error(w, r, http.StatusBadRequest, respond.CodeCollegeMismatch, "Students must belong to the college of the lesson")
+
+				return
+			}
+		}
+
+		// creating a lesson model owned by the caller
+		lesson := models.Lesson{
+			CollegeID:  req.CollegeID,
+			TeacherID:  caller.UserID,
+			Title:      req.Title,
+			StartsAt:   req.StartsAt,
+			EndsAt:     req.EndsAt,
+			StudentIDs: req.StudentIDs,
+		}
+
+		// trying to write lesson to a db
+		if err := repo.Create(r.Context(), &lesson); err != nil {
+			logger.ErrorContext(r.Context(), "cannot add lesson to db", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot add lesson to db")
+
+			return
+		}
+
+		myMw.RecordChange(r.Context(), "lesson", lesson.ID, nil, lesson)
+
+		// logging...
+		logger.InfoContext(
+			r.Context(),
+			"lesson has been successfully added",
+			slog.Any("lesson_id", lesson.ID),
+		)
+
+		// OK response
+		respond.JSON(w, http.StatusCreated, response{
+			Status:   "OK",
+			LessonID: lesson.ID,
+		})
+	}
+}
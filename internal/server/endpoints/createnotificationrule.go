@@ -0,0 +1,104 @@
+package endpoints
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for adding a notification rule to a college
+func CreateNotificationRule(
+	logger *slog.Logger,
+	repo abstractions.NotificationsRepo,
+	colleges abstractions.CollegesRepo,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.CreateNotificationRule"
+
+		// a struct for server's response
+		type response struct {
+			Status string `json:"status"`
+			RuleID uint   `json:"rule_id,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the college from the route
+		collegeID, err := strconv.ParseUint(chi.URLParam(r, "college_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid college id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid college id")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, uint(collegeID)) {
+			return
+		}
+
+		// client's request for creating the rule:
+		// a number of absences in a row or a rate in percent
+		var req struct {
+			Kind      string `json:"kind" validate:"required,oneof=absences_in_row rate_below"`
+			Threshold int    `json:"threshold" validate:"required,min=1,max=100"`
+		}
+
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
+			return
+		}
+
+		college, err := colleges.GetByID(r.Context(), uint(collegeID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "college does not exist", slog.Uint64("college_id", collegeID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "College does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the college", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot create the rule")
+
+			return
+		}
+
+		rule := models.NotificationRule{
+			CollegeID: college.ID,
+			Kind:      req.Kind,
+			Threshold: req.Threshold,
+		}
+
+		if err := repo.CreateRule(r.Context(), &rule); err != nil {
+			logger.ErrorContext(r.Context(), "cannot create the rule", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot create the rule")
+
+			return
+		}
+
+		myMw.RecordChange(r.Context(), "notification_rule", rule.ID, nil, rule)
+
+		logger.InfoContext(r.Context(), "rule has been created", slog.Any("rule_id", rule.ID))
+
+		respond.JSON(w, http.StatusCreated, response{
+			Status: "OK",
+			RuleID: rule.ID,
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package endpoints
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/config"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/webhook"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for subscribing a webhook to the events of a college.
+// The signing secret is shown only in the response of this endpoint
+func CreateWebhook(
+	logger *slog.Logger,
+	repo abstractions.WebhooksRepo,
+	colleges abstractions.CollegesRepo,
+	cfg config.Webhooks,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.CreateWebhook"
+
+		// a struct for server's response
+		type response struct {
+			Status    string `json:"status"`
+			WebhookID uint   `json:"webhook_id,omitempty"`
+			Secret    string `json:"secret,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the college from the route
+		collegeID, err := strconv.ParseUint(chi.URLParam(r, "college_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid college id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid college id")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, uint(collegeID)) {
+			return
+		}
+
+		// client's request for creating the subscription
+		var req struct {
+			URL    string   `json:"url" validate:"required,url,startswith=https://|startswith=http://"`
+			Events []string `json:"events" validate:"required,min=1,dive,oneof=attendance.created attendance.updated attendance.deleted absence.materialized"`
+		}
+
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
+			return
+		}
+
+		// the deliveries are not sent to the internal services
+		if !cfg.AllowPrivateTargets {
+			if err := webhook.CheckTarget(req.URL); err != nil {
+				logger.ErrorContext(r.Context(), "webhook target is not allowed", slog.String("url", req.URL), slog.Any("err", err))
+
+				respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Webhook URL must not point to the loopback or a private network")
+
+				return
+			}
+		}
+
+		college, err := colleges.GetByID(r.Context(), uint(collegeID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "college does not exist", slog.Uint64("college_id", collegeID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "College does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the college", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot create the webhook")
+
+			return
+		}
+
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot generate the secret", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot create the webhook")
+
+			return
+		}
+
+		sub := models.WebhookSubscription{
+			CollegeID: college.ID,
+			URL:       req.URL,
+			Secret:    secret,
+			Events:    req.Events,
+		}
+
+		if err := repo.CreateSubscription(r.Context(), &sub); err != nil {
+			logger.ErrorContext(r.Context(), "cannot create the webhook", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot create the webhook")
+
+			return
+		}
+
+		// the secret is not written to the audit log
+		snapshot := sub
+		snapshot.Secret = ""
+		myMw.RecordChange(r.Context(), "webhook", sub.ID, nil, snapshot)
+
+		logger.InfoContext(r.Context(), "webhook has been created", slog.Any("webhook_id", sub.ID))
+
+		respond.JSON(w, http.StatusCreated, response{
+			Status:    "OK",
+			WebhookID: sub.ID,
+			Secret:    sub.Secret,
+		})
+	}
+}
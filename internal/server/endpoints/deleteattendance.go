@@ -0,0 +1,88 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for soft-deleting an attendance.
+// The record can be restored by an admin until the retention period ends
+func DeleteAttendance(
+	logger *slog.Logger,
+	repo abstractions.AttendancesRepo,
+	tx abstractions.Transactor,
+	events abstractions.EventPublisher,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.DeleteAttendance"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the ID from the route
+		id, err := strconv.ParseUint(chi.URLParam(r, "attendance_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid id")
+
+			return
+		}
+
+		record, err := repo.Get(r.Context(), uint(id))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "record does not exist", slog.Uint64("id", id))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Record does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the record", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot delete the record")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, record.CollegeID) {
+			return
+		}
+
+		// the subscribers are notified in the transaction of the deletion
+		err = tx.InTx(r.Context(), func(ctx context.Context) error {
+			if _, err := repo.Delete(ctx, record.ID); err != nil {
+				return err
+			}
+
+			return events.Publish(ctx, record.CollegeID, models.EventAttendanceDeleted, record)
+		})
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot delete the record", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot delete the record")
+
+			return
+		}
+
+		myMw.RecordChange(r.Context(), "attendance", record.ID, record, nil)
+
+		logger.InfoContext(r.Context(), "record has been deleted", slog.Uint64("id", id))
+
+		respond.OK(w, http.StatusOK)
+	}
+}
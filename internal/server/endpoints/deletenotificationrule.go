@@ -0,0 +1,72 @@
+package endpoints
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for deleting a notification rule
+func DeleteNotificationRule(logger *slog.Logger, repo abstractions.NotificationsRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.DeleteNotificationRule"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the rule from the route
+		ruleID, err := strconv.ParseUint(chi.URLParam(r, "rule_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid rule id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid rule id")
+
+			return
+		}
+
+		rule, err := repo.GetRule(r.Context(), uint(ruleID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "rule does not exist", slog.Uint64("rule_id", ruleID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Rule does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the rule", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot delete the rule")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, rule.CollegeID) {
+			return
+		}
+
+		if err := repo.DeleteRule(r.Context(), rule.ID); err != nil {
+			logger.ErrorContext(r.Context(), "cannot delete the rule", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot delete the rule")
+
+			return
+		}
+
+		myMw.RecordChange(r.Context(), "notification_rule", rule.ID, rule, nil)
+
+		logger.InfoContext(r.Context(), "rule has been deleted", slog.Uint64("rule_id", ruleID))
+
+		respond.OK(w, http.StatusOK)
+	}
+}
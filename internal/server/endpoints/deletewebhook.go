@@ -0,0 +1,74 @@
+package endpoints
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for deleting a webhook with its delivery log
+func DeleteWebhook(logger *slog.Logger, repo abstractions.WebhooksRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.DeleteWebhook"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the webhook from the route
+		webhookID, err := strconv.ParseUint(chi.URLParam(r, "webhook_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid webhook id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid webhook id")
+
+			return
+		}
+
+		sub, err := repo.GetSubscription(r.Context(), uint(webhookID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "webhook does not exist", slog.Uint64("webhook_id", webhookID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Webhook does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the webhook", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot delete the webhook")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, sub.CollegeID) {
+			return
+		}
+
+		if err := repo.DeleteSubscription(r.Context(), sub.ID); err != nil {
+			logger.ErrorContext(r.Context(), "cannot delete the webhook", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot delete the webhook")
+
+			return
+		}
+
+		// the secret is not written to the audit log
+		sub.Secret = ""
+		myMw.RecordChange(r.Context(), "webhook", sub.ID, sub, nil)
+
+		logger.InfoContext(r.Context(), "webhook has been deleted", slog.Uint64("webhook_id", webhookID))
+
+		respond.OK(w, http.StatusOK)
+	}
+}
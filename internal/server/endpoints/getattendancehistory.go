@@ -0,0 +1,117 @@
+package endpoints
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for getting the history of the manual changes of an attendance
+func GetAttendanceHistory(
+	logger *slog.Logger,
+	repo abstractions.AttendancesRepo,
+	lessons abstractions.LessonsRepo,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.GetAttendanceHistory"
+
+		// a struct for server's response
+		type response struct {
+			Status  string                     `json:"status"`
+			History []*models.AttendanceChange `json:"history"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the teacher
+		caller, ok := principal.FromContext(r.Context())
+		if !ok {
+			logger.ErrorContext(r.Context(), "no principal in the context")
+
+			respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
+
+			return
+		}
+
+		// getting the attendance from the route
+		attendanceID, err := strconv.ParseUint(chi.URLParam(r, "attendance_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid attendance id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid attendance id")
+
+			return
+		}
+
+		attendance, err := repo.Get(r.Context(), uint(attendanceID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "attendance does not exist", slog.Uint64("attendance_id", attendanceID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Attendance does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the attendance", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot get the history")
+
+			return
+		}
+
+		// the history is visible to the teacher of the lesson only,
+		// the attendances outside the lessons have no teacher
+		var lesson *models.Lesson
+		if attendance.LessonID != nil {
+			lesson, err = lessons.Get(r.Context(), *attendance.LessonID)
+			if err != nil && !errors.Is(err, abstractions.ErrNotFound) {
+				logger.ErrorContext(r.Context(), "cannot get the lesson", slog.Any("err", err))
+
+				respond.Failure(w, r, err, "Cannot get the history")
+
+				return
+			}
+		}
+		if lesson == nil || lesson.TeacherID != caller.UserID {
+			logger.ErrorContext(r.Context(), "lesson belongs to another teacher")
+
+			respond.Error(w, r, http.StatusForbidden, respond.CodeNotOwner, "Lesson belongs to another teacher")
+
+			return
+		}
+
+		history, err := repo.GetHistory(r.Context(), attendance.ID)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the history", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot get the history")
+
+			return
+		}
+
+		// an attendance that has not been corrected has an empty history
+		if history == nil {
+			history = []*models.AttendanceChange{}
+		}
+
+		logger.InfoContext(r.Context(), "successfully got the history", slog.Uint64("attendance_id", attendanceID))
+
+		respond.JSON(w, http.StatusOK, response{
+			Status:  "OK",
+			History: history,
+		})
+	}
+}
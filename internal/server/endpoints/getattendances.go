@@ -1,18 +1,14 @@
 package endpoints
 
 import (
-	"encoding/json"
-	"errors"
-	"io"
 	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/cyberbrain-dev/na-meste-api/internal/models"
 	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
-	"github.com/cyberbrain-dev/na-meste-api/pkg/errfmt"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-playground/validator/v10"
 )
 
 // An endpoint for getting the endpoints
@@ -24,17 +20,10 @@ func GetAttendances(logger *slog.Logger, repo abstractions.AttendancesRepo) http
 		// a struct for server's response
 		type response struct {
 			Status      string               `json:"status"`
-			Error       string               `json:"error,omitempty"`
 			Attendances []*models.Attendance `json:"attendances,omitempty"`
-		}
-
-		// decoder of the body's json
-		decoder := json.NewDecoder(r.Body)
-		// encodes the response to the response body
-		encoder := json.NewEncoder(w)
 
-		// setting the type of response
-		w.Header().Set("Content-Type", "application/json")
+			Summary *models.AttendanceSummary `json:"summary,omitempty"`
+		}
 
 		// editing the logger
 		logger := logger.With(
@@ -49,98 +38,44 @@ func GetAttendances(logger *slog.Logger, repo abstractions.AttendancesRepo) http
 			EndDate   time.Time `json:"end_date" validate:"required"`
 		}
 
-		// decoding the request's body
-		err := decoder.Decode(&req)
-		// if the body's empty
-		if errors.Is(err, io.EOF) {
-			logger.Error("request body is empty")
-
-			w.WriteHeader(http.StatusBadRequest)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Request body is empty",
-			})
-
-			return
-		}
-		// if another error occurs
-		if err != nil {
-			logger.Error("cannot decode the request body")
-
-			w.WriteHeader(http.StatusBadRequest)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Cannot decode the request body",
-			})
-
-			return
-		}
-
-		// logging...
-		logger.Info(
-			"request body decoded",
-			slog.Any("request", req),
-		)
-
-		// validating the request
-		if err := vld.Struct(&req); err != nil {
-			validateErr := err.(validator.ValidationErrors)
-
-			logger.Error("invalid request", slog.Any("err", err))
-
-			w.WriteHeader(http.StatusBadRequest)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  errfmt.ValidationErrorsToString(validateErr),
-			})
-
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
 			return
 		}
 
 		// checking if the dates are correct
 		if req.StartDate.Unix() > req.EndDate.Unix() {
-			logger.Error("start date must be less than end date")
+			logger.ErrorContext(r.Context(), "Start date must be before end date")
 
-			w.WriteHeader(http.StatusBadRequest)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "start date must be less than end date",
-			})
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Start date must be before end date")
 
 			return
 		}
 
 		// getting the attendances
-		atts, err := repo.GetByStudentAndDatespan(
+		atts, err := repo.GetByStudentAndDatespan(r.Context(),
 			req.StudentID,
 			req.StartDate,
 			req.EndDate,
 		)
 		// if an error occurs
 		if err != nil {
-			logger.Error("cannot get the attendances")
-
-			w.WriteHeader(http.StatusInternalServerError)
+			logger.ErrorContext(r.Context(), "Cannot get the attendances")
 
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "cannot get the attendances",
-			})
+			respond.Failure(w, r, err, "Cannot get the attendances")
 
 			return
 		}
 
-		logger.Info("successfully got the attendances", slog.Any("student_id", req.StudentID))
+		logger.InfoContext(r.Context(), "successfully got the attendances", slog.Any("student_id", req.StudentID))
 
-		w.WriteHeader(http.StatusOK)
+		// counting the on-time and late arrivals
+		summary := models.Summarize(atts)
 
-		encoder.Encode(response{
+		respond.JSON(w, http.StatusOK, response{
 			Status:      "OK",
 			Attendances: atts,
+			Summary:     &summary,
 		})
 
 		return
@@ -0,0 +1,113 @@
+package endpoints
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// Returns a handler for querying the audit log.
+// Filters are passed in the query: actor_id, action, entity, entity_id,
+// from and to (RFC 3339), limit and offset
+func GetAuditLog(logger *slog.Logger, repo abstractions.AuditRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.GetAuditLog"
+
+		// a struct for server's response
+		type response struct {
+			Status  string                `json:"status"`
+			Records []*models.AuditRecord `json:"records,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		filter := models.AuditFilter{
+			Action:   query.Get("action"),
+			Entity:   query.Get("entity"),
+			EntityID: query.Get("entity_id"),
+			Limit:    defaultAuditLimit,
+		}
+
+		// parsing the filters that are not strings
+		var parseErr error
+
+		if v := query.Get("actor_id"); v != "" {
+			if id, err := strconv.ParseUint(v, 10, 64); err != nil {
+				parseErr = err
+			} else {
+				actorID := uint(id)
+				filter.ActorID = &actorID
+			}
+		}
+		if v := query.Get("from"); v != "" {
+			if t, err := time.Parse(time.RFC3339, v); err != nil {
+				parseErr = err
+			} else {
+				filter.From = t
+			}
+		}
+		if v := query.Get("to"); v != "" {
+			if t, err := time.Parse(time.RFC3339, v); err != nil {
+				parseErr = err
+			} else {
+				filter.To = t
+			}
+		}
+		if v := query.Get("limit"); v != "" {
+			if n, err := strconv.Atoi(v); err != nil || n <= 0 || n > maxAuditLimit {
+				parseErr = strconv.ErrRange
+			} else {
+				filter.Limit = n
+			}
+		}
+		if v := query.Get("offset"); v != "" {
+			if n, err := strconv.Atoi(v); err != nil || n < 0 {
+				parseErr = strconv.ErrRange
+			} else {
+				filter.Offset = n
+			}
+		}
+
+		if parseErr != nil {
+			logger.ErrorContext(r.Context(), "invalid filters", slog.Any("err", parseErr))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid filters")
+
+			return
+		}
+
+		records, err := repo.List(r.Context(), filter)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the audit records", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot get the audit records")
+
+			return
+		}
+
+		logger.InfoContext(r.Context(), "successfully got the audit records", slog.Int("count", len(records)))
+
+		respond.JSON(w, http.StatusOK, response{
+			Status:  "OK",
+			Records: records,
+		})
+	}
+}
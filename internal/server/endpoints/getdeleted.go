@@ -0,0 +1,89 @@
+package endpoints
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for listing the soft-deleted colleges, users or attendances
+func GetDeleted(
+	logger *slog.Logger,
+	colleges abstractions.CollegesRepo,
+	users abstractions.UsersRepo,
+	attendances abstractions.AttendancesRepo,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.GetDeleted"
+
+		// a struct for server's response
+		type response struct {
+			Status  string `json:"status"`
+			Records any    `json:"records,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		caller, ok := principal.FromContext(r.Context())
+		if !ok {
+			logger.ErrorContext(r.Context(), "no principal in the context")
+
+			respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
+
+			return
+		}
+
+		kind := chi.URLParam(r, "kind")
+
+		// an admin sees the records of their own college only
+		var (
+			records any
+			err     error
+		)
+
+		switch kind {
+		case "colleges":
+			records, err = colleges.GetDeleted(r.Context(), caller.CollegeID)
+		case "users":
+			usrs, e := users.GetDeleted(r.Context(), caller.CollegeID)
+			// the password hashes are not shown
+			for _, u := range usrs {
+				u.PasswordHash = ""
+			}
+			records, err = usrs, e
+		case "attendances":
+			records, err = attendances.GetDeleted(r.Context(), caller.CollegeID)
+		default:
+			logger.ErrorContext(r.Context(), "unknown kind of records", slog.String("kind", kind))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Unknown kind of records")
+
+			return
+		}
+
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the deleted records", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot get the deleted records")
+
+			return
+		}
+
+		logger.InfoContext(r.Context(), "successfully got the deleted records", slog.String("kind", kind))
+
+		respond.JSON(w, http.StatusOK, response{
+			Status:  "OK",
+			Records: records,
+		})
+	}
+}
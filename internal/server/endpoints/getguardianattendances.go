@@ -0,0 +1,135 @@
+package endpoints
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// The span of the attendances returned to a guardian by default
+const defaultGuardianSpan = 30 * 24 * time.Hour
+
+// Returns a handler for getting the attendances and their summary
+// of a student linked to the calling guardian.
+// The span is set by the from and to query params (RFC 3339)
+func GetGuardianAttendances(
+	logger *slog.Logger,
+	repo abstractions.AttendancesRepo,
+	guardians abstractions.GuardiansRepo,
+	clock func() time.Time,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.GetGuardianAttendances"
+
+		// a struct for server's response
+		type response struct {
+			Status      string               `json:"status"`
+			Attendances []*models.Attendance `json:"attendances,omitempty"`
+
+			Summary *models.AttendanceSummary `json:"summary,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the student from the route
+		studentID, err := strconv.ParseUint(chi.URLParam(r, "student_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid student id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid student id")
+
+			return
+		}
+
+		// getting the span
+		query := r.URL.Query()
+
+		to := clock()
+		if v := query.Get("to"); v != "" {
+			if to, err = time.Parse(time.RFC3339, v); err != nil {
+				logger.ErrorContext(r.Context(), "invalid end of the span", slog.String("to", v))
+
+				respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid to, expected RFC 3339")
+
+				return
+			}
+		}
+
+		from := to.Add(-defaultGuardianSpan)
+		if v := query.Get("from"); v != "" {
+			if from, err = time.Parse(time.RFC3339, v); err != nil {
+				logger.ErrorContext(r.Context(), "invalid start of the span", slog.String("from", v))
+
+				respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid from, expected RFC 3339")
+
+				return
+			}
+		}
+
+		if from.After(to) {
+			logger.ErrorContext(r.Context(), "Start date must be before end date")
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "From must be before to")
+
+			return
+		}
+
+		// the guardian is the caller
+		caller, _ := principal.FromContext(r.Context())
+
+		linked, err := guardians.IsLinked(r.Context(), caller.UserID, uint(studentID))
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot check the link", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot get the attendances")
+
+			return
+		}
+		// the other students are not revealed even to exist
+		if !linked {
+			logger.ErrorContext(
+				r.Context(),
+				"student is not linked to the guardian",
+				slog.Any("guardian_id", caller.UserID),
+				slog.Uint64("student_id", studentID),
+			)
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotLinked, "Student is not linked to you")
+
+			return
+		}
+
+		atts, err := repo.GetByStudentAndDatespan(r.Context(), uint(studentID), from, to)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the attendances", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot get the attendances")
+
+			return
+		}
+
+		logger.InfoContext(r.Context(), "successfully got the attendances", slog.Uint64("student_id", studentID))
+
+		// counting the on-time and late arrivals
+		summary := models.Summarize(atts)
+
+		respond.JSON(w, http.StatusOK, response{
+			Status:      "OK",
+			Attendances: atts,
+			Summary:     &summary,
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package endpoints
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for listing the students linked to the calling guardian
+func GetGuardianStudents(logger *slog.Logger, repo abstractions.GuardiansRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.GetGuardianStudents"
+
+		// a student linked to the guardian
+		type student struct {
+			ID        uint   `json:"id"`
+			Username  string `json:"username"`
+			CollegeID uint   `json:"college_id"`
+		}
+
+		// a struct for server's response
+		type response struct {
+			Status   string    `json:"status"`
+			Students []student `json:"students,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// the guardian is the caller
+		caller, _ := principal.FromContext(r.Context())
+
+		linked, err := repo.GetStudents(r.Context(), caller.UserID)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the students", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot get the students")
+
+			return
+		}
+
+		var students []student
+		for _, s := range linked {
+			students = append(students, student{
+				ID:        s.ID,
+				Username:  s.Username,
+				CollegeID: s.CollegeID,
+			})
+		}
+
+		logger.InfoContext(r.Context(), "successfully got the students", slog.Any("guardian_id", caller.UserID))
+
+		respond.JSON(w, http.StatusOK, response{
+			Status:   "OK",
+			Students: students,
+		})
+	}
+}
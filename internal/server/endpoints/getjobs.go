@@ -0,0 +1,116 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// How many jobs are returned by default and at most
+const (
+	defaultJobsLimit = 50
+	maxJobsLimit     = 500
+)
+
+// Returns a handler for listing the background jobs by their status,
+// the dead letters are returned by default
+func GetJobs(logger *slog.Logger, repo abstractions.JobsRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.GetJobs"
+
+		// a job in the queue
+		type job struct {
+			ID          uint            `json:"id"`
+			Kind        string          `json:"kind"`
+			Payload     json.RawMessage `json:"payload"`
+			Status      string          `json:"status"`
+			Attempts    int             `json:"attempts"`
+			MaxAttempts int             `json:"max_attempts"`
+			RunAt       time.Time       `json:"run_at"`
+			LastError   string          `json:"last_error,omitempty"`
+			CreatedAt   time.Time       `json:"created_at"`
+			FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+		}
+
+		// a struct for server's response
+		type response struct {
+			Status string `json:"status"`
+			Jobs   []job  `json:"jobs,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		status := query.Get("status")
+		switch status {
+		case "":
+			status = models.JobStatusDead
+		case models.JobStatusPending, models.JobStatusRunning, models.JobStatusDone, models.JobStatusDead:
+		default:
+			logger.ErrorContext(r.Context(), "invalid status", slog.String("status", status))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid status")
+
+			return
+		}
+
+		limit := defaultJobsLimit
+		if v := query.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 || n > maxJobsLimit {
+				logger.ErrorContext(r.Context(), "invalid limit", slog.String("limit", v))
+
+				respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid limit")
+
+				return
+			}
+
+			limit = n
+		}
+
+		records, err := repo.GetByStatus(r.Context(), status, limit)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the jobs", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot get the jobs")
+
+			return
+		}
+
+		var jobs []job
+		for _, j := range records {
+			jobs = append(jobs, job{
+				ID:          j.ID,
+				Kind:        j.Kind,
+				Payload:     j.Payload,
+				Status:      j.Status,
+				Attempts:    j.Attempts,
+				MaxAttempts: j.MaxAttempts,
+				RunAt:       j.RunAt,
+				LastError:   j.LastError,
+				CreatedAt:   j.CreatedAt,
+				FinishedAt:  j.FinishedAt,
+			})
+		}
+
+		logger.InfoContext(r.Context(), "successfully got the jobs", slog.String("status", status))
+
+		respond.JSON(w, http.StatusOK, response{
+			Status: "OK",
+			Jobs:   jobs,
+		})
+	}
+}
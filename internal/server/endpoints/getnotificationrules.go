@@ -0,0 +1,78 @@
+package endpoints
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for listing the notification rules of a college
+func GetNotificationRules(logger *slog.Logger, repo abstractions.NotificationsRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.GetNotificationRules"
+
+		// a rule of the college
+		type rule struct {
+			ID        uint   `json:"id"`
+			Kind      string `json:"kind"`
+			Threshold int    `json:"threshold"`
+		}
+
+		// a struct for server's response
+		type response struct {
+			Status string `json:"status"`
+			Rules  []rule `json:"rules,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the college from the route
+		collegeID, err := strconv.ParseUint(chi.URLParam(r, "college_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid college id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid college id")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, uint(collegeID)) {
+			return
+		}
+
+		records, err := repo.GetRules(r.Context(), uint(collegeID))
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the rules", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot get the rules")
+
+			return
+		}
+
+		var rules []rule
+		for _, r := range records {
+			rules = append(rules, rule{
+				ID:        r.ID,
+				Kind:      r.Kind,
+				Threshold: r.Threshold,
+			})
+		}
+
+		logger.InfoContext(r.Context(), "successfully got the rules", slog.Uint64("college_id", collegeID))
+
+		respond.JSON(w, http.StatusOK, response{
+			Status: "OK",
+			Rules:  rules,
+		})
+	}
+}
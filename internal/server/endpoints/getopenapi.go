@@ -0,0 +1,39 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler that serves the OpenAPI document as json
+func GetOpenAPI(logger *slog.Logger, doc *openapi3.T) http.HandlerFunc {
+	// the document doesn't change, so it is marshalled once
+	body, err := json.Marshal(doc)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.GetOpenAPI"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot marshal the OpenAPI document", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot marshal the OpenAPI document")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}
+}
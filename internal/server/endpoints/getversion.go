@@ -0,0 +1,27 @@
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/buildinfo"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+)
+
+// Returns a handler for getting the information about the build
+func GetVersion() http.HandlerFunc {
+	// the build doesn't change, so it is read once
+	info := buildinfo.Get()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// a struct for server's response
+		type response struct {
+			Status string `json:"status"`
+			buildinfo.Info
+		}
+
+		respond.JSON(w, http.StatusOK, response{
+			Status: "OK",
+			Info:   info,
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// How many deliveries are returned by default and at most
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+// Returns a handler for getting the delivery log of a webhook
+func GetWebhookDeliveries(logger *slog.Logger, repo abstractions.WebhooksRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.GetWebhookDeliveries"
+
+		// a delivery in the log
+		type delivery struct {
+			ID             uint            `json:"id"`
+			Event          string          `json:"event"`
+			Payload        json.RawMessage `json:"payload"`
+			Status         string          `json:"status"`
+			Attempts       int             `json:"attempts"`
+			NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+			LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+			LastStatusCode int             `json:"last_status_code,omitempty"`
+			LastError      string          `json:"last_error,omitempty"`
+			CreatedAt      time.Time       `json:"created_at"`
+			DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+		}
+
+		// a struct for server's response
+		type response struct {
+			Status     string     `json:"status"`
+			Deliveries []delivery `json:"deliveries,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the webhook from the route
+		webhookID, err := strconv.ParseUint(chi.URLParam(r, "webhook_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid webhook id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid webhook id")
+
+			return
+		}
+
+		limit := defaultDeliveriesLimit
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 || n > maxDeliveriesLimit {
+				logger.ErrorContext(r.Context(), "invalid limit", slog.String("limit", v))
+
+				respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid limit")
+
+				return
+			}
+
+			limit = n
+		}
+
+		sub, err := repo.GetSubscription(r.Context(), uint(webhookID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "webhook does not exist", slog.Uint64("webhook_id", webhookID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Webhook does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the webhook", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot get the deliveries")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, sub.CollegeID) {
+			return
+		}
+
+		records, err := repo.GetDeliveries(r.Context(), uint(webhookID), limit)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the deliveries", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot get the deliveries")
+
+			return
+		}
+
+		var deliveries []delivery
+		for _, d := range records {
+			item := delivery{
+				ID:             d.ID,
+				Event:          d.Event,
+				Payload:        d.Payload,
+				Status:         d.Status,
+				Attempts:       d.Attempts,
+				LastAttemptAt:  d.LastAttemptAt,
+				LastStatusCode: d.LastStatusCode,
+				LastError:      d.LastError,
+				CreatedAt:      d.CreatedAt,
+				DeliveredAt:    d.DeliveredAt,
+			}
+
+			// only the pending deliveries are going to be attempted
+			if d.Status == models.DeliveryStatusPending {
+				next := d.NextAttemptAt
+				item.NextAttemptAt = &next
+			}
+
+			deliveries = append(deliveries, item)
+		}
+
+		logger.InfoContext(r.Context(), "successfully got the deliveries", slog.Uint64("webhook_id", webhookID))
+
+		respond.JSON(w, http.StatusOK, response{
+			Status:     "OK",
+			Deliveries: deliveries,
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package endpoints
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for listing the webhooks of a college
+func GetWebhooks(logger *slog.Logger, repo abstractions.WebhooksRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.GetWebhooks"
+
+		// a webhook without its secret
+		type webhook struct {
+			ID        uint      `json:"id"`
+			URL       string    `json:"url"`
+			Events    []string  `json:"events"`
+			Active    bool      `json:"active"`
+			CreatedAt time.Time `json:"created_at"`
+		}
+
+		// a struct for server's response
+		type response struct {
+			Status   string    `json:"status"`
+			Webhooks []webhook `json:"webhooks,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the college from the route
+		collegeID, err := strconv.ParseUint(chi.URLParam(r, "college_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid college id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid college id")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, uint(collegeID)) {
+			return
+		}
+
+		subs, err := repo.GetSubscriptions(r.Context(), uint(collegeID))
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the webhooks", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot get the webhooks")
+
+			return
+		}
+
+		var webhooks []webhook
+		for _, s := range subs {
+			webhooks = append(webhooks, webhook{
+				ID:        s.ID,
+				URL:       s.URL,
+				Events:    s.Events,
+				Active:    s.Active,
+				CreatedAt: s.CreatedAt,
+			})
+		}
+
+		logger.InfoContext(r.Context(), "successfully got the webhooks", slog.Uint64("college_id", collegeID))
+
+		respond.JSON(w, http.StatusOK, response{
+			Status:   "OK",
+			Webhooks: webhooks,
+		})
+	}
+}
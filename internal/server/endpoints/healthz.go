@@ -0,0 +1,15 @@
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+)
+
+// Returns a handler telling the process is alive.
+// The dependencies are not checked, so a broken db does not restart the instance
+func Healthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		respond.OK(w, http.StatusOK)
+	}
+}
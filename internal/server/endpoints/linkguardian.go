@@ -0,0 +1,127 @@
+package endpoints
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for linking a student to a guardian
+func LinkGuardian(
+	logger *slog.Logger,
+	repo abstractions.GuardiansRepo,
+	users abstractions.UsersRepo,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.LinkGuardian"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the guardian and the student from the route
+		guardianID, err := strconv.ParseUint(chi.URLParam(r, "guardian_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid guardian id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid guardian id")
+
+			return
+		}
+
+		studentID, err := strconv.ParseUint(chi.URLParam(r, "student_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid student id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid student id")
+
+			return
+		}
+
+		guardian, err := users.GetByID(r.Context(), uint(guardianID))
+		if err != nil && !errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "cannot get the guardian", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot link the student")
+
+			return
+		}
+		if guardian == nil || guardian.Role != "guardian" {
+			logger.ErrorContext(r.Context(), "guardian does not exist", slog.Uint64("guardian_id", guardianID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Guardian does not exist")
+
+			return
+		}
+
+		student, err := users.GetByID(r.Context(), uint(studentID))
+		if err != nil && !errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "cannot get the student", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot link the student")
+
+			return
+		}
+		if student == nil || student.Role != "student" {
+			logger.ErrorContext(r.Context(), "student does not exist", slog.Uint64("student_id", studentID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Student does not exist")
+
+			return
+		}
+
+		// a guardian watches the students of their own college only
+		if guardian.CollegeID != student.CollegeID {
+			logger.ErrorContext(
+				r.Context(),
+				"guardian and student belong to different colleges",
+				slog.Uint64("guardian_id", guardianID),
+				slog.Uint64("student_id", studentID),
+			)
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeCollegeMismatch, "Guardian and student belong to different colleges")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, student.CollegeID) {
+			return
+		}
+
+		link := models.GuardianLink{
+			GuardianID: guardian.ID,
+			StudentID:  student.ID,
+		}
+
+		if err := repo.Link(r.Context(), &link); err != nil {
+			logger.ErrorContext(r.Context(), "cannot link the student", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot link the student")
+
+			return
+		}
+
+		myMw.RecordChange(r.Context(), "guardian_link", fmt.Sprintf("%d:%d", guardian.ID, student.ID), nil, link)
+
+		logger.InfoContext(
+			r.Context(),
+			"student has been linked to the guardian",
+			slog.Uint64("guardian_id", guardianID),
+			slog.Uint64("student_id", studentID),
+		)
+
+		respond.OK(w, http.StatusOK)
+	}
+}
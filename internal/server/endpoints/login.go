@@ -1,22 +1,28 @@
 package endpoints
 
 import (
-	"encoding/json"
 	"errors"
-	"io"
 	"log/slog"
 	"net/http"
 
+	"github.com/cyberbrain-dev/na-meste-api/internal/metrics"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
 	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
 	"github.com/cyberbrain-dev/na-meste-api/pkg/authentication"
-	"github.com/cyberbrain-dev/na-meste-api/pkg/errfmt"
 	"github.com/cyberbrain-dev/na-meste-api/pkg/hashing"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/ldapauth"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-playground/validator/v10"
 )
 
 // Provides an endpoint for logging in the application and getting the JWT
-func Login(logger *slog.Logger, repo abstractions.UsersRepo) http.HandlerFunc {
+// using either the local password or the college's LDAP directory
+func Login(
+	logger *slog.Logger,
+	repo abstractions.UsersRepo,
+	backends map[uint]*ldapauth.Authenticator,
+	m *metrics.Metrics,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// name of the endpoint
 		ep := "endpoinds.Login"
@@ -25,17 +31,8 @@ func Login(logger *slog.Logger, repo abstractions.UsersRepo) http.HandlerFunc {
 		type response struct {
 			Status string `json:"status"`
 			Token  string `json:"jwt,omitempty"`
-			Error  string `json:"error,omitempty"`
 		}
 
-		// decoder of the body's json
-		decoder := json.NewDecoder(r.Body)
-		// encodes the response to the response body
-		encoder := json.NewEncoder(w)
-
-		// setting the type of response
-		w.Header().Set("Content-Type", "application/json")
-
 		// editing the logger
 		logger := logger.With(
 			slog.String("ep", ep),
@@ -46,111 +43,138 @@ func Login(logger *slog.Logger, repo abstractions.UsersRepo) http.HandlerFunc {
 		var req struct {
 			Email    string `json:"email" validate:"required,email"`
 			Password string `json:"password" validate:"required"`
-		}
-
-		// decoding the request's body
-		err := decoder.Decode(&req)
-		// if the body's empty
-		if errors.Is(err, io.EOF) {
-			logger.Error("request body is empty")
 
-			w.WriteHeader(http.StatusBadRequest)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Request body is empty",
-			})
-
-			return
+			// needed only for the first login of a directory user
+			CollegeID uint `json:"college_id"`
 		}
-		// if another error occurs
-		if err != nil {
-			logger.Error("cannot decode the request body")
-
-			w.WriteHeader(http.StatusBadRequest)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Cannot decode the request body",
-			})
 
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
 			return
 		}
 
-		// logging...
-		logger.Info(
-			"request body decoded",
-			slog.Any("request", req),
-		)
-
-		// validating the request
-		if err := vld.Struct(&req); err != nil {
-			validateErr := err.(validator.ValidationErrors)
-
-			logger.Error("invalid request", slog.Any("err", err))
-
-			w.WriteHeader(http.StatusBadRequest)
+		user, err := repo.Get(r.Context(), req.Email)
+		// if smth goes wrong
+		if err != nil && !errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "cannot get the user", slog.Any("err", err))
 
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  errfmt.ValidationErrorsToString(validateErr),
-			})
+			respond.Failure(w, r, err, "Failed to log in, try later again")
 
 			return
 		}
 
-		user, err := repo.Get(req.Email)
-		// if the user ain't exist
-		if err != nil {
-			logger.Error("user with this email does not exist")
-
-			w.WriteHeader(http.StatusNotFound)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "User with this email does not exist",
-			})
-
-			return
+		// the college decides which backend checks the password
+		collegeID := req.CollegeID
+		if user != nil {
+			collegeID = user.CollegeID
 		}
 
-		// checking the password
-		reqPasswordHash := hashing.HashSHA256(req.Password)
-		// if the password is incorrect
-		if reqPasswordHash != user.PasswordHash {
-			logger.Error("password is incorrect")
-
-			w.WriteHeader(http.StatusUnauthorized)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Password is incorrect",
-			})
-
-			return
+		if backend, ok := backends[collegeID]; ok {
+			// checking the password against the college's directory
+			identity, err := backend.Authenticate(req.Email, req.Password)
+			if errors.Is(err, ldapauth.ErrInvalidCredentials) {
+				logger.ErrorContext(r.Context(), "directory rejected the credentials")
+
+				m.LoginFailed(metrics.LoginLDAP, respond.CodeInvalidCredentials)
+				respond.Error(w, r, http.StatusUnauthorized, respond.CodeInvalidCredentials, "Email or password is incorrect")
+
+				return
+			}
+			if errors.Is(err, ldapauth.ErrNoRole) {
+				logger.ErrorContext(r.Context(), "no role is mapped to the user's groups")
+
+				m.LoginFailed(metrics.LoginLDAP, respond.CodeLoginNotAllowed)
+				respond.Error(w, r, http.StatusForbidden, respond.CodeLoginNotAllowed, "User is not allowed to use the application")
+
+				return
+			}
+			if err != nil {
+				logger.ErrorContext(r.Context(), "directory is unavailable", slog.Any("err", err))
+
+				m.LoginFailed(metrics.LoginLDAP, respond.CodeSSOUnavailable)
+				respond.Error(w, r, http.StatusBadGateway, respond.CodeSSOUnavailable, "Failed to log in, try later again")
+
+				return
+			}
+
+			if user == nil {
+				// creating the local record on the first login
+				username := identity.Name
+				if username == "" {
+					username = req.Email
+				}
+
+				user = &models.User{
+					Username:  username,
+					Email:     req.Email,
+					Role:      identity.Role,
+					CollegeID: collegeID,
+				}
+
+				if err := repo.Create(r.Context(), user); err != nil {
+					logger.ErrorContext(r.Context(), "cannot create the user", slog.Any("err", err))
+
+					respond.Failure(w, r, err, "Failed to log in, try later again")
+
+					return
+				}
+
+				logger.InfoContext(
+					r.Context(),
+					"user has been created from the directory",
+					slog.Any("user_id", user.ID),
+					slog.String("role", user.Role),
+				)
+			} else if user.Role != identity.Role {
+				// the directory groups are the source of truth for the role
+				if err := repo.SetRole(r.Context(), user.ID, identity.Role); err != nil {
+					logger.ErrorContext(r.Context(), "cannot update the role", slog.Any("err", err))
+
+					respond.Failure(w, r, err, "Failed to log in, try later again")
+
+					return
+				}
+
+				user.Role = identity.Role
+			}
+		} else {
+			// if the user ain't exist
+			if user == nil {
+				logger.ErrorContext(r.Context(), "user with this email does not exist")
+
+				m.LoginFailed(metrics.LoginPassword, respond.CodeNotFound)
+				respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "User with this email does not exist")
+
+				return
+			}
+
+			// checking the password
+			reqPasswordHash := hashing.HashSHA256(req.Password)
+			// if the password is incorrect
+			if reqPasswordHash != user.PasswordHash {
+				logger.ErrorContext(r.Context(), "password is incorrect")
+
+				m.LoginFailed(metrics.LoginPassword, respond.CodeInvalidCredentials)
+				respond.Error(w, r, http.StatusUnauthorized, respond.CodeInvalidCredentials, "Password is incorrect")
+
+				return
+			}
 		}
 
 		// if everything is fine, generating a JWT for this user
-		token, err := authentication.GenerateJWT(user.ID, user.Role)
+		token, err := authentication.GenerateJWT(user.ID, user.Role, user.CollegeID)
 		// if smth goes wrong
 		if err != nil {
-			logger.Error("failed to generate the JWT", slog.Any("err", err))
-
-			w.WriteHeader(http.StatusInternalServerError)
+			logger.ErrorContext(r.Context(), "failed to generate the JWT", slog.Any("err", err))
 
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Failed to log in, try later again",
-			})
+			respond.Failure(w, r, err, "Failed to log in, try later again")
 
 			return
 		}
 
-		logger.Info("successfully logged in", slog.Any("user_id", user.ID))
-
-		w.WriteHeader(http.StatusOK)
+		logger.InfoContext(r.Context(), "successfully logged in", slog.Any("user_id", user.ID))
 
-		encoder.Encode(response{
+		respond.JSON(w, http.StatusOK, response{
 			Status: "OK",
 			Token:  token,
 		})
@@ -0,0 +1,210 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for a teacher marking a student at their own lesson
+// or changing the status of the student's attendance
+func MarkAttendance(
+	logger *slog.Logger,
+	repo abstractions.AttendancesRepo,
+	lessons abstractions.LessonsRepo,
+	tx abstractions.Transactor,
+	events abstractions.EventPublisher,
+	clock func() time.Time,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.MarkAttendance"
+
+		// a struct for server's response
+		type response struct {
+			Status       string `json:"status"`
+			AttendanceID uint   `json:"attendance_id,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the teacher
+		caller, ok := principal.FromContext(r.Context())
+		if !ok {
+			logger.ErrorContext(r.Context(), "no principal in the context")
+
+			respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
+
+			return
+		}
+
+		// getting the lesson and the student from the route
+		lessonID, errL := strconv.ParseUint(chi.URLParam(r, "lesson_id"), 10, 64)
+		studentID, errS := strconv.ParseUint(chi.URLParam(r, "student_id"), 10, 64)
+		if errL != nil || errS != nil {
+			logger.ErrorContext(r.Context(), "invalid lesson or student id")
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid lesson or student id")
+
+			return
+		}
+
+		// client's request for marking the attendance
+		var req struct {
+			Status      string `json:"status" validate:"required,oneof=on_time late absent"`
+			MinutesLate int    `json:"minutes_late" validate:"required_if=Status late,gte=0"`
+			Reason      string `json:"reason" validate:"required,max=500"`
+		}
+
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
+			return
+		}
+
+		// only late arrivals have minutes late
+		if req.Status != models.AttendanceStatusLate {
+			req.MinutesLate = 0
+		}
+
+		lesson, err := lessons.Get(r.Context(), uint(lessonID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "lesson does not exist", slog.Uint64("lesson_id", lessonID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Lesson does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the lesson", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot mark the attendance")
+
+			return
+		}
+
+		// teachers can correct only their own lessons
+		if lesson.TeacherID != caller.UserID {
+			logger.ErrorContext(r.Context(), "lesson belongs to another teacher")
+
+			respond.Error(w, r, http.StatusForbidden, respond.CodeNotOwner, "Lesson belongs to another teacher")
+
+			return
+		}
+
+		// the student must be enrolled in the lesson
+		enrolled := false
+		for _, id := range lesson.StudentIDs {
+			if id == uint(studentID) {
+				enrolled = true
+				break
+			}
+		}
+		if !enrolled {
+			logger.ErrorContext(r.Context(), "student is not enrolled", slog.Uint64("student_id", studentID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotEnrolled, "Student is not enrolled in the lesson")
+
+			return
+		}
+
+		attendance, err := repo.GetByLessonAndStudent(r.Context(), lesson.ID, uint(studentID))
+		if err != nil && !errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "cannot get the attendance", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot mark the attendance")
+
+			return
+		}
+
+		change := models.AttendanceChange{
+			LessonID:  lesson.ID,
+			StudentID: uint(studentID),
+			ChangedBy: caller.UserID,
+			ChangedAt: clock(),
+			NewStatus: req.Status,
+			Reason:    req.Reason,
+		}
+
+		// the state before the change for the audit log
+		var before *models.Attendance
+		if attendance != nil {
+			snapshot := *attendance
+			before = &snapshot
+		}
+
+		if attendance == nil {
+			// marking the student
+			change.Action = models.AttendanceActionMark
+
+			attendance = &models.Attendance{
+				UserID:      uint(studentID),
+				CollegeID:   lesson.CollegeID,
+				Date:        lesson.StartsAt.Add(time.Duration(req.MinutesLate) * time.Minute),
+				LessonID:    &lesson.ID,
+				Status:      req.Status,
+				MinutesLate: req.MinutesLate,
+			}
+
+			err = tx.InTx(r.Context(), func(ctx context.Context) error {
+				if err := repo.Mark(ctx, attendance, &change); err != nil {
+					return err
+				}
+
+				return events.Publish(ctx, attendance.CollegeID, models.EventAttendanceCreated, attendance)
+			})
+		} else {
+			// changing the status of the existing attendance
+			change.Action = models.AttendanceActionChangeStatus
+			change.OldStatus = attendance.Status
+
+			attendance.Status = req.Status
+			attendance.MinutesLate = req.MinutesLate
+
+			err = tx.InTx(r.Context(), func(ctx context.Context) error {
+				if err := repo.ChangeStatus(ctx, attendance.ID, req.Status, req.MinutesLate, &change); err != nil {
+					return err
+				}
+
+				return events.Publish(ctx, attendance.CollegeID, models.EventAttendanceUpdated, attendance)
+			})
+		}
+
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot mark the attendance", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot mark the attendance")
+
+			return
+		}
+
+		myMw.RecordChange(r.Context(), "attendance", attendance.ID, before, attendance)
+
+		logger.InfoContext(
+			r.Context(),
+			"attendance has been corrected",
+			slog.Any("attendance_id", attendance.ID),
+			slog.String("action", change.Action),
+			slog.String("status", req.Status),
+		)
+
+		respond.JSON(w, http.StatusOK, response{
+			Status:       "OK",
+			AttendanceID: attendance.ID,
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler that (re-)writes the absences of an ended lesson of the caller
+func MaterializeAbsences(
+	logger *slog.Logger,
+	lessons abstractions.LessonsRepo,
+	attendances abstractions.AttendancesRepo,
+	tx abstractions.Transactor,
+	events abstractions.EventPublisher,
+	clock func() time.Time,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.MaterializeAbsences"
+
+		// a struct for server's response
+		type response struct {
+			Status   string `json:"status"`
+			Absences int    `json:"absences"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the teacher
+		caller, ok := principal.FromContext(r.Context())
+		if !ok {
+			logger.ErrorContext(r.Context(), "no principal in the context")
+
+			respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
+
+			return
+		}
+
+		// getting the lesson from the route
+		lessonID, err := strconv.ParseUint(chi.URLParam(r, "lesson_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid lesson id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid lesson id")
+
+			return
+		}
+
+		lesson, err := lessons.Get(r.Context(), uint(lessonID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "lesson does not exist", slog.Uint64("lesson_id", lessonID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Lesson does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the lesson", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot materialize the absences")
+
+			return
+		}
+
+		// teachers materialize only their own lessons
+		if lesson.TeacherID != caller.UserID {
+			logger.ErrorContext(r.Context(), "lesson belongs to another teacher")
+
+			respond.Error(w, r, http.StatusForbidden, respond.CodeNotOwner, "Lesson belongs to another teacher")
+
+			return
+		}
+
+		// absences make sense only after the lesson is over
+		if clock().Before(lesson.EndsAt) {
+			logger.ErrorContext(r.Context(), "lesson has not ended yet", slog.Uint64("lesson_id", lessonID))
+
+			respond.Error(w, r, http.StatusConflict, respond.CodeLessonNotEnded, "Lesson has not ended yet")
+
+			return
+		}
+
+		// the subscribers are notified in the transaction of the absences
+		var written int
+		err = tx.InTx(r.Context(), func(ctx context.Context) error {
+			var err error
+			if written, err = attendances.MaterializeAbsences(ctx, lesson.ID); err != nil || written == 0 {
+				return err
+			}
+
+			return events.Publish(ctx, lesson.CollegeID, models.EventAbsenceMaterialized, models.AbsencesMaterialized{
+				LessonID: lesson.ID,
+				Absences: written,
+			})
+		})
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot materialize the absences", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot materialize the absences")
+
+			return
+		}
+
+		myMw.RecordChange(r.Context(), "lesson", lesson.ID, nil, map[string]int{"absences": written})
+
+		logger.InfoContext(
+			r.Context(),
+			"absences have been materialized",
+			slog.Uint64("lesson_id", lessonID),
+			slog.Int("absences", written),
+		)
+
+		respond.JSON(w, http.StatusOK, response{
+			Status:   "OK",
+			Absences: written,
+		})
+	}
+}
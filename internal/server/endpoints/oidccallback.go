@@ -0,0 +1,201 @@
+package endpoints
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/config"
+	"github.com/cyberbrain-dev/na-meste-api/internal/metrics"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/authentication"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/oidc"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler that finishes the single sign-on flow:
+// exchanges the code, maps the identity to a user and issues our JWT
+func OIDCCallback(
+	logger *slog.Logger,
+	repo abstractions.UsersRepo,
+	providers map[uint]*oidc.Provider,
+	states *oidc.StateStore,
+	cfg config.OIDC,
+	m *metrics.Metrics,
+) http.HandlerFunc {
+	// provisioning policies of the colleges
+	policies := make(map[uint]config.OIDCProvider)
+	for _, p := range cfg.Providers {
+		policies[p.CollegeID] = p
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.OIDCCallback"
+
+		// a struct for server's response
+		type response struct {
+			Status string `json:"status"`
+			Token  string `json:"jwt,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		// if the identity provider has returned an error
+		if idpErr := query.Get("error"); idpErr != "" {
+			logger.ErrorContext(r.Context(), "identity provider returned an error", slog.String("error", idpErr))
+
+			m.LoginFailed(metrics.LoginOIDC, respond.CodeSSODenied)
+			respond.Error(w, r, http.StatusUnauthorized, respond.CodeSSODenied, "Identity provider denied the login")
+
+			return
+		}
+
+		// getting the flow started by OIDCLogin
+		var sealed string
+		if cookie, err := r.Cookie(FlowCookie); err == nil {
+			sealed = cookie.Value
+		}
+
+		// the flow is finished once whatever happens next
+		http.SetCookie(w, &http.Cookie{Name: FlowCookie, Path: "/auth/oidc/", MaxAge: -1})
+
+		flow, ok := states.Finish(query.Get("state"), sealed)
+		if !ok {
+			logger.ErrorContext(r.Context(), "unknown or expired state")
+
+			m.LoginFailed(metrics.LoginOIDC, respond.CodeSSOStateExpired)
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeSSOStateExpired, "Login session is unknown or expired")
+
+			return
+		}
+
+		provider, ok := providers[flow.CollegeID]
+		if !ok {
+			logger.ErrorContext(r.Context(), "single sign-on is not configured", slog.Any("college_id", flow.CollegeID))
+
+			m.LoginFailed(metrics.LoginOIDC, respond.CodeSSONotConfigured)
+			respond.Error(w, r, http.StatusNotFound, respond.CodeSSONotConfigured, "Single sign-on is not configured for this college")
+
+			return
+		}
+
+		// exchanging the code and verifying the ID token
+		claims, err := provider.Exchange(r.Context(), query.Get("code"), flow.Verifier, flow.Nonce)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "failed to exchange the code", slog.Any("err", err))
+
+			m.LoginFailed(metrics.LoginOIDC, respond.CodeUnauthorized)
+			respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Failed to verify the identity")
+
+			return
+		}
+
+		// the email is the only thing that links the identity to a user
+		email := strings.TrimSpace(claims.Email)
+		if email == "" || (claims.EmailVerified != nil && !*claims.EmailVerified) {
+			logger.ErrorContext(r.Context(), "identity has no verified email", slog.String("sub", claims.Subject))
+
+			m.LoginFailed(metrics.LoginOIDC, respond.CodeForbidden)
+			respond.Error(w, r, http.StatusForbidden, respond.CodeForbidden, "Identity has no verified email")
+
+			return
+		}
+
+		user, err := repo.Get(r.Context(), email)
+		if err != nil && !errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "cannot get the user", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Failed to log in, try later again")
+
+			return
+		}
+
+		// if the user does not exist yet
+		if user == nil {
+			policy := policies[flow.CollegeID]
+
+			if !policy.AutoProvision {
+				logger.ErrorContext(r.Context(), "user with this email does not exist", slog.String("email", email))
+
+				m.LoginFailed(metrics.LoginOIDC, respond.CodeLoginNotAllowed)
+				respond.Error(w, r, http.StatusForbidden, respond.CodeLoginNotAllowed, "User with this email does not exist")
+
+				return
+			}
+
+			username := claims.Name
+			if username == "" {
+				username = claims.PreferredUsername
+			}
+			if username == "" {
+				username = email
+			}
+
+			// creating a user without a password,
+			// so they can log in only through the identity provider
+			user = &models.User{
+				Username:  username,
+				Email:     email,
+				Role:      policy.DefaultRole,
+				CollegeID: flow.CollegeID,
+			}
+
+			if err := repo.Create(r.Context(), user); err != nil {
+				logger.ErrorContext(r.Context(), "cannot provision the user", slog.Any("err", err))
+
+				respond.Failure(w, r, err, "Failed to log in, try later again")
+
+				return
+			}
+
+			logger.InfoContext(
+				r.Context(),
+				"user has been provisioned",
+				slog.String("email", email),
+				slog.String("role", user.Role),
+			)
+		}
+
+		// the identity provider may vouch only for its own college
+		if user.CollegeID != flow.CollegeID {
+			logger.ErrorContext(
+				r.Context(),
+				"user belongs to another college",
+				slog.Any("user_id", user.ID),
+				slog.Any("college_id", flow.CollegeID),
+			)
+
+			m.LoginFailed(metrics.LoginOIDC, respond.CodeCollegeMismatch)
+			respond.Error(w, r, http.StatusForbidden, respond.CodeCollegeMismatch, "User belongs to another college")
+
+			return
+		}
+
+		// issuing our own JWT
+		token, err := authentication.GenerateJWT(user.ID, user.Role, user.CollegeID)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "failed to generate the JWT", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Failed to log in, try later again")
+
+			return
+		}
+
+		logger.InfoContext(r.Context(), "successfully logged in with single sign-on", slog.Any("user_id", user.ID))
+
+		respond.JSON(w, http.StatusOK, response{
+			Status: "OK",
+			Token:  token,
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package endpoints
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/oidc"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Name of the cookie the started login flow is kept in
+const FlowCookie = "oidc_flow"
+
+// Returns a handler that starts the single sign-on flow
+// and redirects the user to the college's identity provider
+func OIDCLogin(
+	logger *slog.Logger,
+	providers map[uint]*oidc.Provider,
+	states *oidc.StateStore,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.OIDCLogin"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the college from the route
+		collegeID, err := strconv.ParseUint(chi.URLParam(r, "college_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid college id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid college id")
+
+			return
+		}
+
+		// getting the identity provider of the college
+		provider, ok := providers[uint(collegeID)]
+		if !ok {
+			logger.ErrorContext(r.Context(), "single sign-on is not configured", slog.Uint64("college_id", collegeID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeSSONotConfigured, "Single sign-on is not configured for this college")
+
+			return
+		}
+
+		// starting the flow
+		state, flow, sealed := states.Start(uint(collegeID))
+
+		authURL, err := provider.AuthCodeURL(r.Context(), state, flow.Nonce, flow.Verifier)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot build the authorization URL", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadGateway, respond.CodeSSOUnavailable, "Identity provider is unavailable")
+
+			return
+		}
+
+		// the browser keeps the flow until the identity provider sends it back
+		http.SetCookie(w, &http.Cookie{
+			Name:     FlowCookie,
+			Value:    sealed,
+			Path:     "/auth/oidc/",
+			HttpOnly: true,
+			Secure:   strings.HasPrefix(provider.RedirectURL(), "https://"),
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		logger.InfoContext(r.Context(), "redirecting to the identity provider", slog.Uint64("college_id", collegeID))
+
+		http.Redirect(w, r, authURL, http.StatusFound)
+	}
+}
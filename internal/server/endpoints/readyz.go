@@ -0,0 +1,76 @@
+package endpoints
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/health"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Statuses of the readiness report
+const (
+	readyStatusOK           = "OK"
+	readyStatusUnavailable  = "unavailable"
+	readyStatusShuttingDown = "shutting_down"
+)
+
+// Returns a handler telling whether the instance is ready to take the traffic.
+// The report of the checks is returned with 503 as well, since the probes only read the status
+func Readyz(logger *slog.Logger, probe *health.Probe) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.Readyz"
+
+		// a struct for server's response
+		type response struct {
+			Status string `json:"status"`
+			// Results of the checks by their names, OK or unavailable
+			Checks map[string]string `json:"checks,omitempty"`
+		}
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// the instance stops taking the traffic as soon as the shutdown begins
+		if probe.Draining() {
+			respond.JSON(w, http.StatusServiceUnavailable, response{Status: readyStatusShuttingDown})
+			return
+		}
+
+		res := response{
+			Status: readyStatusOK,
+			Checks: make(map[string]string),
+		}
+
+		for _, result := range probe.Check(r.Context()) {
+			if result.Err != nil {
+				logger.ErrorContext(
+					r.Context(),
+					"dependency is not ready",
+					slog.String("check", result.Name),
+					slog.Any("err", result.Err),
+				)
+
+				res.Status = readyStatusUnavailable
+				// the errors are only logged, since the probe is public
+				res.Checks[result.Name] = readyStatusUnavailable
+
+				continue
+			}
+
+			res.Checks[result.Name] = readyStatusOK
+		}
+
+		if res.Status != readyStatusOK {
+			respond.JSON(w, http.StatusServiceUnavailable, res)
+			return
+		}
+
+		respond.JSON(w, http.StatusOK, res)
+	}
+}
@@ -1,42 +1,24 @@
 package endpoints
 
 import (
-	"encoding/json"
 	"errors"
-	"io"
 	"log/slog"
 	"net/http"
 
 	"github.com/cyberbrain-dev/na-meste-api/internal/models"
 	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
-	"github.com/cyberbrain-dev/na-meste-api/pkg/errfmt"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
 	"github.com/cyberbrain-dev/na-meste-api/pkg/hashing"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-playground/validator/v10"
 )
 
-var vld = validator.New()
-
 // Returns a handler for user registration
 func Register(logger *slog.Logger, repo abstractions.UsersRepo) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// name of the endpoint
 		ep := "endpoinds.Register"
 
-		// an anonymous struct for server's response
-		type response struct {
-			Status string `json:"status"`
-			Error  string `json:"error,omitempty"`
-		}
-
-		// decoder of the body's json
-		decoder := json.NewDecoder(r.Body)
-		// encodes the response to the response body
-		encoder := json.NewEncoder(w)
-
-		// setting the type of response
-		w.Header().Set("Content-Type", "application/json")
-
 		// editing the logger
 		logger := logger.With(
 			slog.String("ep", ep),
@@ -53,54 +35,8 @@ func Register(logger *slog.Logger, repo abstractions.UsersRepo) http.HandlerFunc
 			CollegeID uint `json:"college_id" validate:"required"`
 		}
 
-		// decoding the request's body
-		err := decoder.Decode(&req)
-		// if the body's empty
-		if errors.Is(err, io.EOF) {
-			logger.Error("request body is empty")
-
-			w.WriteHeader(http.StatusBadRequest)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Request body is empty",
-			})
-
-			return
-		}
-		// if another error occurs
-		if err != nil {
-			logger.Error("cannot decode the request body")
-
-			w.WriteHeader(http.StatusBadRequest)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Cannot decode the request body",
-			})
-
-			return
-		}
-
-		// logging...
-		logger.Info(
-			"request body decoded",
-			slog.Any("request", req),
-		)
-
-		// validating the request
-		if err := vld.Struct(&req); err != nil {
-			validateErr := err.(validator.ValidationErrors)
-
-			logger.Error("invalid request", slog.Any("err", err))
-
-			w.WriteHeader(http.StatusBadRequest)
-
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  errfmt.ValidationErrorsToString(validateErr),
-			})
-
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
 			return
 		}
 
@@ -116,30 +52,35 @@ func Register(logger *slog.Logger, repo abstractions.UsersRepo) http.HandlerFunc
 
 		// trying to write user to a db
 		// and handling an error if the one occurs
-		if err := repo.Create(&user); err != nil {
-			logger.Error("cannot add user to db", slog.Any("err", err))
+		err := repo.Create(r.Context(), &user)
+		if errors.Is(err, abstractions.ErrDuplicate) {
+			logger.ErrorContext(r.Context(), "user with this email already exists", slog.String("email", user.Email))
 
-			w.WriteHeader(http.StatusBadRequest)
+			respond.Error(w, r, http.StatusConflict, respond.CodeConflict, "User with this email already exists")
 
-			encoder.Encode(response{
-				Status: "Error",
-				Error:  "Cannot add user to db",
-			})
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot add user to db", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot add user to db")
 
 			return
 		}
 
+		// the password hash is not written to the audit log
+		snapshot := user
+		snapshot.PasswordHash = ""
+		myMw.RecordChange(r.Context(), "user", user.ID, nil, snapshot)
+
 		// logging...
-		logger.Info(
+		logger.InfoContext(
+			r.Context(),
 			"user has been successfully added",
 			slog.String("email", user.Email),
 		)
 
 		// OK response
-		w.WriteHeader(http.StatusCreated)
-
-		encoder.Encode(response{
-			Status: "OK",
-		})
+		respond.OK(w, http.StatusCreated)
 	}
 }
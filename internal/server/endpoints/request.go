@@ -0,0 +1,96 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/i18n"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-playground/validator/v10"
+)
+
+// Validator of the requests, the fields are named as in json
+var vld = newValidator()
+
+// Creates the validator naming the fields by their json tags
+// with the messages of its tags in every language
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+
+		return name
+	})
+
+	if err := i18n.RegisterValidator(v); err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// Decodes the json body of the request into req and validates it.
+// If it fails, the problem is written and false is returned
+func decodeRequest(w http.ResponseWriter, r *http.Request, logger *slog.Logger, req any) bool {
+	_, ok := decodeRequestCode(w, r, logger, req)
+	return ok
+}
+
+// Decodes and validates the request like decodeRequest
+// and returns the code of the problem written if it has failed
+func decodeRequestCode(w http.ResponseWriter, r *http.Request, logger *slog.Logger, req any) (string, bool) {
+	// decoding the request's body
+	err := json.NewDecoder(r.Body).Decode(req)
+	// if the body's empty
+	if errors.Is(err, io.EOF) {
+		logger.ErrorContext(r.Context(), "request body is empty")
+
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeEmptyBody, "Request body is empty")
+
+		return respond.CodeEmptyBody, false
+	}
+	// if another error occurs
+	if err != nil {
+		logger.ErrorContext(r.Context(), "cannot decode the request body", slog.Any("err", err))
+
+		respond.Error(w, r, http.StatusBadRequest, respond.CodeMalformedBody, "Cannot decode the request body")
+
+		return respond.CodeMalformedBody, false
+	}
+
+	// logging...
+	logger.InfoContext(
+		r.Context(),
+		"request body decoded",
+		slog.Any("request", req),
+	)
+
+	// validating the request
+	if err := vld.Struct(req); err != nil {
+		logger.ErrorContext(r.Context(), "invalid request", slog.Any("err", err))
+
+		var validateErr validator.ValidationErrors
+		if !errors.As(err, &validateErr) {
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeValidationFailed, "Request is invalid")
+			return respond.CodeValidationFailed, false
+		}
+
+		respond.Validation(w, r, validateErr)
+
+		return respond.CodeValidationFailed, false
+	}
+
+	return "", true
+}
@@ -0,0 +1,131 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for restoring a soft-deleted college, user or attendance
+func RestoreDeleted(
+	logger *slog.Logger,
+	colleges abstractions.CollegesRepo,
+	users abstractions.UsersRepo,
+	attendances abstractions.AttendancesRepo,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.RestoreDeleted"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		kind := chi.URLParam(r, "kind")
+
+		// getting the ID from the route
+		id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid id")
+
+			return
+		}
+
+		caller, ok := principal.FromContext(r.Context())
+		if !ok {
+			logger.ErrorContext(r.Context(), "no principal in the context")
+
+			respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
+
+			return
+		}
+
+		// the deleted records of the admin's college, the others cannot be restored
+		var (
+			restore func(ctx context.Context, id uint) error
+			ids     []uint
+		)
+
+		switch kind {
+		case "colleges":
+			var deleted []*models.College
+			deleted, err = colleges.GetDeleted(r.Context(), caller.CollegeID)
+			for _, c := range deleted {
+				ids = append(ids, c.ID)
+			}
+			restore = colleges.Restore
+		case "users":
+			var deleted []*models.User
+			deleted, err = users.GetDeleted(r.Context(), caller.CollegeID)
+			for _, u := range deleted {
+				ids = append(ids, u.ID)
+			}
+			restore = users.Restore
+		case "attendances":
+			var deleted []*models.Attendance
+			deleted, err = attendances.GetDeleted(r.Context(), caller.CollegeID)
+			for _, a := range deleted {
+				ids = append(ids, a.ID)
+			}
+			restore = attendances.Restore
+		default:
+			logger.ErrorContext(r.Context(), "unknown kind of records", slog.String("kind", kind))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Unknown kind of records")
+
+			return
+		}
+
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the deleted records", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot restore the record")
+
+			return
+		}
+
+		if slices.Contains(ids, uint(id)) {
+			err = restore(r.Context(), uint(id))
+		} else {
+			err = abstractions.ErrNotDeleted
+		}
+
+		// if there's nothing to restore
+		if errors.Is(err, abstractions.ErrNotDeleted) {
+			logger.ErrorContext(r.Context(), "record is not deleted", slog.Uint64("id", id))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Record does not exist or is not deleted")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot restore the record", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot restore the record")
+
+			return
+		}
+
+		myMw.RecordChange(r.Context(), strings.TrimSuffix(kind, "s"), id, nil, map[string]bool{"restored": true})
+
+		logger.InfoContext(r.Context(), "record has been restored", slog.String("kind", kind), slog.Uint64("id", id))
+
+		respond.OK(w, http.StatusOK)
+	}
+}
@@ -0,0 +1,83 @@
+package endpoints
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for putting a dead job back to the queue
+func RetryJob(logger *slog.Logger, repo abstractions.JobsRepo, clock func() time.Time) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.RetryJob"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the job from the route
+		jobID, err := strconv.ParseUint(chi.URLParam(r, "job_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid job id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid job id")
+
+			return
+		}
+
+		job, err := repo.Get(r.Context(), uint(jobID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "job does not exist", slog.Uint64("job_id", jobID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Job does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the job", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot retry the job")
+
+			return
+		}
+
+		// only the dead letters are retried by hand
+		if job.Status != models.JobStatusDead {
+			logger.ErrorContext(r.Context(), "job is not dead", slog.Uint64("job_id", jobID), slog.String("status", job.Status))
+
+			respond.Error(w, r, http.StatusConflict, respond.CodeJobNotDead, "Only dead jobs can be retried")
+
+			return
+		}
+
+		if err := repo.Requeue(r.Context(), job.ID, clock()); err != nil {
+			logger.ErrorContext(r.Context(), "cannot requeue the job", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot retry the job")
+
+			return
+		}
+
+		myMw.RecordChange(
+			r.Context(), "job", job.ID,
+			map[string]any{"status": job.Status, "attempts": job.Attempts},
+			map[string]any{"status": models.JobStatusPending, "attempts": 0},
+		)
+
+		logger.InfoContext(r.Context(), "job has been requeued", slog.Uint64("job_id", jobID))
+
+		respond.OK(w, http.StatusOK)
+	}
+}
@@ -0,0 +1,33 @@
+package endpoints
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+)
+
+// Reports whether the college is the one of the calling admin,
+// otherwise responds that it belongs to another admin
+func ownCollege(w http.ResponseWriter, r *http.Request, logger *slog.Logger, collegeID uint) bool {
+	caller, ok := principal.FromContext(r.Context())
+	if !ok {
+		logger.ErrorContext(r.Context(), "no principal in the context")
+
+		respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
+
+		return false
+	}
+
+	// an admin manages only their own college
+	if caller.CollegeID != collegeID {
+		logger.ErrorContext(r.Context(), "college belongs to another admin", slog.Any("college_id", collegeID))
+
+		respond.Error(w, r, http.StatusForbidden, respond.CodeForbidden, "College belongs to another admin")
+
+		return false
+	}
+
+	return true
+}
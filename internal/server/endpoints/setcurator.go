@@ -0,0 +1,106 @@
+package endpoints
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for setting the curator of a student.
+// A null curator removes the current one
+func SetCurator(logger *slog.Logger, repo abstractions.UsersRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.SetCurator"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the student from the route
+		studentID, err := strconv.ParseUint(chi.URLParam(r, "user_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid user id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid user id")
+
+			return
+		}
+
+		// client's request for setting the curator
+		var req struct {
+			CuratorID *uint `json:"curator_id"`
+		}
+
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
+			return
+		}
+
+		student, err := repo.GetByID(r.Context(), uint(studentID))
+		if err != nil && !errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "cannot get the student", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot set the curator")
+
+			return
+		}
+		if student == nil || student.Role != "student" {
+			logger.ErrorContext(r.Context(), "student does not exist", slog.Uint64("student_id", studentID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Student does not exist")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, student.CollegeID) {
+			return
+		}
+
+		// the curator is a teacher of the student's college
+		if req.CuratorID != nil {
+			curator, err := repo.GetByID(r.Context(), *req.CuratorID)
+			if err != nil && !errors.Is(err, abstractions.ErrNotFound) {
+				logger.ErrorContext(r.Context(), "cannot get the curator", slog.Any("err", err))
+
+				respond.Failure(w, r, err, "Cannot set the curator")
+
+				return
+			}
+			if curator == nil || curator.Role != "teacher" || curator.CollegeID != student.CollegeID {
+				logger.ErrorContext(r.Context(), "curator is not a teacher of the college", slog.Any("curator_id", *req.CuratorID))
+
+				respond.Error(w, r, http.StatusBadRequest, respond.CodeCollegeMismatch, "Curator must be a teacher of the student's college")
+
+				return
+			}
+		}
+
+		if err := repo.SetCurator(r.Context(), student.ID, req.CuratorID); err != nil {
+			logger.ErrorContext(r.Context(), "cannot set the curator", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot set the curator")
+
+			return
+		}
+
+		myMw.RecordChange(
+			r.Context(), "user", student.ID,
+			map[string]any{"curator_id": student.CuratorID},
+			map[string]any{"curator_id": req.CuratorID},
+		)
+
+		logger.InfoContext(r.Context(), "curator has been set", slog.Uint64("student_id", studentID))
+
+		respond.OK(w, http.StatusOK)
+	}
+}
@@ -0,0 +1,116 @@
+package endpoints
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/geo"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for setting the geofence of a college.
+// A request with neither a center nor a polygon removes the geofence
+func SetGeofence(logger *slog.Logger, repo abstractions.CollegesRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.SetGeofence"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the college from the route
+		collegeID, err := strconv.ParseUint(chi.URLParam(r, "college_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid college id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid college id")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, uint(collegeID)) {
+			return
+		}
+
+		type point struct {
+			Latitude  float64 `json:"latitude" validate:"gte=-90,lte=90"`
+			Longitude float64 `json:"longitude" validate:"gte=-180,lte=180"`
+		}
+
+		// client's request for setting the geofence
+		var req struct {
+			Mode    string  `json:"mode" validate:"required,oneof=flag reject"`
+			Center  *point  `json:"center" validate:"excluded_with=Polygon"`
+			Radius  float64 `json:"radius" validate:"required_with=Center,omitempty,gt=0"`
+			Polygon []point `json:"polygon" validate:"omitempty,min=3,dive"`
+		}
+
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
+			return
+		}
+
+		// building the fence
+		var fence *geo.Fence
+		if req.Center != nil {
+			fence = &geo.Fence{
+				Center: &geo.Point{Latitude: req.Center.Latitude, Longitude: req.Center.Longitude},
+				Radius: req.Radius,
+			}
+		} else if len(req.Polygon) > 0 {
+			fence = &geo.Fence{}
+			for _, p := range req.Polygon {
+				fence.Polygon = append(fence.Polygon, geo.Point{Latitude: p.Latitude, Longitude: p.Longitude})
+			}
+		}
+
+		college, err := repo.GetByID(r.Context(), uint(collegeID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "college does not exist", slog.Uint64("college_id", collegeID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "College does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the college", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot set the geofence")
+
+			return
+		}
+
+		// saving the fence
+		if err := repo.SetGeofence(r.Context(), college.ID, fence, req.Mode); err != nil {
+			logger.ErrorContext(r.Context(), "cannot set the geofence", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot set the geofence")
+
+			return
+		}
+
+		myMw.RecordChange(
+			r.Context(), "college", college.ID,
+			map[string]any{"geofence": college.Geofence, "geofence_mode": college.GeofenceMode},
+			map[string]any{"geofence": fence, "geofence_mode": req.Mode},
+		)
+
+		logger.InfoContext(
+			r.Context(),
+			"geofence has been set",
+			slog.Uint64("college_id", collegeID),
+			slog.Bool("removed", fence == nil),
+		)
+
+		respond.OK(w, http.StatusOK)
+	}
+}
@@ -0,0 +1,101 @@
+package endpoints
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for setting the grace periods
+// used for detecting the late arrivals in a college
+func SetGracePeriods(logger *slog.Logger, repo abstractions.CollegesRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.SetGracePeriods"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the college from the route
+		collegeID, err := strconv.ParseUint(chi.URLParam(r, "college_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid college id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid college id")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, uint(collegeID)) {
+			return
+		}
+
+		// client's request for setting the grace periods
+		var req struct {
+			LateGraceMinutes    *uint `json:"late_grace_minutes" validate:"required,lte=240"`
+			EarlyCheckInMinutes *uint `json:"early_check_in_minutes" validate:"required,lte=240"`
+		}
+
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
+			return
+		}
+
+		college, err := repo.GetByID(r.Context(), uint(collegeID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "college does not exist", slog.Uint64("college_id", collegeID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "College does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the college", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot set the grace periods")
+
+			return
+		}
+
+		// saving the grace periods
+		err = repo.SetGracePeriods(r.Context(),
+			college.ID,
+			time.Duration(*req.LateGraceMinutes)*time.Minute,
+			time.Duration(*req.EarlyCheckInMinutes)*time.Minute,
+		)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot set the grace periods", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot set the grace periods")
+
+			return
+		}
+
+		myMw.RecordChange(
+			r.Context(), "college", college.ID,
+			map[string]any{
+				"late_grace_minutes":     college.LateGrace.Minutes(),
+				"early_check_in_minutes": college.EarlyCheckIn.Minutes(),
+			},
+			map[string]any{
+				"late_grace_minutes":     *req.LateGraceMinutes,
+				"early_check_in_minutes": *req.EarlyCheckInMinutes,
+			},
+		)
+
+		logger.InfoContext(r.Context(), "grace periods have been set", slog.Uint64("college_id", collegeID))
+
+		respond.OK(w, http.StatusOK)
+	}
+}
@@ -0,0 +1,74 @@
+package endpoints
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for setting the notification channels of the calling user
+func SetNotificationPreference(logger *slog.Logger, repo abstractions.NotificationsRepo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.SetNotificationPreference"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// client's request for setting the channels
+		var req struct {
+			Email          bool   `json:"email"`
+			Telegram       bool   `json:"telegram"`
+			TelegramChatID string `json:"telegram_chat_id" validate:"required_if=Telegram true,max=100"`
+			Language       string `json:"language" validate:"required,oneof=ru en"`
+		}
+
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
+			return
+		}
+
+		// the preference belongs to the caller
+		caller, _ := principal.FromContext(r.Context())
+
+		before, err := repo.GetPreference(r.Context(), caller.UserID)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the preference", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot set the preference")
+
+			return
+		}
+
+		pref := models.NotificationPreference{
+			UserID:         caller.UserID,
+			Email:          req.Email,
+			Telegram:       req.Telegram,
+			TelegramChatID: req.TelegramChatID,
+			Language:       req.Language,
+		}
+
+		if err := repo.SetPreference(r.Context(), &pref); err != nil {
+			logger.ErrorContext(r.Context(), "cannot set the preference", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot set the preference")
+
+			return
+		}
+
+		myMw.RecordChange(r.Context(), "notification_preference", caller.UserID, before, pref)
+
+		logger.InfoContext(r.Context(), "preference has been set")
+
+		respond.OK(w, http.StatusOK)
+	}
+}
@@ -0,0 +1,193 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/feed"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// How often a comment is sent to keep an idle stream open
+const feedHeartbeat = 25 * time.Second
+
+// How many events are kept for a slow client
+const feedBuffer = 64
+
+// Returns a handler streaming the attendance events as server-sent events.
+// Teachers watch one of their lessons (?lesson_id=),
+// admins watch a lesson or the whole college of theirs (?college_id=).
+// The stream starts with a snapshot of the lesson's attendances
+func StreamAttendances(
+	logger *slog.Logger,
+	f *feed.Feed,
+	lessons abstractions.LessonsRepo,
+	attendances abstractions.AttendancesRepo,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.StreamAttendances"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		caller, _ := principal.FromContext(r.Context())
+		query := r.URL.Query()
+
+		var (
+			lessonID  *uint
+			collegeID uint
+		)
+
+		switch {
+		case query.Get("lesson_id") != "":
+			id, err := strconv.ParseUint(query.Get("lesson_id"), 10, 64)
+			if err != nil {
+				logger.ErrorContext(r.Context(), "invalid lesson id", slog.Any("err", err))
+				respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid lesson id")
+				return
+			}
+
+			lesson, err := lessons.Get(r.Context(), uint(id))
+			if errors.Is(err, abstractions.ErrNotFound) {
+				logger.ErrorContext(r.Context(), "lesson does not exist", slog.Uint64("lesson_id", id))
+				respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Lesson does not exist")
+				return
+			}
+			if err != nil {
+				logger.ErrorContext(r.Context(), "cannot get the lesson", slog.Any("err", err))
+				respond.Failure(w, r, err, "Cannot open the feed")
+				return
+			}
+
+			lessonID = &lesson.ID
+			collegeID = lesson.CollegeID
+
+			// a teacher watches only their own lessons
+			if caller.Role == "teacher" && lesson.TeacherID != caller.UserID {
+				logger.ErrorContext(r.Context(), "lesson belongs to another teacher", slog.Uint64("lesson_id", id))
+				respond.Error(w, r, http.StatusForbidden, respond.CodeNotOwner, "Lesson belongs to another teacher")
+				return
+			}
+
+		case query.Get("college_id") != "" && caller.Role == "admin":
+			id, err := strconv.ParseUint(query.Get("college_id"), 10, 64)
+			if err != nil {
+				logger.ErrorContext(r.Context(), "invalid college id", slog.Any("err", err))
+				respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid college id")
+				return
+			}
+
+			collegeID = uint(id)
+
+		default:
+			logger.ErrorContext(r.Context(), "no lesson or college to watch")
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "lesson_id (or college_id for admins) is required")
+			return
+		}
+
+		// an admin watches only their own college
+		if caller.Role == "admin" && caller.CollegeID != collegeID {
+			logger.ErrorContext(r.Context(), "college belongs to another admin", slog.Any("college_id", collegeID))
+			respond.Error(w, r, http.StatusForbidden, respond.CodeForbidden, "College belongs to another admin")
+			return
+		}
+
+		// subscribing before the snapshot, so nothing is missed in between
+		sub := f.Subscribe(func(e feed.Event) bool {
+			if e.CollegeID != collegeID {
+				return false
+			}
+
+			return lessonID == nil || (e.LessonID != nil && *e.LessonID == *lessonID)
+		}, feedBuffer)
+		defer sub.Close()
+
+		var snapshot any
+		if lessonID != nil {
+			atts, err := attendances.GetByLesson(r.Context(), *lessonID)
+			if err != nil {
+				logger.ErrorContext(r.Context(), "cannot get the attendances", slog.Any("err", err))
+				respond.Failure(w, r, err, "Cannot open the feed")
+				return
+			}
+
+			snapshot = atts
+		}
+
+		// the stream outlives the write timeout of the server
+		rc := http.NewResponseController(w)
+		rc.SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		// writes an event of the stream
+		send := func(event string, data any) error {
+			payload, err := json.Marshal(data)
+			if err != nil {
+				return err
+			}
+
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+				return err
+			}
+
+			return rc.Flush()
+		}
+
+		if lessonID != nil {
+			if err := send("snapshot", snapshot); err != nil {
+				return
+			}
+		}
+
+		logger.InfoContext(
+			r.Context(),
+			"feed has been opened",
+			slog.Any("college_id", collegeID),
+		)
+
+		heartbeat := time.NewTicker(feedHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				logger.InfoContext(r.Context(), "feed has been closed by the client")
+				return
+
+			case e, ok := <-sub.C:
+				// the server is shutting down
+				if !ok {
+					return
+				}
+
+				if err := send(e.Type, e); err != nil {
+					return
+				}
+
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package endpoints
+
+import (
+	"net/http"
+)
+
+// Version of the Swagger UI loaded from the CDN
+const swaggerUIVersion = "5.17.14"
+
+// Page rendering the OpenAPI document served at /openapi.json
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Na meste API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({
+        url: "/openapi.json",
+        dom_id: "#swagger-ui",
+      });
+    };
+  </script>
+</body>
+</html>
+`
+
+// Returns a handler that serves the Swagger UI page of the API
+func SwaggerUI() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// setting the type of response
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(swaggerUIPage))
+	}
+}
@@ -0,0 +1,108 @@
+package endpoints
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for unlinking a student from a guardian
+func UnlinkGuardian(
+	logger *slog.Logger,
+	repo abstractions.GuardiansRepo,
+	users abstractions.UsersRepo,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.UnlinkGuardian"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the guardian and the student from the route
+		guardianID, err := strconv.ParseUint(chi.URLParam(r, "guardian_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid guardian id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid guardian id")
+
+			return
+		}
+
+		studentID, err := strconv.ParseUint(chi.URLParam(r, "student_id"), 10, 64)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "invalid student id", slog.Any("err", err))
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid student id")
+
+			return
+		}
+
+		student, err := users.GetByID(r.Context(), uint(studentID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "student does not exist", slog.Uint64("student_id", studentID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Student does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the student", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot unlink the student")
+
+			return
+		}
+
+		if !ownCollege(w, r, logger, student.CollegeID) {
+			return
+		}
+
+		unlinked, err := repo.Unlink(r.Context(), uint(guardianID), uint(studentID))
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot unlink the student", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot unlink the student")
+
+			return
+		}
+		if !unlinked {
+			logger.ErrorContext(
+				r.Context(),
+				"student is not linked to the guardian",
+				slog.Uint64("guardian_id", guardianID),
+				slog.Uint64("student_id", studentID),
+			)
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotLinked, "Student is not linked to the guardian")
+
+			return
+		}
+
+		myMw.RecordChange(
+			r.Context(), "guardian_link", fmt.Sprintf("%d:%d", guardianID, studentID),
+			models.GuardianLink{GuardianID: uint(guardianID), StudentID: uint(studentID)}, nil,
+		)
+
+		logger.InfoContext(
+			r.Context(),
+			"student has been unlinked from the guardian",
+			slog.Uint64("guardian_id", guardianID),
+			slog.Uint64("student_id", studentID),
+		)
+
+		respond.OK(w, http.StatusOK)
+	}
+}
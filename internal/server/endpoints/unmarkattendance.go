@@ -0,0 +1,144 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a handler for a teacher removing a student's attendance
+// at their own lesson
+func UnmarkAttendance(
+	logger *slog.Logger,
+	repo abstractions.AttendancesRepo,
+	lessons abstractions.LessonsRepo,
+	tx abstractions.Transactor,
+	events abstractions.EventPublisher,
+	clock func() time.Time,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// name of the endpoint
+		ep := "endpoints.UnmarkAttendance"
+
+		// editing the logger
+		logger := logger.With(
+			slog.String("ep", ep),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// getting the teacher
+		caller, ok := principal.FromContext(r.Context())
+		if !ok {
+			logger.ErrorContext(r.Context(), "no principal in the context")
+
+			respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Unauthorized")
+
+			return
+		}
+
+		// getting the lesson and the student from the route
+		lessonID, errL := strconv.ParseUint(chi.URLParam(r, "lesson_id"), 10, 64)
+		studentID, errS := strconv.ParseUint(chi.URLParam(r, "student_id"), 10, 64)
+		if errL != nil || errS != nil {
+			logger.ErrorContext(r.Context(), "invalid lesson or student id")
+
+			respond.Error(w, r, http.StatusBadRequest, respond.CodeInvalidParameter, "Invalid lesson or student id")
+
+			return
+		}
+
+		// client's request for unmarking the attendance
+		var req struct {
+			Reason string `json:"reason" validate:"required,max=500"`
+		}
+
+		// decoding and validating the request
+		if !decodeRequest(w, r, logger, &req) {
+			return
+		}
+
+		lesson, err := lessons.Get(r.Context(), uint(lessonID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "lesson does not exist", slog.Uint64("lesson_id", lessonID))
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Lesson does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the lesson", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot unmark the attendance")
+
+			return
+		}
+
+		// teachers can correct only their own lessons
+		if lesson.TeacherID != caller.UserID {
+			logger.ErrorContext(r.Context(), "lesson belongs to another teacher")
+
+			respond.Error(w, r, http.StatusForbidden, respond.CodeNotOwner, "Lesson belongs to another teacher")
+
+			return
+		}
+
+		attendance, err := repo.GetByLessonAndStudent(r.Context(), lesson.ID, uint(studentID))
+		if errors.Is(err, abstractions.ErrNotFound) {
+			logger.ErrorContext(r.Context(), "attendance does not exist")
+
+			respond.Error(w, r, http.StatusNotFound, respond.CodeNotFound, "Attendance does not exist")
+
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot get the attendance", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot unmark the attendance")
+
+			return
+		}
+
+		change := models.AttendanceChange{
+			LessonID:  lesson.ID,
+			StudentID: uint(studentID),
+			ChangedBy: caller.UserID,
+			ChangedAt: clock(),
+			Action:    models.AttendanceActionUnmark,
+			OldStatus: attendance.Status,
+			Reason:    req.Reason,
+		}
+
+		// the subscribers are notified in the transaction of the change
+		err = tx.InTx(r.Context(), func(ctx context.Context) error {
+			if err := repo.Unmark(ctx, attendance.ID, &change); err != nil {
+				return err
+			}
+
+			return events.Publish(ctx, attendance.CollegeID, models.EventAttendanceDeleted, attendance)
+		})
+		if err != nil {
+			logger.ErrorContext(r.Context(), "cannot unmark the attendance", slog.Any("err", err))
+
+			respond.Failure(w, r, err, "Cannot unmark the attendance")
+
+			return
+		}
+
+		myMw.RecordChange(r.Context(), "attendance", attendance.ID, attendance, nil)
+
+		logger.InfoContext(r.Context(), "attendance has been unmarked", slog.Any("attendance_id", attendance.ID))
+
+		respond.OK(w, http.StatusOK)
+	}
+}
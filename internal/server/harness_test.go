@@ -0,0 +1,291 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/config"
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/memory"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/authentication"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/hashing"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+// Password of every seeded user
+const password = "secret"
+
+// Represents the API served over the in-memory repositories
+type harness struct {
+	srv   *httptest.Server
+	repos server.Repos
+
+	// counter of the seeded records, so their names are unique
+	seq int
+}
+
+// Represents a response of the API
+type response struct {
+	Status int
+	Body   map[string]any
+	// Problem details if the request has failed
+	Problem respond.Problem
+}
+
+// Returns the default configuration with the validation of the requests set
+func testConfig(t *testing.T, validation string) config.Configuration {
+	t.Helper()
+
+	var cfg config.Configuration
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		t.Fatalf("cannot read the default config: %v", err)
+	}
+
+	cfg.HTTPServer.Address = "127.0.0.1:0"
+	cfg.OpenAPI.Validation = validation
+	// the apps of the tests are shut down at once
+	cfg.Health.DrainDelay = 0
+
+	return cfg
+}
+
+// Returns the repositories of a new in-memory store
+func memoryRepos() server.Repos {
+	store := memory.NewStore()
+
+	return server.Repos{
+		Colleges:      memory.NewColleges(store),
+		Users:         memory.NewUsers(store),
+		Attendances:   memory.NewAttendances(store),
+		Lessons:       memory.NewLessons(store),
+		Audit:         memory.NewAudit(store),
+		Webhooks:      memory.NewWebhooks(store),
+		Jobs:          memory.NewJobs(store),
+		Guardians:     memory.NewGuardians(store),
+		Notifications: memory.NewNotifications(store),
+		Transactor:    memory.NewTransactor(store),
+	}
+}
+
+// Serves the API as cmd/na-meste-api builds it without its background workers,
+// the validation of the requests can be switched off
+func newHarness(t *testing.T, validation string) *harness {
+	t.Helper()
+
+	return newHarnessWith(t, testConfig(t, validation))
+}
+
+// Serves the API with the configuration passed
+func newHarnessWith(t *testing.T, cfg config.Configuration) *harness {
+	t.Helper()
+
+	return newHarnessWithRepos(t, cfg, memoryRepos())
+}
+
+// Serves the API over the repositories passed
+func newHarnessWithRepos(t *testing.T, cfg config.Configuration, repos server.Repos) *harness {
+	t.Helper()
+
+	app, err := server.New(server.Options{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Config: cfg,
+		Repos:  repos,
+	})
+	if err != nil {
+		t.Fatalf("cannot create the app: %v", err)
+	}
+
+	srv := httptest.NewServer(app.Handler())
+	// keeping the cookies like a browser does, the single sign-on needs them
+	srv.Client().Jar, _ = cookiejar.New(nil)
+	t.Cleanup(func() {
+		srv.Close()
+		app.Shutdown(context.Background())
+	})
+
+	return &harness{srv: srv, repos: repos}
+}
+
+// Adds a college to the store
+func (h *harness) college(t *testing.T) *models.College {
+	t.Helper()
+
+	h.seq++
+
+	c := &models.College{Name: fmt.Sprintf("College %d", h.seq)}
+	if err := h.repos.Colleges.Create(context.Background(), c); err != nil {
+		t.Fatalf("cannot seed the college: %v", err)
+	}
+
+	return c
+}
+
+// Adds a user with the role to the college
+func (h *harness) user(t *testing.T, role string, collegeID uint) *models.User {
+	t.Helper()
+
+	h.seq++
+
+	u := &models.User{
+		Username:     fmt.Sprintf("%s %d", role, h.seq),
+		Email:        fmt.Sprintf("%s%d@example.com", role, h.seq),
+		PasswordHash: hashing.HashSHA256(password),
+		Role:         role,
+		CollegeID:    collegeID,
+	}
+	if err := h.repos.Users.Create(context.Background(), u); err != nil {
+		t.Fatalf("cannot seed the user: %v", err)
+	}
+
+	return u
+}
+
+// Adds a lesson of the teacher with the students between the moments
+func (h *harness) lesson(t *testing.T, teacher *models.User, start, end time.Time, students ...*models.User) *models.Lesson {
+	t.Helper()
+
+	l := &models.Lesson{
+		CollegeID: teacher.CollegeID,
+		TeacherID: teacher.ID,
+		Title:     "Maths",
+		StartsAt:  start,
+		EndsAt:    end,
+	}
+	for _, s := range students {
+		l.StudentIDs = append(l.StudentIDs, s.ID)
+	}
+
+	if err := h.repos.Lessons.Create(context.Background(), l); err != nil {
+		t.Fatalf("cannot seed the lesson: %v", err)
+	}
+
+	return l
+}
+
+// Mints a JWT of the user
+func token(t *testing.T, u *models.User) string {
+	t.Helper()
+
+	return tokenFor(t, u.ID, u.Role, u.CollegeID)
+}
+
+// Mints a JWT of the user with the role
+func tokenFor(t *testing.T, userID uint, role string, collegeID uint) string {
+	t.Helper()
+
+	tok, err := authentication.GenerateJWT(userID, role, collegeID)
+	if err != nil {
+		t.Fatalf("cannot generate the JWT: %v", err)
+	}
+
+	return "Bearer " + tok
+}
+
+// Sends the request with the Authorization header and the body encoded as json.
+// An empty authorization is not sent
+func (h *harness) do(t *testing.T, method, path, authorization string, body any) response {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("cannot encode the body: %v", err)
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, h.srv.URL+path, reader)
+	if err != nil {
+		t.Fatalf("cannot create the request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := h.srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("cannot read the response: %v", err)
+	}
+
+	res := response{Status: resp.StatusCode}
+
+	if resp.Header.Get("Content-Type") == respond.ContentTypeProblem {
+		if err := json.Unmarshal(data, &res.Problem); err != nil {
+			t.Fatalf("cannot decode the problem %q: %v", data, err)
+		}
+	} else if len(data) > 0 && resp.Header.Get("Content-Type") == "application/json" {
+		if err := json.Unmarshal(data, &res.Body); err != nil {
+			t.Fatalf("cannot decode the response %q: %v", data, err)
+		}
+	}
+
+	return res
+}
+
+// Fails the test if the response has another status
+// or, when the code is not empty, another problem code
+func expect(t *testing.T, res response, status int, code string) {
+	t.Helper()
+
+	if res.Status != status {
+		t.Fatalf("status = %d, want %d (problem %+v)", res.Status, status, res.Problem)
+	}
+	if code != "" && res.Problem.Code != code {
+		t.Fatalf("code = %q, want %q (problem %+v)", res.Problem.Code, code, res.Problem)
+	}
+}
+
+// Returns the number in the body of the response
+func id(t *testing.T, res response, key string) uint {
+	t.Helper()
+
+	v, ok := res.Body[key].(float64)
+	if !ok {
+		t.Fatalf("response has no %s: %+v", key, res.Body)
+	}
+
+	return uint(v)
+}
+
+// Formats the ID for a path
+func itoa(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// Returns the claims of the JWT issued by the login
+func jwtClaims(t *testing.T, res response) *authentication.Claims {
+	t.Helper()
+
+	tok, _ := res.Body["jwt"].(string)
+
+	claims, err := authentication.ParseJWT(tok)
+	if err != nil {
+		t.Fatalf("login has not returned a valid JWT: %v", err)
+	}
+
+	return claims
+}
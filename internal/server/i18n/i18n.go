@@ -0,0 +1,183 @@
+// Contains the translations of the messages of the API
+// and the negotiation of the language of a request
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	ruTranslations "github.com/go-playground/validator/v10/translations/ru"
+)
+
+// Languages of the API
+const (
+	LanguageEnglish = "en"
+	LanguageRussian = "ru"
+)
+
+// Translators of the supported languages, English is the fallback
+var uni = newUniversalTranslator()
+
+// Creates the translators and adds the messages to them
+func newUniversalTranslator() *ut.UniversalTranslator {
+	uni := ut.New(en.New(), en.New(), ru.New())
+
+	ruTrans, _ := uni.GetTranslator(LanguageRussian)
+	for key, text := range messagesRu {
+		if err := ruTrans.Add(key, text, false); err != nil {
+			panic(fmt.Sprintf("cannot add the translation of %q: %v", key, err))
+		}
+	}
+
+	return uni
+}
+
+// Returns the translator of the language or the English one if it isn't supported
+func Translator(lang string) ut.Translator {
+	trans, _ := uni.GetTranslator(lang)
+	return trans
+}
+
+// Registers the messages of the validation tags in every language
+func RegisterValidator(v *validator.Validate) error {
+	enTrans := Translator(LanguageEnglish)
+	ruTrans := Translator(LanguageRussian)
+
+	if err := enTranslations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		return err
+	}
+	if err := ruTranslations.RegisterDefaultTranslations(v, ruTrans); err != nil {
+		return err
+	}
+
+	// the tags missing from the default translations
+	for _, t := range tagsRu {
+		if err := RegisterTag(v, LanguageRussian, t.tag, t.text); err != nil {
+			return err
+		}
+	}
+	for _, t := range tagsEn {
+		if err := RegisterTag(v, LanguageEnglish, t.tag, t.text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Registers the message of a validation tag in the language,
+// {0} is replaced with the field and {1} with the param of the tag.
+// The custom validators must register their tags in every language
+func RegisterTag(v *validator.Validate, lang string, tag string, text string) error {
+	return v.RegisterTranslation(
+		tag,
+		Translator(lang),
+		func(trans ut.Translator) error {
+			return trans.Add(tag, text, true)
+		},
+		func(trans ut.Translator, fe validator.FieldError) string {
+			msg, err := trans.T(tag, fe.Field(), fe.Param())
+			if err != nil {
+				return fe.Error()
+			}
+
+			return msg
+		},
+	)
+}
+
+// Key of the translator in a request context
+type translatorKey struct{}
+
+// Returns a middleware that picks the language of the request
+// by its Accept-Language header, defaultLang is used if none is supported
+func Negotiate(defaultLang string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trans, found := uni.FindTranslator(parseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+			if !found {
+				trans = Translator(defaultLang)
+			}
+
+			w.Header().Set("Content-Language", trans.Locale())
+
+			ctx := context.WithValue(r.Context(), translatorKey{}, trans)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Returns the translator of the request, English if it wasn't negotiated
+func FromContext(ctx context.Context) ut.Translator {
+	if trans, ok := ctx.Value(translatorKey{}).(ut.Translator); ok {
+		return trans
+	}
+
+	return Translator(LanguageEnglish)
+}
+
+// Translates the message to the language of the request,
+// the message is returned as it is if it has no translation
+func Message(ctx context.Context, msg string) string {
+	translated, err := FromContext(ctx).T(msg)
+	if err != nil {
+		return msg
+	}
+
+	return translated
+}
+
+// Returns the languages of the header from the most preferred,
+// the regions are dropped like "ru" in "ru-RU"
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	var langs []weighted
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		lang, _, _ := strings.Cut(tag, "-")
+		langs = append(langs, weighted{lang: strings.ToLower(lang), q: q})
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+
+	result := make([]string, 0, len(langs))
+	for _, l := range langs {
+		result = append(result, l.lang)
+	}
+
+	return result
+}
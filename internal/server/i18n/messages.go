@@ -0,0 +1,141 @@
+package i18n
+
+// Represents the message of a validation tag
+type tagText struct {
+	tag  string
+	text string
+}
+
+// Russian messages of the tags the default translations miss
+var tagsRu = []tagText{
+	{"required_if", "{0} обязательное поле"},
+	{"required_with", "{0} обязательное поле"},
+	{"excluded_with", "{0} не должно быть указано"},
+	{"startswith", "{0} должен начинаться с {1}"},
+}
+
+// English messages of the tags the default translations miss
+var tagsEn = []tagText{
+	{"startswith", "{0} must start with {1}"},
+}
+
+// Russian translations of the messages of the endpoints,
+// the English messages are their keys
+var messagesRu = map[string]string{
+	// requests
+	"Request body is empty":                            "Тело запроса пустое",
+	"Cannot decode the request body":                   "Не удалось разобрать тело запроса",
+	"Request is invalid":                               "Запрос заполнен неверно",
+	"Invalid id":                                       "Неверный идентификатор",
+	"Invalid attendance id":                            "Неверный идентификатор отметки",
+	"Invalid college id":                               "Неверный идентификатор колледжа",
+	"Invalid guardian id":                              "Неверный идентификатор представителя",
+	"Invalid job id":                                   "Неверный идентификатор задачи",
+	"Invalid lesson id":                                "Неверный идентификатор занятия",
+	"Invalid lesson or student id":                     "Неверный идентификатор занятия или студента",
+	"Invalid rule id":                                  "Неверный идентификатор правила",
+	"Invalid student id":                               "Неверный идентификатор студента",
+	"Invalid user id":                                  "Неверный идентификатор пользователя",
+	"Invalid webhook id":                               "Неверный идентификатор вебхука",
+	"Invalid filters":                                  "Неверные фильтры",
+	"Invalid limit":                                    "Неверный лимит",
+	"Invalid status":                                   "Неверный статус",
+	"Invalid from, expected RFC 3339":                  "Неверное начало периода, ожидается RFC 3339",
+	"Invalid to, expected RFC 3339":                    "Неверный конец периода, ожидается RFC 3339",
+	"From must be before to":                           "Начало периода должно быть раньше конца",
+	"Start date must be before end date":               "Дата начала должна быть раньше даты окончания",
+	"Unknown kind of records":                          "Неизвестный вид записей",
+	"lesson_id (or college_id for admins) is required": "Нужно указать lesson_id (или college_id для администраторов)",
+
+	// authentication
+	"Unauthorized":                               "Требуется авторизация",
+	"No token provided":                          "Токен не передан",
+	"Invalid Authorization format":               "Неверный формат заголовка Authorization",
+	"Failed to parse the token":                  "Не удалось разобрать токен",
+	"Insufficient permissions":                   "Недостаточно прав",
+	"Email or password is incorrect":             "Неверная почта или пароль",
+	"Password is incorrect":                      "Неверный пароль",
+	"User with this email does not exist":        "Пользователь с такой почтой не найден",
+	"User is not allowed to use the application": "Пользователю запрещено пользоваться приложением",
+	"Failed to log in, try later again":          "Не удалось войти, попробуйте позже",
+
+	// single sign-on
+	"Single sign-on is not configured for this college": "Единый вход не настроен для этого колледжа",
+	"Identity provider is unavailable":                  "Провайдер входа недоступен",
+	"Identity provider denied the login":                "Провайдер входа отклонил вход",
+	"Login session is unknown or expired":               "Сессия входа неизвестна или истекла",
+	"Failed to verify the identity":                     "Не удалось подтвердить личность",
+	"Identity has no verified email":                    "У учётной записи нет подтверждённой почты",
+	"User belongs to another college":                   "Пользователь относится к другому колледжу",
+
+	// missing records
+	"Attendance does not exist":               "Отметка не найдена",
+	"College does not exist":                  "Колледж не найден",
+	"Guardian does not exist":                 "Представитель не найден",
+	"Job does not exist":                      "Задача не найдена",
+	"Lesson does not exist":                   "Занятие не найдено",
+	"Record does not exist":                   "Запись не найдена",
+	"Record does not exist or is not deleted": "Запись не найдена или не удалена",
+	"College with this name already exists":   "Колледж с таким названием уже существует",
+	"User with this email already exists":     "Пользователь с такой почтой уже существует",
+	"Record already exists":                   "Запись уже существует",
+	"Rule does not exist":                     "Правило не найдено",
+	"Student does not exist":                  "Студент не найден",
+	"Webhook does not exist":                  "Вебхук не найден",
+
+	// rules of the domain
+	"College belongs to another admin":                   "Колледж относится к другому администратору",
+	"Curator must be a teacher of the student's college": "Куратором может быть только преподаватель колледжа студента",
+	"Guardian and student belong to different colleges":  "Представитель и студент относятся к разным колледжам",
+	"Lesson belongs to another teacher":                  "Занятие ведёт другой преподаватель",
+	"Lesson has not ended yet":                           "Занятие ещё не закончилось",
+	"Lesson must belong to your college":                 "Занятие должно относиться к вашему колледжу",
+	"Students must belong to the college of the lesson":  "Студенты должны относиться к колледжу занятия",
+	"Location is outside the college's geofence":         "Местоположение за пределами территории колледжа",
+	"Only dead jobs can be retried":                      "Повторить можно только задачу, исчерпавшую попытки",
+	"Student is not enrolled in the lesson":              "Студент не записан на занятие",
+	"Student is not linked to the guardian":              "Студент не привязан к представителю",
+	"Student is not linked to you":                       "Студент не привязан к вам",
+
+	// targets of the webhooks
+	"Webhook URL must not point to the loopback or a private network": "Адрес вебхука не должен указывать на локальный адрес или внутреннюю сеть",
+
+	// constraints of the storage
+	"Record refers to a missing record or is still referred to": "Запись ссылается на несуществующую запись или на неё ещё ссылаются",
+	"Record violates the constraints":                           "Запись нарушает ограничения",
+
+	// failures of the server
+	"Cannot add college to db":            "Не удалось добавить колледж",
+	"Cannot add lesson to db":             "Не удалось добавить занятие",
+	"Cannot add user to db":               "Не удалось добавить пользователя",
+	"Cannot create the rule":              "Не удалось создать правило",
+	"Cannot create the webhook":           "Не удалось создать вебхук",
+	"Cannot delete the record":            "Не удалось удалить запись",
+	"Cannot delete the rule":              "Не удалось удалить правило",
+	"Cannot delete the webhook":           "Не удалось удалить вебхук",
+	"Cannot get the attendances":          "Не удалось получить отметки",
+	"Cannot get the audit records":        "Не удалось получить журнал аудита",
+	"Cannot get the deleted records":      "Не удалось получить удалённые записи",
+	"Cannot get the deliveries":           "Не удалось получить доставки",
+	"Cannot get the history":              "Не удалось получить историю",
+	"Cannot get the jobs":                 "Не удалось получить задачи",
+	"Cannot get the rules":                "Не удалось получить правила",
+	"Cannot get the students":             "Не удалось получить студентов",
+	"Cannot get the webhooks":             "Не удалось получить вебхуки",
+	"Cannot link the student":             "Не удалось привязать студента",
+	"Cannot mark the attendance":          "Не удалось отметить посещение",
+	"Cannot marshal the OpenAPI document": "Не удалось сформировать документ OpenAPI",
+	"Cannot materialize the absences":     "Не удалось записать пропуски",
+	"Cannot open the feed":                "Не удалось открыть поток событий",
+	"Cannot restore the record":           "Не удалось восстановить запись",
+	"Cannot retry the job":                "Не удалось повторить задачу",
+	"Cannot set the curator":              "Не удалось назначить куратора",
+	"Cannot set the geofence":             "Не удалось задать территорию",
+	"Cannot set the grace periods":        "Не удалось задать допустимые опоздания",
+	"Cannot set the preference":           "Не удалось сохранить настройки",
+	"Cannot unlink the student":           "Не удалось отвязать студента",
+	"Cannot unmark the attendance":        "Не удалось снять отметку",
+	"Failed to create the attendance":     "Не удалось создать отметку",
+	"Request has been cancelled":          "Запрос отменён",
+	"Request has timed out":               "Время ожидания запроса истекло",
+}
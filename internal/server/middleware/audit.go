@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// How long the audit record is written after the response
+const auditTimeout = 5 * time.Second
+
+// Collects the data of an audit record while the request is handled
+type auditScope struct {
+	actor *principal.Principal
+
+	entity   string
+	entityID string
+	before   any
+	after    any
+}
+
+// Key of the audit scope in a request context
+type auditKey struct{}
+
+// Returns a middleware that appends a record to the audit log
+// for every mutating request
+func Audit(logger *slog.Logger, repo abstractions.AuditRepo) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// reading requests are not audited
+			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			mw := "middleware.Audit"
+
+			scope := &auditScope{}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), auditKey{}, scope)))
+
+			// the route pattern is known only after the routing
+			action := r.Method + " " + r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				action = r.Method + " " + rctx.RoutePattern()
+			}
+
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				ip = r.RemoteAddr
+			}
+
+			record := models.AuditRecord{
+				OccurredAt: time.Now(),
+				Action:     action,
+				Entity:     scope.entity,
+				EntityID:   scope.entityID,
+				Before:     snapshot(scope.before),
+				After:      snapshot(scope.after),
+				RequestID:  middleware.GetReqID(r.Context()),
+				IP:         ip,
+				StatusCode: ww.Status(),
+			}
+
+			if scope.actor != nil {
+				record.ActorID = &scope.actor.UserID
+				record.ActorRole = scope.actor.Role
+			}
+
+			// the response has been written, so the record outlives
+			// the request cancelled by the client going away
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), auditTimeout)
+			defer cancel()
+
+			if err := repo.Append(ctx, &record); err != nil {
+				logger.ErrorContext(
+					r.Context(),
+					"failed to append the audit record",
+					slog.String("mw", mw),
+					slog.String("request_id", record.RequestID),
+					slog.Any("err", err),
+				)
+			}
+		})
+	}
+}
+
+// Describes the entity changed by the request for the audit log.
+// The snapshots are marshalled to JSON, nil snapshots are omitted
+func RecordChange(ctx context.Context, entity string, entityID any, before any, after any) {
+	scope, ok := ctx.Value(auditKey{}).(*auditScope)
+	if !ok {
+		return
+	}
+
+	scope.entity = entity
+	scope.entityID = fmt.Sprint(entityID)
+	scope.before = before
+	scope.after = after
+}
+
+// Sets the actor of the audited request
+func setAuditActor(ctx context.Context, p *principal.Principal) {
+	if scope, ok := ctx.Value(auditKey{}).(*auditScope); ok {
+		scope.actor = p
+	}
+}
+
+// Marshals the snapshot, returns nil if there's nothing to marshal
+// including the nil pointers, maps and slices wrapped in the interface
+func snapshot(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return nil
+		}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	return b
+}
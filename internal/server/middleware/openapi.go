@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Returns a middleware that rejects the requests not matching the OpenAPI document.
+// The responses are checked too if validateResponses is set, but a mismatch is only logged.
+// The routes missing from the document are passed as they are
+func ValidateOpenAPI(logger *slog.Logger, doc *openapi3.T, validateResponses bool) (func(http.Handler) http.Handler, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &openapi3filter.Options{
+		// the tokens are checked by CheckRole
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mw := "middleware.ValidateOpenAPI"
+
+			// editing the logger
+			logger := logger.With(
+				slog.String("mw", mw),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				// unknown routes and methods are answered by the router
+				if !errors.Is(err, routers.ErrPathNotFound) && !errors.Is(err, routers.ErrMethodNotAllowed) {
+					logger.ErrorContext(r.Context(), "cannot find the route in the OpenAPI document", slog.Any("err", err))
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			}
+
+			// the body is put back into the request after reading
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				logger.ErrorContext(r.Context(), "request doesn't match the OpenAPI document", slog.Any("err", err))
+
+				respond.Error(w, r, http.StatusBadRequest, respond.CodeValidationFailed, err.Error())
+
+				return
+			}
+
+			// the streams are never buffered
+			if !validateResponses || isStream(route.Operation) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var body bytes.Buffer
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&body)
+
+			next.ServeHTTP(ww, r)
+
+			// nobody reads the responses of the abandoned requests
+			if errors.Is(r.Context().Err(), context.Canceled) {
+				return
+			}
+
+			err = openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: input,
+				Status:                 ww.Status(),
+				Header:                 ww.Header(),
+				Body:                   io.NopCloser(&body),
+				Options:                options,
+			})
+			if err != nil {
+				logger.WarnContext(
+					r.Context(),
+					"response doesn't match the OpenAPI document",
+					slog.Int("status", ww.Status()),
+					slog.Any("err", err),
+				)
+			}
+		})
+	}, nil
+}
+
+// Checks whether the operation responds with server-sent events
+func isStream(op *openapi3.Operation) bool {
+	for _, resp := range op.Responses.Map() {
+		if resp.Value != nil && resp.Value.Content.Get("text/event-stream") != nil {
+			return true
+		}
+	}
+
+	return false
+}
@@ -5,14 +5,17 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
 	"github.com/cyberbrain-dev/na-meste-api/pkg/authentication"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
-// Returns a middleware function that checks the role of the user
-func CheckRole(logger *slog.Logger, requiredRole string, next http.HandlerFunc) http.HandlerFunc {
+// Returns a middleware function that authenticates the caller
+// by the JWT and puts them into the request context as a principal
+func Authenticate(logger *slog.Logger, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		mw := "middleware.CheckRole"
+		mw := "middleware.Authenticate"
 
 		// editing the logger
 		logger := logger.With(
@@ -24,9 +27,9 @@ func CheckRole(logger *slog.Logger, requiredRole string, next http.HandlerFunc)
 		authHeader := r.Header.Get("Authorization")
 		// if smth goes wrong
 		if authHeader == "" {
-			logger.Error("no token provided")
+			logger.ErrorContext(r.Context(), "no token provided")
 
-			http.Error(w, "no token provided", http.StatusUnauthorized)
+			respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "No token provided")
 			return
 		}
 
@@ -34,45 +37,69 @@ func CheckRole(logger *slog.Logger, requiredRole string, next http.HandlerFunc)
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		// if there's no Bearer prefix
 		if tokenString == authHeader {
-			logger.Error("invalid Authorization format")
+			logger.ErrorContext(r.Context(), "invalid Authorization format")
 
-			http.Error(w, "invalid Authorization format", http.StatusUnauthorized)
+			respond.Error(w, r, http.StatusUnauthorized, respond.CodeUnauthorized, "Invalid Authorization format")
 			return
 		}
 
 		claims, err := authentication.ParseJWT(tokenString)
 		if err != nil {
-			logger.Error(
+			logger.ErrorContext(
+				r.Context(),
 				"failed to parse the token",
 				slog.Any("err", err),
 			)
 
-			http.Error(
-				w,
-				"failed to parse the token",
-				http.StatusInternalServerError,
-			)
+			respond.Error(w, r, http.StatusUnauthorized, respond.CodeInvalidToken, "Failed to parse the token")
 			return
 		}
 
+		p := &principal.Principal{
+			UserID:    claims.UserID,
+			Role:      claims.Role,
+			CollegeID: claims.CollegeID,
+			SessionID: claims.ID,
+			Scopes:    claims.Scopes,
+		}
+
+		// the caller is known now, even if they are not allowed
+		setAuditActor(r.Context(), p)
+
+		// moving to next endpoint or middleware
+		// with the principal available to it
+		next(w, r.WithContext(principal.NewContext(r.Context(), p)))
+	}
+}
+
+// Returns a middleware function that checks the role of the user
+func CheckRole(logger *slog.Logger, requiredRole string, next http.HandlerFunc) http.HandlerFunc {
+	return CheckRoles(logger, []string{requiredRole}, next)
+}
+
+// Returns a middleware function that authenticates the user
+// and checks they have one of the roles
+func CheckRoles(logger *slog.Logger, allowedRoles []string, next http.HandlerFunc) http.HandlerFunc {
+	return Authenticate(logger, func(w http.ResponseWriter, r *http.Request) {
+		mw := "middleware.CheckRole"
+
+		p, _ := principal.FromContext(r.Context())
+
 		// checking the role
-		userRole := claims.Role
-		if userRole != requiredRole {
-			logger.Error(
+		if !p.HasRole(allowedRoles...) {
+			logger.ErrorContext(
+				r.Context(),
 				"access is forbidden",
-				slog.Int("user_id", int(claims.UserID)),
+				slog.String("mw", mw),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
 			)
 
-			http.Error(
-				w,
-				"forbidden: insufficient permissions",
-				http.StatusForbidden,
-			)
+			respond.Error(w, r, http.StatusForbidden, respond.CodeForbidden, "Insufficient permissions")
 			return
 		}
 
-		// if everything gors fine,
+		// if everything goes fine,
 		// moving to next endpoint or middleware
 		next(w, r)
-	}
+	})
 }
@@ -0,0 +1,67 @@
+package middleware_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/principal"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/authentication"
+)
+
+func TestAuthenticate(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name    string
+		scopes  []string
+		allowed []string
+		denied  []string
+	}{
+		{"unscoped", nil, []string{"attendances:write", "colleges:admin"}, nil},
+		{"scoped", []string{"attendances:write"}, []string{"attendances:write"}, []string{"colleges:admin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok, err := authentication.GenerateJWT(7, "teacher", 3, tt.scopes...)
+			if err != nil {
+				t.Fatalf("cannot generate the JWT: %v", err)
+			}
+
+			var got *principal.Principal
+			handler := middleware.Authenticate(logger, func(w http.ResponseWriter, r *http.Request) {
+				got, _ = principal.FromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tok)
+			handler(httptest.NewRecorder(), req)
+
+			if got == nil {
+				t.Fatal("no principal in the context")
+			}
+			if got.UserID != 7 || got.Role != "teacher" || got.CollegeID != 3 || got.SessionID == "" {
+				t.Errorf("got the principal %+v, want the claims of the token", got)
+			}
+			if !slices.Equal(got.Scopes, tt.scopes) {
+				t.Errorf("got the scopes %v, want %v", got.Scopes, tt.scopes)
+			}
+
+			for _, scope := range tt.allowed {
+				if !got.HasScope(scope) {
+					t.Errorf("the scope %q is not allowed", scope)
+				}
+			}
+			for _, scope := range tt.denied {
+				if got.HasScope(scope) {
+					t.Errorf("the scope %q is allowed", scope)
+				}
+			}
+		})
+	}
+}
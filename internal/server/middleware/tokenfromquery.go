@@ -0,0 +1,16 @@
+package middleware
+
+import "net/http"
+
+// Returns a middleware function that moves the access_token query param
+// to the Authorization header, for the clients that cannot set headers
+// like the browser's EventSource
+func TokenFromQuery(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,36 @@
+// Contains the OpenAPI document describing the routes of the API
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// The document is kept next to the routes it describes
+//
+//go:embed openapi.yaml
+var spec []byte
+
+func init() {
+	// the schemas are not dumped into the validation errors shown to the clients
+	openapi3.SchemaErrorDetailsDisabled = true
+}
+
+// Loads the embedded document and checks that it is valid
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load the OpenAPI document: %w", err)
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	return doc, nil
+}
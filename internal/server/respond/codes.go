@@ -0,0 +1,46 @@
+package respond
+
+// Machine-readable codes of the problems.
+// The clients rely on them, so they are never renamed
+const (
+	// The request is malformed
+	CodeEmptyBody        = "empty_body"
+	CodeMalformedBody    = "malformed_body"
+	CodeValidationFailed = "validation_failed"
+	CodeInvalidParameter = "invalid_parameter"
+
+	// The caller is unknown or not allowed
+	CodeUnauthorized       = "unauthorized"
+	CodeInvalidToken       = "invalid_token"
+	CodeForbidden          = "forbidden"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeLoginNotAllowed    = "login_not_allowed"
+
+	// The state of the resources doesn't allow the request
+	CodeNotFound        = "not_found"
+	CodeConflict        = "conflict"
+	CodeCollegeMismatch = "college_mismatch"
+	CodeNotOwner        = "not_owner"
+	CodeNotEnrolled     = "not_enrolled"
+	CodeNotLinked       = "not_linked"
+	CodeOutsideGeofence = "outside_geofence"
+	CodeLessonNotEnded  = "lesson_not_ended"
+	CodeJobNotDead      = "job_not_dead"
+
+	// The record breaks the rules of the storage
+	CodeInvalidReference    = "invalid_reference"
+	CodeConstraintViolation = "constraint_violation"
+
+	// Single sign-on has failed
+	CodeSSONotConfigured = "sso_not_configured"
+	CodeSSOStateExpired  = "sso_state_expired"
+	CodeSSODenied        = "sso_denied"
+	CodeSSOUnavailable   = "sso_unavailable"
+
+	// The server has failed
+	CodeInternal = "internal_error"
+
+	// The request has been abandoned by the client or has run out of time
+	CodeRequestCancelled = "request_cancelled"
+	CodeTimeout          = "timeout"
+)
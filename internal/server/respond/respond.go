@@ -0,0 +1,143 @@
+// Contains the helpers writing the responses of the API.
+// The errors are written as RFC 7807 problem details
+package respond
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/i18n"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/errfmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-playground/validator/v10"
+)
+
+// Content type of the problem details
+const ContentTypeProblem = "application/problem+json"
+
+// Status of the requests the client has abandoned, it is not defined by net/http
+const StatusClientClosedRequest = 499
+
+// Prefix of the type URIs of the problems, the code follows it
+const problemTypePrefix = "urn:na-meste:problem:"
+
+// Represents the status of a successful response,
+// the data of the response is embedded next to it
+type Status struct {
+	Status string `json:"status"`
+}
+
+// Status of every successful response
+var StatusOK = Status{Status: "OK"}
+
+// Represents the problem details of a failed request
+type Problem struct {
+	// URI of the problem type, it is derived from the code
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	// Explanation of this occurrence of the problem
+	Detail string `json:"detail,omitempty"`
+	// Path of the request that has failed
+	Instance string `json:"instance,omitempty"`
+
+	// Machine-readable code of the problem, it never changes
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+
+	// Errors of the fields if the request is invalid
+	Errors []errfmt.FieldError `json:"errors,omitempty"`
+}
+
+// Writes the value as json with the status code
+func JSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(v)
+}
+
+// Writes a successful response without data
+func OK(w http.ResponseWriter, status int) {
+	JSON(w, status, StatusOK)
+}
+
+// Writes the problem details of the failed request,
+// the detail is translated to the language of the request
+func Error(w http.ResponseWriter, r *http.Request, status int, code string, detail string) {
+	Write(w, r, Problem{
+		Status: status,
+		Code:   code,
+		Detail: i18n.Message(r.Context(), detail),
+	})
+}
+
+// Writes the failure of the request. The errors of the repositories,
+// the requests the client has abandoned and the ones that have run out of time
+// are told apart from the failures of the server
+func Failure(w http.ResponseWriter, r *http.Request, err error, detail string) {
+	status, code, message := failure(err)
+	if message == "" {
+		message = detail
+	}
+
+	Error(w, r, status, code, message)
+}
+
+// Returns the status and the code of the problem Failure writes for the error
+func FailureCode(err error) (int, string) {
+	status, code, _ := failure(err)
+	return status, code
+}
+
+// Returns the status, the code and the detail of the failure,
+// the detail is empty for the failures of the server
+func failure(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, abstractions.ErrNotFound):
+		return http.StatusNotFound, CodeNotFound, "Record does not exist"
+	case errors.Is(err, abstractions.ErrDuplicate):
+		return http.StatusConflict, CodeConflict, "Record already exists"
+	case errors.Is(err, abstractions.ErrForeignKey):
+		return http.StatusUnprocessableEntity, CodeInvalidReference, "Record refers to a missing record or is still referred to"
+	case errors.Is(err, abstractions.ErrCheckViolation):
+		return http.StatusUnprocessableEntity, CodeConstraintViolation, "Record violates the constraints"
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, CodeRequestCancelled, "Request has been cancelled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, CodeTimeout, "Request has timed out"
+	default:
+		return http.StatusInternalServerError, CodeInternal, ""
+	}
+}
+
+// Writes the errors of the fields of an invalid request
+func Validation(w http.ResponseWriter, r *http.Request, errs validator.ValidationErrors) {
+	Write(w, r, Problem{
+		Status: http.StatusBadRequest,
+		Code:   CodeValidationFailed,
+		Detail: i18n.Message(r.Context(), "Request is invalid"),
+		Errors: errfmt.ValidationErrorsToFields(errs, i18n.FromContext(r.Context())),
+	})
+}
+
+// Fills the missing members of the problem and writes it
+func Write(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Type == "" {
+		p.Type = problemTypePrefix + p.Code
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+
+	p.Instance = r.URL.Path
+	p.RequestID = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(p.Status)
+
+	json.NewEncoder(w).Encode(p)
+}
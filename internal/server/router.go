@@ -0,0 +1,325 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/config"
+	"github.com/cyberbrain-dev/na-meste-api/internal/feed"
+	"github.com/cyberbrain-dev/na-meste-api/internal/health"
+	"github.com/cyberbrain-dev/na-meste-api/internal/metrics"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/endpoints"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/i18n"
+	myMw "github.com/cyberbrain-dev/na-meste-api/internal/server/middleware"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/openapi"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/ldapauth"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/oidc"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Represents everything the endpoints are wired to
+type Dependencies struct {
+	Repos
+
+	// Publisher of the domain events
+	Events abstractions.EventPublisher
+	// Live feed of the attendances
+	Feed *feed.Feed
+
+	// Identity providers and LDAP directories of the colleges
+	OIDCProviders map[uint]*oidc.Provider
+	OIDCStates    *oidc.StateStore
+	LDAPBackends  map[uint]*ldapauth.Authenticator
+
+	// Checks of the dependencies run by the readiness probe
+	Probe *health.Probe
+	// Metrics of the requests and the business events, new ones are created if nil
+	Metrics *metrics.Metrics
+
+	// Source of the current time, time.Now if nil
+	Clock func() time.Time
+}
+
+// Builds the router of the API with all its middlewares and routes
+func NewRouter(logger *slog.Logger, cfg config.Configuration, deps Dependencies) (*chi.Mux, error) {
+	switch cfg.I18n.DefaultLanguage {
+	case i18n.LanguageRussian, i18n.LanguageEnglish:
+	default:
+		return nil, fmt.Errorf("invalid i18n.default_language %q", cfg.I18n.DefaultLanguage)
+	}
+
+	clock := deps.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	probe := deps.Probe
+	if probe == nil {
+		probe = health.NewProbe(cfg.Health.Timeout)
+	}
+
+	m := deps.Metrics
+	if m == nil {
+		var err error
+		if m, err = metrics.New(); err != nil {
+			return nil, fmt.Errorf("cannot set up the metrics: %w", err)
+		}
+	}
+
+	// loading the API contract
+	doc, err := openapi.Load()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load the OpenAPI document: %w", err)
+	}
+
+	// initializing a router
+	router := chi.NewRouter()
+
+	router.Use(middleware.RequestID)
+	router.Use(myMw.Trace())
+	router.Use(myMw.Metrics(m))
+	router.Use(i18n.Negotiate(cfg.I18n.DefaultLanguage))
+	router.Use(middleware.Recoverer)
+	router.Use(myMw.Audit(logger, deps.Audit))
+
+	switch cfg.OpenAPI.Validation {
+	case "off":
+	case "requests", "all":
+		validate, err := myMw.ValidateOpenAPI(logger, doc, cfg.OpenAPI.Validation == "all")
+		if err != nil {
+			return nil, fmt.Errorf("cannot set up the OpenAPI validation: %w", err)
+		}
+
+		router.Use(validate)
+	default:
+		return nil, fmt.Errorf("invalid openapi.validation %q", cfg.OpenAPI.Validation)
+	}
+
+	// ! settin' up the routes
+
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Все на месте!"))
+	})
+
+	// the probes of the orchestrator
+	router.Get("/healthz", endpoints.Healthz())
+	router.Get("/readyz", endpoints.Readyz(logger, probe))
+	router.Get("/version", endpoints.GetVersion())
+	router.Get("/metrics", m.Handler().ServeHTTP)
+
+	router.Get("/openapi.json", endpoints.GetOpenAPI(logger, doc))
+	router.Get("/docs", endpoints.SwaggerUI())
+
+	router.Post("/colleges/", endpoints.CreateCollege(logger, deps.Colleges))
+	router.Put("/colleges/{college_id}/geofence", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.SetGeofence(logger, deps.Colleges),
+	))
+	router.Put("/colleges/{college_id}/grace-periods", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.SetGracePeriods(logger, deps.Colleges),
+	))
+	router.Post("/auth/register", endpoints.Register(logger, deps.Users))
+	router.Post("/auth/login/", endpoints.Login(logger, deps.Users, deps.LDAPBackends, m))
+	router.Get("/auth/oidc/{college_id}/login", endpoints.OIDCLogin(logger, deps.OIDCProviders, deps.OIDCStates))
+	router.Get("/auth/oidc/{college_id}/callback", endpoints.OIDCCallback(
+		logger, deps.Users, deps.OIDCProviders, deps.OIDCStates, cfg.OIDC, m,
+	))
+
+	// registring the attendance creation endpoint and setting a middleware
+	router.Post("/attendances/", myMw.CheckRole(
+		logger,
+		"scanner",
+		endpoints.CreateAttendance(
+			logger, deps.Attendances, deps.Colleges, deps.Lessons, deps.Transactor, deps.Events, m,
+		),
+	))
+
+	// registring the attendance getter endpoint and setting a middleware
+	router.Get("/attendances/", myMw.CheckRole(
+		logger,
+		"teacher",
+		endpoints.GetAttendances(
+			logger, deps.Attendances,
+		),
+	))
+
+	// registring the lesson scheduling endpoint and setting a middleware
+	router.Post("/lessons/", myMw.CheckRole(
+		logger,
+		"teacher",
+		endpoints.CreateLesson(
+			logger, deps.Lessons, deps.Users,
+		),
+	))
+
+	// registring the manual absence materialization and setting a middleware
+	router.Post("/lessons/{lesson_id}/absences", myMw.CheckRole(
+		logger,
+		"teacher",
+		endpoints.MaterializeAbsences(
+			logger, deps.Lessons, deps.Attendances, deps.Transactor, deps.Events, clock,
+		),
+	))
+
+	// registring the teacher's corrections of the attendances
+	router.Put("/lessons/{lesson_id}/attendances/{student_id}", myMw.CheckRole(
+		logger,
+		"teacher",
+		endpoints.MarkAttendance(
+			logger, deps.Attendances, deps.Lessons, deps.Transactor, deps.Events, clock,
+		),
+	))
+	router.Delete("/lessons/{lesson_id}/attendances/{student_id}", myMw.CheckRole(
+		logger,
+		"teacher",
+		endpoints.UnmarkAttendance(
+			logger, deps.Attendances, deps.Lessons, deps.Transactor, deps.Events, clock,
+		),
+	))
+	router.Get("/attendances/{attendance_id}/history", myMw.CheckRole(
+		logger,
+		"teacher",
+		endpoints.GetAttendanceHistory(
+			logger, deps.Attendances, deps.Lessons,
+		),
+	))
+
+	// registring the audit log getter and setting a middleware
+	router.Get("/audit/", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.GetAuditLog(
+			logger, deps.Audit,
+		),
+	))
+
+	// registring the soft deletion and restoring endpoints
+	router.Delete("/colleges/{college_id}", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.DeleteCollege(logger, deps.Colleges),
+	))
+	router.Delete("/users/{user_id}", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.DeleteUser(logger, deps.Users),
+	))
+	router.Delete("/attendances/{attendance_id}", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.DeleteAttendance(logger, deps.Attendances, deps.Transactor, deps.Events),
+	))
+	router.Get("/admin/deleted/{kind}", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.GetDeleted(logger, deps.Colleges, deps.Users, deps.Attendances),
+	))
+	router.Post("/admin/deleted/{kind}/{id}/restore", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.RestoreDeleted(logger, deps.Colleges, deps.Users, deps.Attendances),
+	))
+
+	// registring the webhook subscriptions and their delivery log
+	router.Post("/colleges/{college_id}/webhooks", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.CreateWebhook(logger, deps.Webhooks, deps.Colleges, cfg.Webhooks),
+	))
+	router.Get("/colleges/{college_id}/webhooks", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.GetWebhooks(logger, deps.Webhooks),
+	))
+	router.Delete("/webhooks/{webhook_id}", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.DeleteWebhook(logger, deps.Webhooks),
+	))
+	router.Get("/webhooks/{webhook_id}/deliveries", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.GetWebhookDeliveries(logger, deps.Webhooks),
+	))
+
+	// registring the links between guardians and students
+	router.Put("/guardians/{guardian_id}/students/{student_id}", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.LinkGuardian(logger, deps.Guardians, deps.Users),
+	))
+	router.Delete("/guardians/{guardian_id}/students/{student_id}", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.UnlinkGuardian(logger, deps.Guardians, deps.Users),
+	))
+
+	// registring the guardian's read-only view of their children
+	router.Get("/guardian/students", myMw.CheckRole(
+		logger,
+		"guardian",
+		endpoints.GetGuardianStudents(logger, deps.Guardians),
+	))
+	router.Get("/guardian/students/{student_id}/attendances", myMw.CheckRole(
+		logger,
+		"guardian",
+		endpoints.GetGuardianAttendances(logger, deps.Attendances, deps.Guardians, clock),
+	))
+
+	// registring the notification settings
+	router.Put("/notifications/preference", myMw.CheckRoles(
+		logger,
+		[]string{"teacher", "guardian"},
+		endpoints.SetNotificationPreference(logger, deps.Notifications),
+	))
+	router.Post("/colleges/{college_id}/notification-rules", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.CreateNotificationRule(logger, deps.Notifications, deps.Colleges),
+	))
+	router.Get("/colleges/{college_id}/notification-rules", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.GetNotificationRules(logger, deps.Notifications),
+	))
+	router.Delete("/notification-rules/{rule_id}", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.DeleteNotificationRule(logger, deps.Notifications),
+	))
+	router.Put("/users/{user_id}/curator", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.SetCurator(logger, deps.Users),
+	))
+
+	// registring the live feed of the attendances
+	router.Get("/feed/attendances", myMw.TokenFromQuery(myMw.CheckRoles(
+		logger,
+		[]string{"teacher", "admin"},
+		endpoints.StreamAttendances(logger, deps.Feed, deps.Lessons, deps.Attendances),
+	)))
+
+	// registring the inspection of the background jobs
+	router.Get("/admin/jobs", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.GetJobs(logger, deps.Jobs),
+	))
+	router.Post("/admin/jobs/{job_id}/retry", myMw.CheckRole(
+		logger,
+		"admin",
+		endpoints.RetryJob(logger, deps.Jobs, clock),
+	))
+	// !
+
+	return router, nil
+}
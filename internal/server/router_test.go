@@ -0,0 +1,1220 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/config"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server"
+	"github.com/cyberbrain-dev/na-meste-api/internal/server/respond"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestAuthentication(t *testing.T) {
+	// the tokens are checked before the bodies are read,
+	// so the routes are called without them
+	h := newHarness(t, "off")
+
+	routes := []struct {
+		method string
+		path   string
+		roles  []string
+	}{
+		{http.MethodPut, "/colleges/1/geofence", []string{"admin"}},
+		{http.MethodPut, "/colleges/1/grace-periods", []string{"admin"}},
+		{http.MethodPost, "/attendances/", []string{"scanner"}},
+		{http.MethodGet, "/attendances/", []string{"teacher"}},
+		{http.MethodPost, "/lessons/", []string{"teacher"}},
+		{http.MethodPost, "/lessons/1/absences", []string{"teacher"}},
+		{http.MethodPut, "/lessons/1/attendances/1", []string{"teacher"}},
+		{http.MethodDelete, "/lessons/1/attendances/1", []string{"teacher"}},
+		{http.MethodGet, "/attendances/1/history", []string{"teacher"}},
+		{http.MethodGet, "/audit/", []string{"admin"}},
+		{http.MethodDelete, "/colleges/1", []string{"admin"}},
+		{http.MethodDelete, "/users/1", []string{"admin"}},
+		{http.MethodDelete, "/attendances/1", []string{"admin"}},
+		{http.MethodGet, "/admin/deleted/colleges", []string{"admin"}},
+		{http.MethodPost, "/admin/deleted/colleges/1/restore", []string{"admin"}},
+		{http.MethodPost, "/colleges/1/webhooks", []string{"admin"}},
+		{http.MethodGet, "/colleges/1/webhooks", []string{"admin"}},
+		{http.MethodDelete, "/webhooks/1", []string{"admin"}},
+		{http.MethodGet, "/webhooks/1/deliveries", []string{"admin"}},
+		{http.MethodPut, "/guardians/1/students/2", []string{"admin"}},
+		{http.MethodDelete, "/guardians/1/students/2", []string{"admin"}},
+		{http.MethodGet, "/guardian/students", []string{"guardian"}},
+		{http.MethodGet, "/guardian/students/1/attendances", []string{"guardian"}},
+		{http.MethodPut, "/notifications/preference", []string{"teacher", "guardian"}},
+		{http.MethodPost, "/colleges/1/notification-rules", []string{"admin"}},
+		{http.MethodGet, "/colleges/1/notification-rules", []string{"admin"}},
+		{http.MethodDelete, "/notification-rules/1", []string{"admin"}},
+		{http.MethodPut, "/users/1/curator", []string{"admin"}},
+		{http.MethodGet, "/feed/attendances", []string{"teacher", "admin"}},
+		{http.MethodGet, "/admin/jobs", []string{"admin"}},
+		{http.MethodPost, "/admin/jobs/1/retry", []string{"admin"}},
+	}
+
+	allRoles := []string{"admin", "teacher", "scanner", "student", "guardian"}
+
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			res := h.do(t, route.method, route.path, "", nil)
+			expect(t, res, http.StatusUnauthorized, respond.CodeUnauthorized)
+
+			res = h.do(t, route.method, route.path, "Token abc", nil)
+			expect(t, res, http.StatusUnauthorized, respond.CodeUnauthorized)
+
+			res = h.do(t, route.method, route.path, "Bearer not.a.token", nil)
+			expect(t, res, http.StatusUnauthorized, respond.CodeInvalidToken)
+
+			for _, role := range allRoles {
+				allowed := false
+				for _, r := range route.roles {
+					allowed = allowed || r == role
+				}
+				if allowed {
+					continue
+				}
+
+				res = h.do(t, route.method, route.path, tokenFor(t, 1, role, 1), nil)
+				expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+			}
+		})
+	}
+}
+
+func TestPublicRoutes(t *testing.T) {
+	h := newHarness(t, "requests")
+
+	for _, path := range []string{"/", "/openapi.json", "/docs"} {
+		res := h.do(t, http.MethodGet, path, "", nil)
+		expect(t, res, http.StatusOK, "")
+	}
+
+	// the body is decoded only when it is served as json
+	res := h.do(t, http.MethodGet, "/openapi.json", "", nil)
+	if _, ok := res.Body["openapi"]; !ok {
+		t.Errorf("the OpenAPI document has not been served as json")
+	}
+
+	res = h.do(t, http.MethodGet, "/no-such-route", "", nil)
+	expect(t, res, http.StatusNotFound, "")
+}
+
+func TestCreateCollege(t *testing.T) {
+	h := newHarness(t, "requests")
+
+	res := h.do(t, http.MethodPost, "/colleges/", "", map[string]any{"name": "College"})
+	expect(t, res, http.StatusCreated, "")
+
+	res = h.do(t, http.MethodPost, "/colleges/", "", map[string]any{"name": "College"})
+	expect(t, res, http.StatusConflict, respond.CodeConflict)
+
+	res = h.do(t, http.MethodPost, "/colleges/", "", map[string]any{})
+	expect(t, res, http.StatusBadRequest, "")
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+
+	register := map[string]any{
+		"username":   "Ivan",
+		"email":      "ivan@example.com",
+		"password":   password,
+		"role":       "student",
+		"college_id": college.ID,
+	}
+
+	res := h.do(t, http.MethodPost, "/auth/register", "", register)
+	expect(t, res, http.StatusCreated, "")
+
+	res = h.do(t, http.MethodPost, "/auth/register", "", register)
+	expect(t, res, http.StatusConflict, respond.CodeConflict)
+
+	register["email"] = "not an email"
+	res = h.do(t, http.MethodPost, "/auth/register", "", register)
+	expect(t, res, http.StatusBadRequest, "")
+
+	res = h.do(t, http.MethodPost, "/auth/login/", "", map[string]any{
+		"email":    "ivan@example.com",
+		"password": password,
+	})
+	expect(t, res, http.StatusOK, "")
+
+	jwt, _ := res.Body["jwt"].(string)
+	if jwt == "" {
+		t.Fatalf("login has not returned a JWT: %+v", res.Body)
+	}
+
+	// the issued token is accepted by the protected routes
+	res = h.do(t, http.MethodGet, "/guardian/students", "Bearer "+jwt, nil)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	res = h.do(t, http.MethodPost, "/auth/login/", "", map[string]any{
+		"email":    "ivan@example.com",
+		"password": "wrong",
+	})
+	expect(t, res, http.StatusUnauthorized, respond.CodeInvalidCredentials)
+
+	res = h.do(t, http.MethodPost, "/auth/login/", "", map[string]any{
+		"email":    "nobody@example.com",
+		"password": password,
+	})
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+}
+
+func TestOIDC(t *testing.T) {
+	h := newHarness(t, "requests")
+
+	res := h.do(t, http.MethodGet, "/auth/oidc/1/login", "", nil)
+	expect(t, res, http.StatusNotFound, respond.CodeSSONotConfigured)
+
+	res = h.do(t, http.MethodGet, "/auth/oidc/1/callback?error=access_denied", "", nil)
+	expect(t, res, http.StatusUnauthorized, respond.CodeSSODenied)
+
+	res = h.do(t, http.MethodGet, "/auth/oidc/1/callback?state=unknown&code=abc", "", nil)
+	expect(t, res, http.StatusBadRequest, respond.CodeSSOStateExpired)
+
+	issuer := newFakeIssuer(t)
+
+	cfg := testConfig(t, "requests")
+	cfg.OIDC.Providers = []config.OIDCProvider{{
+		CollegeID:     1,
+		Issuer:        issuer.srv.URL,
+		ClientID:      "na-meste",
+		RedirectURL:   "http://na-meste.example.com/auth/oidc/1/callback",
+		AutoProvision: true,
+		DefaultRole:   "student",
+	}}
+
+	h = newHarnessWith(t, cfg)
+	college := h.college(t)
+	if college.ID != 1 {
+		t.Fatalf("got the college №%d, want the one of the provider", college.ID)
+	}
+
+	issuer.redirectTo(h.srv.URL + "/auth/oidc/1/callback")
+
+	// the login redirects to the issuer, which sends the user back to the callback
+	login := func(email string) response {
+		t.Helper()
+
+		issuer.identify(email, "Ivan Petrov")
+
+		return h.do(t, http.MethodGet, "/auth/oidc/1/login", "", nil)
+	}
+
+	// an unknown user is provisioned on their first login
+	res = login("sso@example.com")
+	expect(t, res, http.StatusOK, "")
+
+	provisioned, err := h.repos.Users.Get(context.Background(), "sso@example.com")
+	if err != nil {
+		t.Fatalf("cannot get the provisioned user: %v", err)
+	}
+	if provisioned.Username != "Ivan Petrov" || provisioned.Role != "student" || provisioned.CollegeID != college.ID {
+		t.Errorf("got the provisioned user %+v, want a student of the college", provisioned)
+	}
+	if claims := jwtClaims(t, res); claims.UserID != provisioned.ID {
+		t.Errorf("got the JWT of the user №%d, want №%d", claims.UserID, provisioned.ID)
+	}
+
+	// a known user logs in as themselves
+	teacher := h.user(t, "teacher", college.ID)
+
+	res = login(teacher.Email)
+	expect(t, res, http.StatusOK, "")
+
+	if claims := jwtClaims(t, res); claims.UserID != teacher.ID || claims.Role != "teacher" {
+		t.Errorf("got the JWT of the %s №%d, want the teacher №%d", claims.Role, claims.UserID, teacher.ID)
+	}
+
+	// the issuer vouches only for its own college
+	stranger := h.user(t, "student", h.college(t).ID)
+
+	res = login(stranger.Email)
+	expect(t, res, http.StatusForbidden, respond.CodeCollegeMismatch)
+
+	// the state is accepted only from the browser the flow has been started in
+	elsewhere := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if req.URL.Path == "/auth/oidc/1/callback" {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}}
+
+	issuer.identify(teacher.Email, "Ivan Petrov")
+
+	resp, err := elsewhere.Get(h.srv.URL + "/auth/oidc/1/login")
+	if err != nil {
+		t.Fatalf("cannot start the login: %v", err)
+	}
+	resp.Body.Close()
+
+	callback := strings.TrimPrefix(resp.Header.Get("Location"), h.srv.URL)
+	if !strings.HasPrefix(callback, "/auth/oidc/1/callback?") {
+		t.Fatalf("got redirected to %q, want the callback", callback)
+	}
+
+	res = h.do(t, http.MethodGet, callback, "", nil)
+	expect(t, res, http.StatusBadRequest, respond.CodeSSOStateExpired)
+
+	// the key set is fetched once for all the logins
+	if fetches := issuer.fetches(); fetches != 1 {
+		t.Errorf("fetched the key set %d times, want 1", fetches)
+	}
+}
+
+func TestLDAPLogin(t *testing.T) {
+	dir := newFakeDirectory(t, "cn=service,dc=example", "service")
+	dir.put(directoryEntry{
+		DN:       "uid=petrov,dc=example",
+		Mail:     "petrov@example.com",
+		Name:     "Ivan Petrov",
+		Password: "directory",
+		Groups:   []string{"cn=teachers,dc=example"},
+	})
+
+	cfg := testConfig(t, "requests")
+	cfg.LDAP.Backends = []config.LDAPBackend{{
+		CollegeID:      1,
+		URL:            dir.url(),
+		BindDN:         "cn=service,dc=example",
+		BindPassword:   "service",
+		BaseDN:         "dc=example",
+		UserFilter:     "(mail=%s)",
+		NameAttribute:  "displayName",
+		GroupAttribute: "memberOf",
+		GroupRoles:     []config.LDAPGroupRole{{Group: "cn=teachers,dc=example", Role: "teacher"}},
+		DefaultRole:    "student",
+		Timeout:        5 * time.Second,
+	}}
+
+	h := newHarnessWith(t, cfg)
+	college := h.college(t)
+	if college.ID != 1 {
+		t.Fatalf("got the college №%d, want the one of the directory", college.ID)
+	}
+
+	// the first login names the college and creates the user
+	res := h.do(t, http.MethodPost, "/auth/login/", "", map[string]any{
+		"email":      "petrov@example.com",
+		"password":   "directory",
+		"college_id": college.ID,
+	})
+	expect(t, res, http.StatusOK, "")
+
+	user, err := h.repos.Users.Get(context.Background(), "petrov@example.com")
+	if err != nil {
+		t.Fatalf("cannot get the created user: %v", err)
+	}
+	if user.Username != "Ivan Petrov" || user.Role != "teacher" || user.CollegeID != college.ID {
+		t.Errorf("got the created user %+v, want a teacher of the college", user)
+	}
+	if claims := jwtClaims(t, res); claims.UserID != user.ID || claims.Role != "teacher" {
+		t.Errorf("got the JWT of the %s №%d, want the teacher №%d", claims.Role, claims.UserID, user.ID)
+	}
+
+	// the groups of the directory decide the role on every login
+	dir.put(directoryEntry{
+		DN:       "uid=petrov,dc=example",
+		Mail:     "petrov@example.com",
+		Name:     "Ivan Petrov",
+		Password: "directory",
+	})
+
+	res = h.do(t, http.MethodPost, "/auth/login/", "", map[string]any{
+		"email":    "petrov@example.com",
+		"password": "directory",
+	})
+	expect(t, res, http.StatusOK, "")
+
+	if claims := jwtClaims(t, res); claims.UserID != user.ID || claims.Role != "student" {
+		t.Errorf("got the JWT of the %s №%d, want the student №%d", claims.Role, claims.UserID, user.ID)
+	}
+
+	res = h.do(t, http.MethodPost, "/auth/login/", "", map[string]any{
+		"email":    "petrov@example.com",
+		"password": "wrong",
+	})
+	expect(t, res, http.StatusUnauthorized, respond.CodeInvalidCredentials)
+}
+
+func TestCollegeSettings(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+	other := h.college(t)
+	admin := token(t, h.user(t, "admin", college.ID))
+
+	geofence := map[string]any{
+		"mode":   "reject",
+		"center": map[string]any{"latitude": 55.75, "longitude": 37.61},
+		"radius": 200,
+	}
+
+	res := h.do(t, http.MethodPut, "/colleges/"+itoa(college.ID)+"/geofence", admin, geofence)
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodPut, "/colleges/999/geofence", tokenFor(t, 1, "admin", 999), geofence)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	// the colleges of the other admins are left alone
+	res = h.do(t, http.MethodPut, "/colleges/"+itoa(other.ID)+"/geofence", admin, geofence)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	grace := map[string]any{"late_grace_minutes": 10, "early_check_in_minutes": 20}
+
+	res = h.do(t, http.MethodPut, "/colleges/"+itoa(college.ID)+"/grace-periods", admin, grace)
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodPut, "/colleges/999/grace-periods", tokenFor(t, 1, "admin", 999), grace)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	// the colleges of the other admins are left alone
+	res = h.do(t, http.MethodPut, "/colleges/"+itoa(other.ID)+"/grace-periods", admin, grace)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	stored, err := h.repos.Colleges.GetByID(context.Background(), college.ID)
+	if err != nil {
+		t.Fatalf("cannot get the college: %v", err)
+	}
+	if stored.GeofenceMode != models.GeofenceModeReject || stored.LateGrace != 10*time.Minute {
+		t.Fatalf("settings are not saved: %+v", stored)
+	}
+
+	untouched, err := h.repos.Colleges.GetByID(context.Background(), other.ID)
+	if err != nil {
+		t.Fatalf("cannot get the college: %v", err)
+	}
+	if untouched.Geofence != nil || untouched.LateGrace == 10*time.Minute {
+		t.Errorf("settings of another college are changed: %+v", untouched)
+	}
+}
+
+func TestAttendances(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+	teacher := h.user(t, "teacher", college.ID)
+	student := h.user(t, "student", college.ID)
+	scanner := token(t, h.user(t, "scanner", college.ID))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	h.lesson(t, teacher, now.Add(-time.Hour), now.Add(time.Hour), student)
+
+	checkIn := map[string]any{
+		"student_id": student.ID,
+		"college_id": college.ID,
+		"date":       now,
+	}
+
+	res := h.do(t, http.MethodPost, "/attendances/", scanner, checkIn)
+	expect(t, res, http.StatusCreated, "")
+	if res.Body["attendance_status"] != models.AttendanceStatusLate {
+		t.Fatalf("attendance_status = %v, want %q", res.Body["attendance_status"], models.AttendanceStatusLate)
+	}
+
+	checkIn["college_id"] = 999
+	res = h.do(t, http.MethodPost, "/attendances/", scanner, checkIn)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	// a check-in without the location is rejected by the geofence in the reject mode
+	res = h.do(t, http.MethodPut, "/colleges/"+itoa(college.ID)+"/geofence", token(t, h.user(t, "admin", college.ID)), map[string]any{
+		"mode":   "reject",
+		"center": map[string]any{"latitude": 55.75, "longitude": 37.61},
+		"radius": 200,
+	})
+	expect(t, res, http.StatusOK, "")
+
+	checkIn["college_id"] = college.ID
+	res = h.do(t, http.MethodPost, "/attendances/", scanner, checkIn)
+	expect(t, res, http.StatusForbidden, respond.CodeOutsideGeofence)
+
+	checkIn["latitude"], checkIn["longitude"] = 59.93, 30.31
+	res = h.do(t, http.MethodPost, "/attendances/", scanner, checkIn)
+	expect(t, res, http.StatusForbidden, respond.CodeOutsideGeofence)
+
+	period := map[string]any{
+		"student_id": student.ID,
+		"start_date": now.Add(-time.Hour),
+		"end_date":   now.Add(time.Hour),
+	}
+
+	res = h.do(t, http.MethodGet, "/attendances/", token(t, teacher), period)
+	expect(t, res, http.StatusOK, "")
+	if atts, _ := res.Body["attendances"].([]any); len(atts) != 1 {
+		t.Fatalf("attendances = %v, want 1", res.Body["attendances"])
+	}
+
+	period["start_date"], period["end_date"] = period["end_date"], period["start_date"]
+	res = h.do(t, http.MethodGet, "/attendances/", token(t, teacher), period)
+	expect(t, res, http.StatusBadRequest, respond.CodeInvalidParameter)
+}
+
+func TestLessons(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+	teacher := h.user(t, "teacher", college.ID)
+	student := h.user(t, "student", college.ID)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	lesson := map[string]any{
+		"college_id":  college.ID,
+		"title":       "Maths",
+		"starts_at":   now.Add(-2 * time.Hour),
+		"ends_at":     now.Add(-time.Hour),
+		"student_ids": []uint{student.ID},
+	}
+
+	res := h.do(t, http.MethodPost, "/lessons/", token(t, teacher), lesson)
+	expect(t, res, http.StatusCreated, "")
+	lessonID := id(t, res, "lesson_id")
+
+	// the lesson is owned by the caller
+	created, err := h.repos.Lessons.Get(context.Background(), lessonID)
+	if err != nil {
+		t.Fatalf("cannot get the lesson: %v", err)
+	}
+	if created.TeacherID != teacher.ID {
+		t.Fatalf("teacher_id = %d, want %d", created.TeacherID, teacher.ID)
+	}
+
+	// neither the lesson nor its students may belong to another college
+	other := h.college(t)
+	lesson["college_id"] = other.ID
+	res = h.do(t, http.MethodPost, "/lessons/", token(t, teacher), lesson)
+	expect(t, res, http.StatusForbidden, respond.CodeCollegeMismatch)
+
+	lesson["college_id"] = college.ID
+	lesson["student_ids"] = []uint{h.user(t, "student", other.ID).ID}
+	res = h.do(t, http.MethodPost, "/lessons/", token(t, teacher), lesson)
+	expect(t, res, http.StatusBadRequest, respond.CodeCollegeMismatch)
+
+	lesson["student_ids"] = []uint{h.user(t, "teacher", college.ID).ID}
+	res = h.do(t, http.MethodPost, "/lessons/", token(t, teacher), lesson)
+	expect(t, res, http.StatusBadRequest, respond.CodeCollegeMismatch)
+
+	lesson["student_ids"] = []uint{999}
+	res = h.do(t, http.MethodPost, "/lessons/", token(t, teacher), lesson)
+	expect(t, res, http.StatusUnprocessableEntity, respond.CodeInvalidReference)
+
+	lesson["ends_at"] = now.Add(-3 * time.Hour)
+	res = h.do(t, http.MethodPost, "/lessons/", token(t, teacher), lesson)
+	expect(t, res, http.StatusBadRequest, respond.CodeValidationFailed)
+
+	res = h.do(t, http.MethodPost, "/lessons/"+itoa(lessonID)+"/absences", token(t, teacher), nil)
+	expect(t, res, http.StatusOK, "")
+	if res.Body["absences"] != float64(1) {
+		t.Fatalf("absences = %v, want 1", res.Body["absences"])
+	}
+
+	running := h.lesson(t, teacher, now.Add(-time.Hour), now.Add(time.Hour), student)
+	res = h.do(t, http.MethodPost, "/lessons/"+itoa(running.ID)+"/absences", token(t, teacher), nil)
+	expect(t, res, http.StatusConflict, respond.CodeLessonNotEnded)
+
+	res = h.do(t, http.MethodPost, "/lessons/999/absences", token(t, teacher), nil)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	// the lessons of the other teachers are not theirs to materialize
+	res = h.do(t, http.MethodPost, "/lessons/"+itoa(lessonID)+"/absences", token(t, h.user(t, "teacher", college.ID)), nil)
+	expect(t, res, http.StatusForbidden, respond.CodeNotOwner)
+}
+
+func TestCorrections(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+	teacher := h.user(t, "teacher", college.ID)
+	stranger := h.user(t, "teacher", college.ID)
+	student := h.user(t, "student", college.ID)
+	outsider := h.user(t, "student", college.ID)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	lesson := h.lesson(t, teacher, now.Add(-time.Hour), now.Add(time.Hour), student)
+
+	path := "/lessons/" + itoa(lesson.ID) + "/attendances/" + itoa(student.ID)
+	mark := map[string]any{"status": "late", "minutes_late": 15, "reason": "Came late"}
+
+	res := h.do(t, http.MethodPut, path, token(t, teacher), mark)
+	expect(t, res, http.StatusOK, "")
+	attendanceID := id(t, res, "attendance_id")
+
+	// the attendance has not existed before the mark, so the audit log has no snapshot of it
+	records, err := h.repos.Audit.List(context.Background(), models.AuditFilter{Entity: "attendance", EntityID: itoa(attendanceID)})
+	if err != nil {
+		t.Fatalf("cannot list the audit log: %v", err)
+	}
+	if len(records) != 1 || records[0].Before != nil || records[0].After == nil {
+		t.Fatalf("audit records = %+v, want one without the state before", records)
+	}
+
+	res = h.do(t, http.MethodPut, path, token(t, stranger), mark)
+	expect(t, res, http.StatusForbidden, respond.CodeNotOwner)
+
+	res = h.do(t, http.MethodPut, "/lessons/"+itoa(lesson.ID)+"/attendances/"+itoa(outsider.ID), token(t, teacher), mark)
+	expect(t, res, http.StatusNotFound, respond.CodeNotEnrolled)
+
+	res = h.do(t, http.MethodPut, "/lessons/999/attendances/"+itoa(student.ID), token(t, teacher), mark)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	history := "/attendances/" + itoa(attendanceID) + "/history"
+
+	res = h.do(t, http.MethodGet, history, token(t, teacher), nil)
+	expect(t, res, http.StatusOK, "")
+	if changes, _ := res.Body["history"].([]any); len(changes) != 1 {
+		t.Fatalf("history = %v, want 1 change", res.Body["history"])
+	}
+
+	res = h.do(t, http.MethodGet, history, token(t, stranger), nil)
+	expect(t, res, http.StatusForbidden, respond.CodeNotOwner)
+
+	res = h.do(t, http.MethodGet, "/attendances/999/history", token(t, teacher), nil)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	// an attendance that has not been corrected has an empty history
+	scanned := &models.Attendance{
+		UserID:    outsider.ID,
+		CollegeID: college.ID,
+		Date:      now,
+		LessonID:  &lesson.ID,
+		Status:    models.AttendanceStatusOnTime,
+	}
+	if err := h.repos.Attendances.Create(context.Background(), scanned); err != nil {
+		t.Fatalf("cannot seed the attendance: %v", err)
+	}
+
+	res = h.do(t, http.MethodGet, "/attendances/"+itoa(scanned.ID)+"/history", token(t, stranger), nil)
+	expect(t, res, http.StatusForbidden, respond.CodeNotOwner)
+
+	res = h.do(t, http.MethodGet, "/attendances/"+itoa(scanned.ID)+"/history", token(t, teacher), nil)
+	expect(t, res, http.StatusOK, "")
+	if changes, ok := res.Body["history"].([]any); !ok || len(changes) != 0 {
+		t.Fatalf("history = %v, want no changes", res.Body["history"])
+	}
+
+	unmark := map[string]any{"reason": "Marked by mistake"}
+
+	res = h.do(t, http.MethodDelete, path, token(t, stranger), unmark)
+	expect(t, res, http.StatusForbidden, respond.CodeNotOwner)
+
+	res = h.do(t, http.MethodDelete, path, token(t, teacher), unmark)
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodDelete, path, token(t, teacher), unmark)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+}
+
+func TestAuditLog(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+	admin := token(t, h.user(t, "admin", college.ID))
+
+	res := h.do(t, http.MethodPost, "/colleges/", "", map[string]any{"name": "Audited"})
+	expect(t, res, http.StatusCreated, "")
+
+	res = h.do(t, http.MethodGet, "/audit/?entity=college", admin, nil)
+	expect(t, res, http.StatusOK, "")
+	if records, _ := res.Body["records"].([]any); len(records) != 1 {
+		t.Fatalf("records = %v, want 1", res.Body["records"])
+	}
+
+	res = h.do(t, http.MethodGet, "/audit/?from=yesterday", admin, nil)
+	expect(t, res, http.StatusBadRequest, "")
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+	admin := token(t, h.user(t, "admin", college.ID))
+	student := h.user(t, "student", college.ID)
+
+	attendance := &models.Attendance{UserID: student.ID, CollegeID: college.ID, Date: time.Now()}
+	if err := h.repos.Attendances.Create(context.Background(), attendance); err != nil {
+		t.Fatalf("cannot seed the attendance: %v", err)
+	}
+
+	for _, record := range []struct {
+		kind string
+		path string
+		id   uint
+	}{
+		{"attendances", "/attendances/", attendance.ID},
+		{"users", "/users/", student.ID},
+		{"colleges", "/colleges/", college.ID},
+	} {
+		t.Run(record.kind, func(t *testing.T) {
+			res := h.do(t, http.MethodDelete, record.path+itoa(record.id), admin, nil)
+			expect(t, res, http.StatusOK, "")
+
+			res = h.do(t, http.MethodDelete, record.path+itoa(record.id), admin, nil)
+			expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+			res = h.do(t, http.MethodGet, "/admin/deleted/"+record.kind, admin, nil)
+			expect(t, res, http.StatusOK, "")
+			if records, _ := res.Body["records"].([]any); len(records) != 1 {
+				t.Fatalf("records = %v, want 1", res.Body["records"])
+			}
+
+			restore := "/admin/deleted/" + record.kind + "/" + itoa(record.id) + "/restore"
+
+			res = h.do(t, http.MethodPost, restore, admin, nil)
+			expect(t, res, http.StatusOK, "")
+
+			res = h.do(t, http.MethodPost, restore, admin, nil)
+			expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+		})
+	}
+
+	res := h.do(t, http.MethodGet, "/admin/deleted/lessons", admin, nil)
+	expect(t, res, http.StatusBadRequest, "")
+
+	// the records of another college are neither deleted, listed nor restored
+	other := h.college(t)
+	otherAdmin := token(t, h.user(t, "admin", other.ID))
+	stranger := h.user(t, "student", other.ID)
+
+	foreign := &models.Attendance{UserID: stranger.ID, CollegeID: other.ID, Date: time.Now()}
+	if err := h.repos.Attendances.Create(context.Background(), foreign); err != nil {
+		t.Fatalf("cannot seed the attendance: %v", err)
+	}
+
+	for _, path := range []string{
+		"/attendances/" + itoa(foreign.ID),
+		"/users/" + itoa(stranger.ID),
+		"/colleges/" + itoa(other.ID),
+	} {
+		res = h.do(t, http.MethodDelete, path, admin, nil)
+		expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+	}
+
+	res = h.do(t, http.MethodDelete, "/users/"+itoa(stranger.ID), otherAdmin, nil)
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodGet, "/admin/deleted/users", admin, nil)
+	expect(t, res, http.StatusOK, "")
+	if records, _ := res.Body["records"].([]any); len(records) != 0 {
+		t.Fatalf("records = %v, want none of another college", res.Body["records"])
+	}
+
+	res = h.do(t, http.MethodPost, "/admin/deleted/users/"+itoa(stranger.ID)+"/restore", admin, nil)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	res = h.do(t, http.MethodPost, "/admin/deleted/users/"+itoa(stranger.ID)+"/restore", otherAdmin, nil)
+	expect(t, res, http.StatusOK, "")
+}
+
+func TestWebhooks(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+	admin := token(t, h.user(t, "admin", college.ID))
+
+	webhook := map[string]any{
+		"url":    "https://example.com/hook",
+		"events": []string{models.EventAttendanceCreated, models.EventAttendanceDeleted},
+	}
+
+	res := h.do(t, http.MethodPost, "/colleges/"+itoa(college.ID)+"/webhooks", admin, map[string]any{
+		"url":    "https://example.com/hook",
+		"events": []string{"excuse.decided"},
+	})
+	expect(t, res, http.StatusBadRequest, "")
+
+	res = h.do(t, http.MethodPost, "/colleges/"+itoa(college.ID)+"/webhooks", admin, webhook)
+	expect(t, res, http.StatusCreated, "")
+	webhookID := id(t, res, "webhook_id")
+	if res.Body["secret"] == "" {
+		t.Fatalf("webhook has no secret: %+v", res.Body)
+	}
+
+	res = h.do(t, http.MethodPost, "/colleges/999/webhooks", tokenFor(t, 1, "admin", 999), webhook)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	// the deliveries are not sent to the internal services
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://10.0.0.5/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+	} {
+		res = h.do(t, http.MethodPost, "/colleges/"+itoa(college.ID)+"/webhooks", admin, map[string]any{
+			"url":    url,
+			"events": []string{models.EventAttendanceCreated},
+		})
+		expect(t, res, http.StatusBadRequest, respond.CodeInvalidParameter)
+	}
+
+	res = h.do(t, http.MethodGet, "/colleges/"+itoa(college.ID)+"/webhooks", admin, nil)
+	expect(t, res, http.StatusOK, "")
+	if webhooks, _ := res.Body["webhooks"].([]any); len(webhooks) != 1 {
+		t.Fatalf("webhooks = %v, want 1", res.Body["webhooks"])
+	}
+
+	// the events are written to the outbox of the subscription
+	if _, err := h.repos.Webhooks.Enqueue(context.Background(), college.ID, models.EventAttendanceCreated, []byte("{}")); err != nil {
+		t.Fatalf("cannot enqueue the delivery: %v", err)
+	}
+
+	res = h.do(t, http.MethodGet, "/webhooks/"+itoa(webhookID)+"/deliveries", admin, nil)
+	expect(t, res, http.StatusOK, "")
+	if deliveries, _ := res.Body["deliveries"].([]any); len(deliveries) != 1 {
+		t.Fatalf("deliveries = %v, want 1", res.Body["deliveries"])
+	}
+
+	res = h.do(t, http.MethodGet, "/webhooks/999/deliveries", admin, nil)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	// the webhooks of another college are neither created, listed nor touched
+	other := h.college(t)
+	otherAdmin := token(t, h.user(t, "admin", other.ID))
+
+	res = h.do(t, http.MethodPost, "/colleges/"+itoa(college.ID)+"/webhooks", otherAdmin, webhook)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	res = h.do(t, http.MethodGet, "/colleges/"+itoa(college.ID)+"/webhooks", otherAdmin, nil)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	res = h.do(t, http.MethodGet, "/webhooks/"+itoa(webhookID)+"/deliveries", otherAdmin, nil)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	res = h.do(t, http.MethodDelete, "/webhooks/"+itoa(webhookID), otherAdmin, nil)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	// deleting an attendance is published as well
+	attendance := &models.Attendance{UserID: h.user(t, "student", college.ID).ID, CollegeID: college.ID, Date: time.Now()}
+	if err := h.repos.Attendances.Create(context.Background(), attendance); err != nil {
+		t.Fatalf("cannot seed the attendance: %v", err)
+	}
+
+	res = h.do(t, http.MethodDelete, "/attendances/"+itoa(attendance.ID), admin, nil)
+	expect(t, res, http.StatusOK, "")
+
+	deliveries, err := h.repos.Webhooks.GetDeliveries(context.Background(), webhookID, 10)
+	if err != nil {
+		t.Fatalf("cannot get the deliveries: %v", err)
+	}
+	if !slices.ContainsFunc(deliveries, func(d *models.WebhookDelivery) bool {
+		return d.Event == models.EventAttendanceDeleted
+	}) {
+		t.Fatalf("deliveries = %+v, want the %s one", deliveries, models.EventAttendanceDeleted)
+	}
+
+	res = h.do(t, http.MethodDelete, "/webhooks/"+itoa(webhookID), admin, nil)
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodDelete, "/webhooks/"+itoa(webhookID), admin, nil)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+}
+
+func TestGuardians(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+	other := h.college(t)
+	admin := token(t, h.user(t, "admin", college.ID))
+	guardian := h.user(t, "guardian", college.ID)
+	student := h.user(t, "student", college.ID)
+	foreigner := h.user(t, "student", other.ID)
+
+	link := "/guardians/" + itoa(guardian.ID) + "/students/" + itoa(student.ID)
+
+	res := h.do(t, http.MethodPut, link, admin, nil)
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodPut, "/guardians/"+itoa(student.ID)+"/students/"+itoa(student.ID), admin, nil)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	res = h.do(t, http.MethodPut, "/guardians/"+itoa(guardian.ID)+"/students/999", admin, nil)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	res = h.do(t, http.MethodPut, "/guardians/"+itoa(guardian.ID)+"/students/"+itoa(foreigner.ID), admin, nil)
+	expect(t, res, http.StatusBadRequest, respond.CodeCollegeMismatch)
+
+	res = h.do(t, http.MethodGet, "/guardian/students", token(t, guardian), nil)
+	expect(t, res, http.StatusOK, "")
+	if students, _ := res.Body["students"].([]any); len(students) != 1 {
+		t.Fatalf("students = %v, want 1", res.Body["students"])
+	}
+
+	res = h.do(t, http.MethodGet, "/guardian/students/"+itoa(student.ID)+"/attendances", token(t, guardian), nil)
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodGet, "/guardian/students/"+itoa(foreigner.ID)+"/attendances", token(t, guardian), nil)
+	expect(t, res, http.StatusNotFound, respond.CodeNotLinked)
+
+	// the links of another college are managed by its own admin
+	otherAdmin := token(t, h.user(t, "admin", other.ID))
+
+	res = h.do(t, http.MethodDelete, link, otherAdmin, nil)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	res = h.do(t, http.MethodPut, "/guardians/"+itoa(guardian.ID)+"/students/"+itoa(h.user(t, "student", college.ID).ID), otherAdmin, nil)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	res = h.do(t, http.MethodDelete, link, admin, nil)
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodDelete, link, admin, nil)
+	expect(t, res, http.StatusNotFound, respond.CodeNotLinked)
+}
+
+func TestNotifications(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+	admin := token(t, h.user(t, "admin", college.ID))
+	teacher := h.user(t, "teacher", college.ID)
+
+	res := h.do(t, http.MethodPut, "/notifications/preference", token(t, teacher), map[string]any{
+		"email":    true,
+		"language": "en",
+	})
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodPut, "/notifications/preference", token(t, teacher), map[string]any{
+		"telegram": true,
+		"language": "ru",
+	})
+	expect(t, res, http.StatusBadRequest, respond.CodeValidationFailed)
+
+	rule := map[string]any{"kind": models.RuleAbsencesInRow, "threshold": 3}
+
+	res = h.do(t, http.MethodPost, "/colleges/"+itoa(college.ID)+"/notification-rules", admin, rule)
+	expect(t, res, http.StatusCreated, "")
+	ruleID := id(t, res, "rule_id")
+
+	res = h.do(t, http.MethodPost, "/colleges/999/notification-rules", tokenFor(t, 1, "admin", 999), rule)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	// the rules of another college are managed by its own admin
+	otherAdmin := token(t, h.user(t, "admin", h.college(t).ID))
+
+	res = h.do(t, http.MethodPost, "/colleges/"+itoa(college.ID)+"/notification-rules", otherAdmin, rule)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	res = h.do(t, http.MethodGet, "/colleges/"+itoa(college.ID)+"/notification-rules", otherAdmin, nil)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	res = h.do(t, http.MethodDelete, "/notification-rules/"+itoa(ruleID), otherAdmin, nil)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	res = h.do(t, http.MethodGet, "/colleges/"+itoa(college.ID)+"/notification-rules", admin, nil)
+	expect(t, res, http.StatusOK, "")
+	if rules, _ := res.Body["rules"].([]any); len(rules) != 1 {
+		t.Fatalf("rules = %v, want 1", res.Body["rules"])
+	}
+
+	res = h.do(t, http.MethodDelete, "/notification-rules/"+itoa(ruleID), admin, nil)
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodDelete, "/notification-rules/"+itoa(ruleID), admin, nil)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+}
+
+func TestSetCurator(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+	admin := token(t, h.user(t, "admin", college.ID))
+	teacher := h.user(t, "teacher", college.ID)
+	foreigner := h.user(t, "teacher", h.college(t).ID)
+	student := h.user(t, "student", college.ID)
+
+	path := "/users/" + itoa(student.ID) + "/curator"
+
+	res := h.do(t, http.MethodPut, path, admin, map[string]any{"curator_id": teacher.ID})
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodPut, path, admin, map[string]any{"curator_id": foreigner.ID})
+	expect(t, res, http.StatusBadRequest, respond.CodeCollegeMismatch)
+
+	res = h.do(t, http.MethodPut, "/users/"+itoa(teacher.ID)+"/curator", admin, map[string]any{"curator_id": teacher.ID})
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	// the students of another college are managed by its own admin
+	res = h.do(t, http.MethodPut, path, token(t, h.user(t, "admin", foreigner.CollegeID)), map[string]any{"curator_id": nil})
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	res = h.do(t, http.MethodPut, path, admin, map[string]any{"curator_id": nil})
+	expect(t, res, http.StatusOK, "")
+}
+
+func TestFeed(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+	teacher := h.user(t, "teacher", college.ID)
+	stranger := h.user(t, "teacher", college.ID)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	lesson := h.lesson(t, teacher, now.Add(-time.Hour), now.Add(time.Hour))
+
+	res := h.do(t, http.MethodGet, "/feed/attendances", token(t, teacher), nil)
+	expect(t, res, http.StatusBadRequest, respond.CodeInvalidParameter)
+
+	res = h.do(t, http.MethodGet, "/feed/attendances?lesson_id=999", token(t, teacher), nil)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	res = h.do(t, http.MethodGet, "/feed/attendances?lesson_id="+itoa(lesson.ID), token(t, stranger), nil)
+	expect(t, res, http.StatusForbidden, respond.CodeNotOwner)
+
+	// an admin watches only the college of their token
+	admin := token(t, h.user(t, "admin", h.college(t).ID))
+	res = h.do(t, http.MethodGet, "/feed/attendances?college_id="+itoa(college.ID), admin, nil)
+	expect(t, res, http.StatusForbidden, respond.CodeForbidden)
+
+	// the browsers pass the token in the query
+	jwt := strings.TrimPrefix(token(t, teacher), "Bearer ")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		h.srv.URL+"/feed/attendances?lesson_id="+itoa(lesson.ID)+"&access_token="+jwt, nil)
+	if err != nil {
+		t.Fatalf("cannot create the request: %v", err)
+	}
+
+	resp, err := h.srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("cannot open the feed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("content type = %q, want text/event-stream", ct)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	h := newHarness(t, "requests")
+	college := h.college(t)
+	admin := token(t, h.user(t, "admin", college.ID))
+
+	ctx := context.Background()
+
+	dead := &models.Job{Kind: "test", Payload: []byte("{}"), MaxAttempts: 1}
+	pending := &models.Job{Kind: "test", Payload: []byte("{}"), MaxAttempts: 1}
+	for _, j := range []*models.Job{dead, pending} {
+		if _, err := h.repos.Jobs.Enqueue(ctx, j); err != nil {
+			t.Fatalf("cannot seed the job: %v", err)
+		}
+	}
+	if err := h.repos.Jobs.Kill(ctx, dead.ID, "failed"); err != nil {
+		t.Fatalf("cannot kill the job: %v", err)
+	}
+
+	res := h.do(t, http.MethodGet, "/admin/jobs", admin, nil)
+	expect(t, res, http.StatusOK, "")
+	if jobs, _ := res.Body["jobs"].([]any); len(jobs) != 1 {
+		t.Fatalf("jobs = %v, want 1 dead job", res.Body["jobs"])
+	}
+
+	res = h.do(t, http.MethodGet, "/admin/jobs?status=lost", admin, nil)
+	expect(t, res, http.StatusBadRequest, "")
+
+	res = h.do(t, http.MethodGet, "/admin/jobs?limit=0", admin, nil)
+	expect(t, res, http.StatusBadRequest, "")
+
+	res = h.do(t, http.MethodPost, "/admin/jobs/"+itoa(dead.ID)+"/retry", admin, nil)
+	expect(t, res, http.StatusOK, "")
+
+	res = h.do(t, http.MethodPost, "/admin/jobs/"+itoa(pending.ID)+"/retry", admin, nil)
+	expect(t, res, http.StatusConflict, respond.CodeJobNotDead)
+
+	res = h.do(t, http.MethodPost, "/admin/jobs/999/retry", admin, nil)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+}
+
+// Runs the transactions of the repositories unless it is down like a database
+type flakyTransactor struct {
+	abstractions.Transactor
+	down atomic.Bool
+}
+
+func (t *flakyTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if t.down.Load() {
+		return errors.New("database is down")
+	}
+
+	return t.Transactor.InTx(ctx, fn)
+}
+
+func TestMetrics(t *testing.T) {
+	repos := memoryRepos()
+	tx := &flakyTransactor{Transactor: repos.Transactor}
+	repos.Transactor = tx
+
+	h := newHarnessWithRepos(t, testConfig(t, "requests"), repos)
+	college := h.college(t)
+	student := h.user(t, "student", college.ID)
+	scanner := h.user(t, "scanner", college.ID)
+
+	res := h.do(t, http.MethodPost, "/auth/login/", "", map[string]any{"email": student.Email, "password": "wrong"})
+	expect(t, res, http.StatusUnauthorized, respond.CodeInvalidCredentials)
+
+	checkIn := map[string]any{
+		"student_id": student.ID,
+		"college_id": college.ID,
+		"date":       time.Now().UTC().Truncate(time.Second),
+	}
+	res = h.do(t, http.MethodPost, "/attendances/", token(t, scanner), checkIn)
+	expect(t, res, http.StatusCreated, "")
+
+	checkIn["college_id"] = 999
+	res = h.do(t, http.MethodPost, "/attendances/", token(t, scanner), checkIn)
+	expect(t, res, http.StatusNotFound, respond.CodeNotFound)
+
+	// every refusal is counted, the invalid requests
+	// and the failed writes included
+	checkIn["college_id"] = college.ID
+	checkIn["latitude"] = 55.75
+	res = h.do(t, http.MethodPost, "/attendances/", token(t, scanner), checkIn)
+	expect(t, res, http.StatusBadRequest, respond.CodeValidationFailed)
+
+	delete(checkIn, "latitude")
+	checkIn["student_id"] = 999
+	res = h.do(t, http.MethodPost, "/attendances/", token(t, scanner), checkIn)
+	expect(t, res, http.StatusUnprocessableEntity, respond.CodeInvalidReference)
+
+	// the failures of the server are not refusals
+	tx.down.Store(true)
+	checkIn["student_id"] = student.ID
+	res = h.do(t, http.MethodPost, "/attendances/", token(t, scanner), checkIn)
+	expect(t, res, http.StatusInternalServerError, respond.CodeInternal)
+	tx.down.Store(false)
+
+	res = h.do(t, http.MethodGet, "/colleges/"+itoa(college.ID)+"/webhooks", tokenFor(t, 1, "admin", college.ID), nil)
+	expect(t, res, http.StatusOK, "")
+
+	h.do(t, http.MethodGet, "/no/such/route", "", nil)
+
+	resp, err := h.srv.Client().Get(h.srv.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("cannot get the metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("cannot read the metrics: %v", err)
+	}
+	body := string(data)
+
+	for _, want := range []string{
+		`na_meste_http_request_duration_seconds_count{method="POST",route="/auth/login",status="401"} 1`,
+		// the requests are labeled by the route patterns instead of the paths
+		`na_meste_http_request_duration_seconds_count{method="GET",route="/colleges/{college_id}/webhooks",status="200"} 1`,
+		`na_meste_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+		`na_meste_failed_logins_total{method="password",reason="invalid_credentials"} 1`,
+		`na_meste_attendances_created_total{college_id="` + itoa(college.ID) + `",device="` + itoa(scanner.ID) + `"} 1`,
+		`na_meste_rejected_check_ins_total{reason="not_found"} 1`,
+		`na_meste_rejected_check_ins_total{reason="validation_failed"} 1`,
+		`na_meste_rejected_check_ins_total{reason="invalid_reference"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics have no %s", want)
+		}
+	}
+
+	if strings.Contains(body, `na_meste_rejected_check_ins_total{reason="`+respond.CodeInternal+`"}`) {
+		t.Error("the failure of the server has been counted as a refused check-in")
+	}
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+
+	// the spans are recorded by the global provider as in cmd/na-meste-api
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	var logs bytes.Buffer
+
+	h := &harness{repos: memoryRepos()}
+	college := h.college(t)
+
+	app, err := server.New(server.Options{
+		Logger: slog.New(slog.NewJSONHandler(&logs, nil)),
+		Config: testConfig(t, "requests"),
+		Repos:  h.repos,
+	})
+	if err != nil {
+		t.Fatalf("cannot create the app: %v", err)
+	}
+
+	h.srv = httptest.NewServer(app.Handler())
+	t.Cleanup(func() {
+		h.srv.Close()
+		app.Shutdown(context.Background())
+	})
+
+	// the trace of the caller is continued
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	req, err := http.NewRequest(http.MethodGet, h.srv.URL+"/colleges/"+itoa(college.ID)+"/webhooks", nil)
+	if err != nil {
+		t.Fatalf("cannot create the request: %v", err)
+	}
+	req.Header.Set("Authorization", tokenFor(t, 1, "admin", college.ID))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	resp, err := h.srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("cannot send the request: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+
+	route, ok := spans["GET /colleges/{college_id}/webhooks"]
+	if !ok {
+		t.Fatalf("no span of the route among %v", spans)
+	}
+	if route.SpanContext().TraceID().String() != traceID {
+		t.Fatalf("trace id = %s, want %s", route.SpanContext().TraceID(), traceID)
+	}
+
+	repo, ok := spans["Webhooks.GetSubscriptions"]
+	if !ok {
+		t.Fatalf("no span of the repository method among %v", spans)
+	}
+	if repo.Parent().SpanID() != route.SpanContext().SpanID() {
+		t.Fatalf("span of the repository method is not a child of the route")
+	}
+
+	// the records of the request are written with the trace and the caller
+	if !strings.Contains(logs.String(), `"trace_id":"`+traceID+`"`) {
+		t.Fatalf("trace id is not logged: %s", logs.String())
+	}
+	if !strings.Contains(logs.String(), `"user_id":1`) {
+		t.Fatalf("caller is not logged: %s", logs.String())
+	}
+}
@@ -0,0 +1,375 @@
+package server_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/pkg/oidc"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ID of the key the fake issuer signs the ID tokens with
+const issuerKeyID = "test"
+
+// Represents an identity provider that vouches for the identity set
+type fakeIssuer struct {
+	srv *httptest.Server
+	key *rsa.PrivateKey
+
+	mu sync.Mutex
+	// where the authorization endpoint sends the user back to
+	callback string
+	// identity vouched for by the next login
+	email string
+	name  string
+	// started logins by their codes
+	codes map[string]issuedCode
+	// how many times the key set has been fetched
+	keyFetches int
+}
+
+// Represents an authorization code issued by the fake issuer
+type issuedCode struct {
+	clientID  string
+	challenge string
+	nonce     string
+	email     string
+	name      string
+}
+
+// Starts an identity provider serving the discovery document, the key set,
+// the authorization endpoint that logs the user in at once and the token endpoint
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate the signing key: %v", err)
+	}
+
+	i := &fakeIssuer{key: key, codes: make(map[string]issuedCode)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", i.discovery)
+	mux.HandleFunc("GET /keys", i.keys)
+	mux.HandleFunc("GET /authorize", i.authorize)
+	mux.HandleFunc("POST /token", i.token)
+
+	i.srv = httptest.NewServer(mux)
+	t.Cleanup(i.srv.Close)
+
+	return i
+}
+
+// Sets the URL the users are sent back to
+func (i *fakeIssuer) redirectTo(callback string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.callback = callback
+}
+
+// Sets the identity vouched for by the next login
+func (i *fakeIssuer) identify(email, name string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.email = email
+	i.name = name
+}
+
+// Returns how many times the key set has been fetched
+func (i *fakeIssuer) fetches() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.keyFetches
+}
+
+func (i *fakeIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]string{
+		"issuer":                 i.srv.URL,
+		"authorization_endpoint": i.srv.URL + "/authorize",
+		"token_endpoint":         i.srv.URL + "/token",
+		"jwks_uri":               i.srv.URL + "/keys",
+	})
+}
+
+func (i *fakeIssuer) keys(w http.ResponseWriter, r *http.Request) {
+	i.mu.Lock()
+	i.keyFetches++
+	i.mu.Unlock()
+
+	pub := i.key.PublicKey
+
+	writeJSON(w, map[string]any{
+		"keys": []map[string]string{{
+			"kid": issuerKeyID,
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+// Logs the user in without asking anything and sends them back with a code
+func (i *fakeIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	i.mu.Lock()
+	code := oidc.RandomString(16)
+	i.codes[code] = issuedCode{
+		clientID:  q.Get("client_id"),
+		challenge: q.Get("code_challenge"),
+		nonce:     q.Get("nonce"),
+		email:     i.email,
+		name:      i.name,
+	}
+	callback := i.callback
+	i.mu.Unlock()
+
+	back := url.Values{}
+	back.Set("code", code)
+	back.Set("state", q.Get("state"))
+
+	http.Redirect(w, r, callback+"?"+back.Encode(), http.StatusFound)
+}
+
+// Exchanges the code for an ID token once the PKCE verifier matches
+func (i *fakeIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	i.mu.Lock()
+	code, ok := i.codes[r.PostForm.Get("code")]
+	delete(i.codes, r.PostForm.Get("code"))
+	i.mu.Unlock()
+
+	if !ok || oidc.Challenge(r.PostForm.Get("code_verifier")) != code.challenge {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, oidc.Claims{
+		Email: code.email,
+		Name:  code.name,
+		Nonce: code.nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    i.srv.URL,
+			Subject:   code.email,
+			Audience:  jwt.ClaimStrings{code.clientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	})
+	idToken.Header["kid"] = issuerKeyID
+
+	signed, err := idToken.SignedString(i.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]string{"id_token": signed})
+}
+
+// Writes the value as json
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Represents a user of the fake directory
+type directoryEntry struct {
+	DN       string
+	Mail     string
+	Name     string
+	Password string
+	Groups   []string
+}
+
+// Represents an LDAP directory answering the binds and the searches by email
+type fakeDirectory struct {
+	ln net.Listener
+
+	// service account allowed to search
+	bindDN       string
+	bindPassword string
+
+	mu      sync.Mutex
+	entries map[string]directoryEntry
+}
+
+// Starts a directory with the service account passed
+func newFakeDirectory(t *testing.T, bindDN, bindPassword string) *fakeDirectory {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	d := &fakeDirectory{
+		ln:           ln,
+		bindDN:       bindDN,
+		bindPassword: bindPassword,
+		entries:      make(map[string]directoryEntry),
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go d.serve(conn)
+		}
+	}()
+
+	return d
+}
+
+// Returns the URL of the directory
+func (d *fakeDirectory) url() string {
+	return "ldap://" + d.ln.Addr().String()
+}
+
+// Adds or replaces the user
+func (d *fakeDirectory) put(e directoryEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.entries[e.Mail] = e
+}
+
+// Answers the requests of the connection until it is closed
+func (d *fakeDirectory) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		id, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			name, _ := op.Children[1].Value.(string)
+
+			code := uint16(ldap.LDAPResultInvalidCredentials)
+			if d.bind(name, op.Children[2].Data.String()) {
+				code = ldap.LDAPResultSuccess
+			}
+
+			conn.Write(ldapResult(id, ldap.ApplicationBindResponse, code).Bytes())
+
+		case ldap.ApplicationSearchRequest:
+			filter, err := ldap.DecompileFilter(op.Children[6])
+			if err != nil {
+				return
+			}
+
+			d.mu.Lock()
+			for _, e := range d.entries {
+				if filter == "(mail="+ldap.EscapeFilter(e.Mail)+")" {
+					conn.Write(searchEntry(id, e).Bytes())
+				}
+			}
+			d.mu.Unlock()
+
+			conn.Write(ldapResult(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess).Bytes())
+
+		default:
+			// unbinding or anything not needed for logging in
+			return
+		}
+	}
+}
+
+// Reports whether the credentials are the ones of the service account or of a user
+func (d *fakeDirectory) bind(dn, password string) bool {
+	if dn == d.bindDN {
+		return password == d.bindPassword
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, e := range d.entries {
+		if e.DN == dn {
+			return password == e.Password
+		}
+	}
+
+	return false
+}
+
+// Encodes a message of the operation with the result code
+func ldapResult(id int64, op ber.Tag, code uint16) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, op, nil, "")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+
+	return ldapMessage(id, result)
+}
+
+// Encodes a search result with the user
+func searchEntry(id int64, e directoryEntry) *ber.Packet {
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+
+	for name, values := range map[string][]string{
+		"mail":        {e.Mail},
+		"displayName": {e.Name},
+		"memberOf":    e.Groups,
+	} {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, v := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+		}
+		attr.AppendChild(set)
+
+		attrs.AppendChild(attr)
+	}
+
+	entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+	entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, ""))
+	entry.AppendChild(attrs)
+
+	return ldapMessage(id, entry)
+}
+
+// Wraps the operation into a message with the ID
+func ldapMessage(id int64, op *ber.Packet) *ber.Packet {
+	msg := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+	msg.AppendChild(op)
+
+	return msg
+}
@@ -0,0 +1,201 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+	"github.com/cyberbrain-dev/na-meste-api/internal/tracing"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/retry"
+	"github.com/cyberbrain-dev/na-meste-api/pkg/webhook"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name of the instrumentation of the dispatcher
+const tracerName = "github.com/cyberbrain-dev/na-meste-api/internal/webhooks"
+
+// Represents the settings of the dispatcher
+type Options struct {
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Timeout     time.Duration
+	// Whether the deliveries may go to the loopback or a private network
+	AllowPrivate bool
+	// Source of the current time, time.Now if nil
+	Clock func() time.Time
+}
+
+// Sends the pending deliveries of the outbox
+type Dispatcher struct {
+	logger *slog.Logger
+	repo   abstractions.WebhooksRepo
+	opts   Options
+	client *http.Client
+}
+
+// Creates a new dispatcher
+func NewDispatcher(logger *slog.Logger, repo abstractions.WebhooksRepo, opts Options) *Dispatcher {
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+
+	// the addresses are checked once the names are resolved,
+	// so a receiver cannot point its name at the internal services later
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivate {
+		dialer.Control = webhook.DialControl
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		logger: logger.With(slog.String("job", "webhooks.Dispatcher")),
+		repo:   repo,
+		opts:   opts,
+		client: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: transport,
+			// a redirect is a failed delivery, it could lead anywhere
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Runs the dispatcher until the context is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.DispatchDue(ctx, d.opts.Clock()); err != nil {
+			d.logger.ErrorContext(ctx, "failed to dispatch the deliveries", slog.Any("err", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sends a batch of the deliveries that are due
+func (d *Dispatcher) DispatchDue(ctx context.Context, now time.Time) error {
+	// a claimed delivery is retried after the lease if the instance dies.
+	// The batch is sent one by one, so the lease covers every delivery of it timing out
+	lease := d.opts.Timeout * time.Duration(d.opts.BatchSize+1)
+
+	deliveries, err := d.repo.ClaimDue(ctx, now, d.opts.BatchSize, lease)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		d.deliver(ctx, delivery)
+	}
+
+	return nil
+}
+
+// Sends the delivery and records the result
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	// the logs and the queries of the delivery share its trace
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "webhook "+delivery.Event,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.Int64("webhook.delivery_id", int64(delivery.ID)),
+			attribute.Int("webhook.attempt", delivery.Attempts+1),
+		),
+	)
+
+	statusCode, err := d.send(ctx, delivery)
+	defer func() { tracing.End(span, err) }()
+
+	logger := d.logger.With(
+		slog.Any("delivery_id", delivery.ID),
+		slog.String("event", delivery.Event),
+	)
+
+	if err == nil {
+		if err := d.repo.MarkDelivered(ctx, delivery.ID, statusCode); err != nil {
+			logger.ErrorContext(ctx, "cannot mark the delivery as delivered", slog.Any("err", err))
+		}
+
+		return
+	}
+
+	// scheduling the retry or giving up
+	attempts := delivery.Attempts + 1
+
+	var next *time.Time
+	if attempts < d.opts.MaxAttempts {
+		t := d.opts.Clock().Add(retry.Backoff(attempts, d.opts.BaseBackoff, d.opts.MaxBackoff))
+		next = &t
+	}
+
+	logger.WarnContext(
+		ctx,
+		"delivery attempt failed",
+		slog.Int("attempts", attempts),
+		slog.Bool("retrying", next != nil),
+		slog.Any("err", err),
+	)
+
+	if err := d.repo.MarkAttemptFailed(ctx, delivery.ID, statusCode, err.Error(), next); err != nil {
+		logger.ErrorContext(ctx, "cannot record the failed attempt", slog.Any("err", err))
+	}
+}
+
+// Posts the signed payload, any response but 2xx is a failure
+func (d *Dispatcher) send(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("cannot create the request: %w", err)
+	}
+
+	now := time.Now()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "na-meste-api-webhooks")
+	req.Header.Set(webhook.HeaderEvent, delivery.Event)
+	req.Header.Set(webhook.HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(delivery.Secret, now, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// reading the body, so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,98 @@
+package webhooks_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/database/memory"
+	"github.com/cyberbrain-dev/na-meste-api/internal/models"
+	"github.com/cyberbrain-dev/na-meste-api/internal/webhooks"
+)
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	s := memory.NewStore()
+	repo := memory.NewWebhooks(s)
+
+	college := &models.College{Name: "college"}
+	if err := memory.NewColleges(s).Create(ctx, college); err != nil {
+		t.Fatalf("cannot seed the college: %v", err)
+	}
+
+	// the receiver redirects the first path to the second one
+	var hits, redirected atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /hook", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("POST /internal", func(w http.ResponseWriter, r *http.Request) {
+		redirected.Add(1)
+	})
+
+	receiver := httptest.NewServer(mux)
+	t.Cleanup(receiver.Close)
+
+	sub := &models.WebhookSubscription{
+		CollegeID: college.ID,
+		URL:       receiver.URL + "/hook",
+		Secret:    "secret",
+		Events:    []string{models.EventAttendanceCreated},
+	}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatalf("cannot seed the subscription: %v", err)
+	}
+
+	// the failed deliveries are not retried, so every dispatch sends only the new one
+	opts := webhooks.Options{
+		BatchSize:   10,
+		MaxAttempts: 1,
+		Timeout:     time.Second,
+	}
+
+	// sends a single event and returns its delivery
+	dispatch := func(opts webhooks.Options) *models.WebhookDelivery {
+		t.Helper()
+
+		if _, err := repo.Enqueue(ctx, college.ID, models.EventAttendanceCreated, []byte("{}")); err != nil {
+			t.Fatalf("cannot enqueue the delivery: %v", err)
+		}
+
+		if err := webhooks.NewDispatcher(logger, repo, opts).DispatchDue(ctx, time.Now()); err != nil {
+			t.Fatalf("cannot dispatch the deliveries: %v", err)
+		}
+
+		deliveries, err := repo.GetDeliveries(ctx, sub.ID, 1)
+		if err != nil || len(deliveries) != 1 {
+			t.Fatalf("cannot get the delivery: %v", err)
+		}
+
+		return deliveries[0]
+	}
+
+	// the receiver on the loopback is never reached
+	d := dispatch(opts)
+	if hits.Load() != 0 || !strings.Contains(d.LastError, "private") {
+		t.Errorf("got %d requests and the error %q, want the loopback refused", hits.Load(), d.LastError)
+	}
+
+	// the redirects are not followed
+	opts.AllowPrivate = true
+
+	d = dispatch(opts)
+	if hits.Load() != 1 || redirected.Load() != 0 {
+		t.Errorf("got %d requests and %d redirected ones, want 1 and 0", hits.Load(), redirected.Load())
+	}
+	if d.Status != models.DeliveryStatusFailed || d.LastStatusCode != http.StatusTemporaryRedirect {
+		t.Errorf("got the delivery %s with %d, want a failed attempt with the redirect", d.Status, d.LastStatusCode)
+	}
+}
@@ -0,0 +1,61 @@
+// Contains the publishing of the domain events to the webhooks
+// and the background job delivering them
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cyberbrain-dev/na-meste-api/internal/models/abstractions"
+)
+
+// Represents the body of a delivery
+type envelope struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	CollegeID  uint      `json:"college_id"`
+	Data       any       `json:"data"`
+}
+
+// Writes the events to the outbox of the subscribed webhooks
+type Publisher struct {
+	repo abstractions.WebhooksRepo
+}
+
+// Creates a new publisher writing to the repo passed
+func NewPublisher(repo abstractions.WebhooksRepo) *Publisher {
+	return &Publisher{repo: repo}
+}
+
+// Publishes the event of the college with the data passed
+func (p *Publisher) Publish(ctx context.Context, collegeID uint, event string, data any) error {
+	payload, err := json.Marshal(envelope{
+		ID:         newEventID(),
+		Event:      event,
+		OccurredAt: time.Now().UTC(),
+		CollegeID:  collegeID,
+		Data:       data,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot marshal the %s event: %w", event, err)
+	}
+
+	if _, err := p.repo.Enqueue(ctx, collegeID, event, payload); err != nil {
+		return fmt.Errorf("cannot publish the %s event: %w", event, err)
+	}
+
+	return nil
+}
+
+// Returns a random ID of an event
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -1,26 +1,40 @@
 package authentication
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Contains payload of a JWT
+// Contains payload of a JWT,
+// the ID of the login session is kept in the jti claim
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	UserID    uint     `json:"user_id"`
+	Role      string   `json:"role"`
+	CollegeID uint     `json:"college_id,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// Generates a JWT with the role encoded
-func GenerateJWT(id uint, role string) (string, error) {
+// Generates a JWT of a new login session with the role and the college encoded.
+// The token is restricted to the scopes if any are given
+func GenerateJWT(id uint, role string, collegeID uint, scopes ...string) (string, error) {
+	sessionID, err := newSessionID()
+	if err != nil {
+		return "", err
+	}
+
 	// creating a payload
 	claims := Claims{
-		UserID: id,
-		Role:   role,
+		UserID:    id,
+		Role:      role,
+		CollegeID: collegeID,
+		Scopes:    scopes,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 1)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "na-meste-api",
@@ -55,3 +69,13 @@ func ParseJWT(tokenString string) (*Claims, error) {
 
 	return nil, fmt.Errorf("invalid or expired token")
 }
+
+// Generates a random ID of a login session
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate the session id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -5,22 +5,65 @@ import (
 	"fmt"
 	"strings"
 
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 )
 
+// Represents a validation error of a single field
+type FieldError struct {
+	// Name of the field as the client sent it
+	Field string `json:"field"`
+	// Validation tag that failed like "required"
+	Tag string `json:"tag"`
+	// Parameter of the tag like "3" in "min=3"
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
 func ValidationErrorsToString(errs validator.ValidationErrors) string {
 	var errMsgs []string
 
 	for _, err := range errs {
-		switch err.ActualTag() {
-		case "required":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is a required field", err.Field()))
-		case "url":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a valid URL", err.Field()))
-		default:
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not valid", err.Field()))
-		}
+		errMsgs = append(errMsgs, message(err))
 	}
 
 	return strings.Join(errMsgs, ", ")
 }
+
+// Turns the validation errors into the errors of the fields.
+// The messages are translated if the translator is set
+// and has the tag, otherwise they are in English
+func ValidationErrorsToFields(errs validator.ValidationErrors, trans ut.Translator) []FieldError {
+	fields := make([]FieldError, 0, len(errs))
+
+	for _, err := range errs {
+		msg := message(err)
+		if trans != nil {
+			// an unknown tag is translated to the raw error
+			if translated := err.Translate(trans); translated != err.Error() {
+				msg = translated
+			}
+		}
+
+		fields = append(fields, FieldError{
+			Field:   err.Field(),
+			Tag:     err.ActualTag(),
+			Param:   err.Param(),
+			Message: msg,
+		})
+	}
+
+	return fields
+}
+
+// Describes the error of the field
+func message(err validator.FieldError) string {
+	switch err.ActualTag() {
+	case "required":
+		return fmt.Sprintf("field %s is a required field", err.Field())
+	case "url":
+		return fmt.Sprintf("field %s is not a valid URL", err.Field())
+	default:
+		return fmt.Sprintf("field %s is not valid", err.Field())
+	}
+}
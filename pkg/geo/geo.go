@@ -0,0 +1,116 @@
+// Contains tools for checking locations against geofences
+package geo
+
+import "math"
+
+// Mean radius of the Earth in meters
+const earthRadius = 6371000.0
+
+// Represents a result of checking a location against a fence
+type Verdict string
+
+const (
+	// No location has been sent or there's no fence to check against
+	VerdictUnknown Verdict = "unknown"
+	// The location is inside the fence even with its accuracy
+	VerdictInside Verdict = "inside"
+	// The location is outside the fence even with its accuracy
+	VerdictOutside Verdict = "outside"
+	// The accuracy circle crosses the boundary of the fence
+	VerdictUncertain Verdict = "uncertain"
+)
+
+// Represents a point on the Earth
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Represents a geofence: either a circle or a polygon
+type Fence struct {
+	// Center and radius (in meters) of a circular fence
+	Center *Point  `json:"center,omitempty"`
+	Radius float64 `json:"radius,omitempty"`
+	// Vertices of a polygonal fence
+	Polygon []Point `json:"polygon,omitempty"`
+}
+
+// Checks the location with the accuracy (in meters) against the fence
+func (f *Fence) Check(p Point, accuracy float64) Verdict {
+	if f == nil || (f.Center == nil && len(f.Polygon) < 3) {
+		return VerdictUnknown
+	}
+
+	d := f.signedDistance(p)
+
+	switch {
+	case d+accuracy <= 0:
+		return VerdictInside
+	case d-accuracy > 0:
+		return VerdictOutside
+	default:
+		return VerdictUncertain
+	}
+}
+
+// Returns the distance (in meters) from the point to the boundary of the fence,
+// the distance is negative if the point is inside the fence
+func (f *Fence) signedDistance(p Point) float64 {
+	if f.Center != nil {
+		return Distance(*f.Center, p) - f.Radius
+	}
+
+	// projecting the polygon to a plane with the point at the origin
+	// (precise enough for fences of a campus size)
+	type xy struct{ x, y float64 }
+
+	latScale := earthRadius * math.Pi / 180
+	lngScale := latScale * math.Cos(p.Latitude*math.Pi/180)
+
+	vertices := make([]xy, len(f.Polygon))
+	for i, v := range f.Polygon {
+		vertices[i] = xy{
+			x: (v.Longitude - p.Longitude) * lngScale,
+			y: (v.Latitude - p.Latitude) * latScale,
+		}
+	}
+
+	inside := false
+	minDist := math.Inf(1)
+
+	for i, j := 0, len(vertices)-1; i < len(vertices); j, i = i, i+1 {
+		a, b := vertices[j], vertices[i]
+
+		// ray casting along the positive x axis
+		if (a.y > 0) != (b.y > 0) && a.x+(0-a.y)*(b.x-a.x)/(b.y-a.y) > 0 {
+			inside = !inside
+		}
+
+		// distance from the origin to the edge
+		dx, dy := b.x-a.x, b.y-a.y
+		t := 0.0
+		if l := dx*dx + dy*dy; l > 0 {
+			t = math.Max(0, math.Min(1, -(a.x*dx+a.y*dy)/l))
+		}
+		minDist = math.Min(minDist, math.Hypot(a.x+t*dx, a.y+t*dy))
+	}
+
+	if inside {
+		return -minDist
+	}
+
+	return minDist
+}
+
+// Returns the great-circle distance between two points in meters
+func Distance(a, b Point) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,178 @@
+// Contains tools for authenticating users against LDAP / Active Directory
+package ldapauth
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+var (
+	// Returned when the user is not found or the password is wrong
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// Returned when none of the user's groups is mapped to a role
+	ErrNoRole = errors.New("no role is mapped to the user's groups")
+)
+
+// Represents a mapping of a directory group to a role
+type GroupRole struct {
+	Group string
+	Role  string
+}
+
+// Represents a config of a directory
+type Config struct {
+	URL      string
+	StartTLS bool
+
+	BindDN       string
+	BindPassword string
+
+	BaseDN         string
+	UserFilter     string
+	NameAttribute  string
+	GroupAttribute string
+
+	GroupRoles  []GroupRole
+	DefaultRole string
+
+	Timeout time.Duration
+}
+
+// Represents a user authenticated by the directory
+type Identity struct {
+	DN     string
+	Email  string
+	Name   string
+	Groups []string
+	Role   string
+}
+
+// Authenticates users with an LDAP bind
+type Authenticator struct {
+	cfg Config
+}
+
+// Creates a new authenticator of the directory
+func NewAuthenticator(cfg Config) *Authenticator {
+	return &Authenticator{cfg: cfg}
+}
+
+// Looks the user up with the service account, binds as them
+// to check the password and maps their groups to a role
+func (a *Authenticator) Authenticate(email, password string) (*Identity, error) {
+	// an empty password makes an unauthenticated bind that always "succeeds"
+	if email == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := a.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// binding as the service account for the search
+	if a.cfg.BindDN != "" {
+		if err := conn.Bind(a.cfg.BindDN, a.cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("service bind failed: %w", err)
+		}
+	}
+
+	// looking the user up
+	search := ldap.NewSearchRequest(
+		a.cfg.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2, // the email must be unique, so two results are already an error
+		int(a.cfg.Timeout.Seconds()),
+		false,
+		fmt.Sprintf(a.cfg.UserFilter, ldap.EscapeFilter(email)),
+		[]string{"dn", "mail", a.cfg.NameAttribute, a.cfg.GroupAttribute},
+		nil,
+	)
+
+	result, err := conn.Search(search)
+	if err != nil {
+		return nil, fmt.Errorf("user search failed: %w", err)
+	}
+
+	if len(result.Entries) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+
+	entry := result.Entries[0]
+
+	// checking the password by binding as the user
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+
+		return nil, fmt.Errorf("user bind failed: %w", err)
+	}
+
+	identity := Identity{
+		DN:     entry.DN,
+		Email:  entry.GetAttributeValue("mail"),
+		Name:   entry.GetAttributeValue(a.cfg.NameAttribute),
+		Groups: entry.GetAttributeValues(a.cfg.GroupAttribute),
+	}
+
+	if identity.Email == "" {
+		identity.Email = email
+	}
+
+	identity.Role = a.role(identity.Groups)
+	if identity.Role == "" {
+		return nil, ErrNoRole
+	}
+
+	return &identity, nil
+}
+
+// Returns the role of the first mapped group or the default one
+func (a *Authenticator) role(groups []string) string {
+	for _, gr := range a.cfg.GroupRoles {
+		for _, g := range groups {
+			if strings.EqualFold(gr.Group, g) {
+				return gr.Role
+			}
+		}
+	}
+
+	return a.cfg.DefaultRole
+}
+
+// Connects to the directory
+func (a *Authenticator) dial() (*ldap.Conn, error) {
+	u, err := url.Parse(a.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid directory URL: %w", err)
+	}
+
+	conn, err := ldap.DialURL(
+		a.cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: a.cfg.Timeout}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the directory: %w", err)
+	}
+
+	conn.SetTimeout(a.cfg.Timeout)
+
+	if a.cfg.StartTLS {
+		if err := conn.StartTLS(&tls.Config{ServerName: u.Hostname()}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("cannot start TLS: %w", err)
+		}
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Keeps the messages in memory instead of sending them,
+// for tests and local runs
+type Fake struct {
+	logger *slog.Logger
+
+	mu   sync.Mutex
+	sent []Message
+
+	// returned by Send if set
+	Err error
+}
+
+// Creates a new fake sender, the messages are logged if the logger is not nil
+func NewFake(logger *slog.Logger) *Fake {
+	return &Fake{logger: logger}
+}
+
+// Records the message
+func (f *Fake) Send(ctx context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+
+	f.sent = append(f.sent, msg)
+
+	if f.logger != nil {
+		f.logger.InfoContext(
+			ctx,
+			"fake notification",
+			slog.String("to", msg.To),
+			slog.String("subject", msg.Subject),
+			slog.String("body", msg.Body),
+		)
+	}
+
+	return nil
+}
+
+// Returns the messages recorded so far
+func (f *Fake) Sent() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Message(nil), f.sent...)
+}
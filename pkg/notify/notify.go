@@ -0,0 +1,17 @@
+// Contains the channels notifications are sent through
+package notify
+
+import "context"
+
+// Represents a message to a recipient
+type Message struct {
+	// Address of the recipient in the channel: an email or a chat ID
+	To      string
+	Subject string
+	Body    string
+}
+
+// Represents a channel delivering the messages
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
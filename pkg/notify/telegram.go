@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Default address of the Telegram Bot API
+const TelegramAPI = "https://api.telegram.org"
+
+// Sends the messages to Telegram chats through the Bot API
+type Telegram struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+// Creates a new Telegram sender of the bot,
+// the default API is used if apiURL is empty
+func NewTelegram(apiURL string, token string, timeout time.Duration) *Telegram {
+	if apiURL == "" {
+		apiURL = TelegramAPI
+	}
+
+	return &Telegram{
+		apiURL: apiURL,
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Sends the message to the chat with the ID in its To
+func (t *Telegram) Send(ctx context.Context, msg Message) error {
+	text := msg.Body
+	if msg.Subject != "" {
+		text = msg.Subject + "\n\n" + msg.Body
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"chat_id": msg.To,
+		"text":    text,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", t.apiURL, t.token)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// the URL holds the token, so it is not returned
+		return fmt.Errorf("cannot reach the Bot API")
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := json.Unmarshal(data, &result); err != nil || !result.OK {
+		return fmt.Errorf("Bot API responded with %d: %s", resp.StatusCode, result.Description)
+	}
+
+	return nil
+}
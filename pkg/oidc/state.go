@@ -0,0 +1,115 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Contains the data of a login flow that has been started
+type Flow struct {
+	CollegeID uint
+	Verifier  string
+	Nonce     string
+}
+
+// Represents a started login flow as it is sealed
+type sealedFlow struct {
+	State     string `json:"s"`
+	CollegeID uint   `json:"c"`
+	Verifier  string `json:"v"`
+	Nonce     string `json:"n"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// Seals the started login flows with a key, so they are kept by the browser
+// instead of the server and any instance sharing the key can finish them
+type StateStore struct {
+	key []byte
+	ttl time.Duration
+}
+
+// Creates a new state store with the signing key and the TTL of the flows passed.
+// A random key is used if none is passed, so only this instance can finish the flows
+func NewStateStore(key []byte, ttl time.Duration) *StateStore {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+
+	return &StateStore{key: key, ttl: ttl}
+}
+
+// Starts a new login flow and returns its state
+// along with the sealed flow to keep until the callback
+func (s *StateStore) Start(collegeID uint) (string, Flow, string) {
+	state := RandomString(32)
+	flow := Flow{
+		CollegeID: collegeID,
+		Verifier:  RandomString(32),
+		Nonce:     RandomString(16),
+	}
+
+	payload, _ := json.Marshal(sealedFlow{
+		State:     state,
+		CollegeID: flow.CollegeID,
+		Verifier:  flow.Verifier,
+		Nonce:     flow.Nonce,
+		ExpiresAt: time.Now().Add(s.ttl).Unix(),
+	})
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return state, flow, encoded + "." + s.sign(encoded)
+}
+
+// Opens the sealed flow and returns it
+// if it has not expired and has been started with the state passed
+func (s *StateStore) Finish(state, sealed string) (Flow, bool) {
+	encoded, signature, ok := strings.Cut(sealed, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return Flow{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Flow{}, false
+	}
+
+	var f sealedFlow
+	if err := json.Unmarshal(payload, &f); err != nil {
+		return Flow{}, false
+	}
+
+	if state == "" || !hmac.Equal([]byte(f.State), []byte(state)) || time.Now().Unix() > f.ExpiresAt {
+		return Flow{}, false
+	}
+
+	return Flow{CollegeID: f.CollegeID, Verifier: f.Verifier, Nonce: f.Nonce}, true
+}
+
+// Returns the signature of the encoded flow
+func (s *StateStore) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(encoded))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Returns the PKCE S256 code challenge of the verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Returns a URL-safe random string made of n random bytes
+func RandomString(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
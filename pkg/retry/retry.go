@@ -0,0 +1,22 @@
+// Contains the scheduling of the retries of the failed attempts
+package retry
+
+import "time"
+
+// Returns the delay before the retry after the attempts made:
+// base, 2*base, 4*base... but never more than max
+func Backoff(attempts int, base time.Duration, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+
+	if delay > max {
+		return max
+	}
+
+	return delay
+}
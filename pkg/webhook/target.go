@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// Returned when a delivery would be sent to the loopback or a private network
+var ErrPrivateTarget = errors.New("target is a loopback or private address")
+
+// Shared address space of the carrier-grade NATs, which IsPrivate does not cover
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Reports whether the address is a public unicast one
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// Checks the URL a subscription is created with.
+// Only the literal addresses and localhost are known before the delivery,
+// the names are checked once they are resolved by the dialer
+func CheckTarget(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateTarget
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil && !IsPublic(addr) {
+		return ErrPrivateTarget
+	}
+
+	return nil
+}
+
+// Refuses the connections to the addresses that are not public,
+// meant to be the Control of a net.Dialer
+func DialControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	if !IsPublic(addr) {
+		return fmt.Errorf("%w: %s", ErrPrivateTarget, addr)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+// Contains tools for signing webhook payloads
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Na-Meste-Event"
+	HeaderDelivery  = "X-Na-Meste-Delivery"
+	HeaderTimestamp = "X-Na-Meste-Timestamp"
+	HeaderSignature = "X-Na-Meste-Signature"
+)
+
+// Number of the random bytes of a secret
+const secretSize = 32
+
+// Returns a new random secret of a subscription
+func NewSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("cannot generate the secret: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Signs the payload sent at the moment passed with the subscription's secret.
+// The timestamp is signed too, so a receiver can reject the replayed deliveries
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Checks the signature of the payload in constant time
+func Verify(secret string, timestamp time.Time, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}